@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -8,10 +9,18 @@ import (
 	"todo-app/internal/auth"
 	"todo-app/internal/config"
 	"todo-app/internal/controller"
+	"todo-app/internal/events"
+	"todo-app/internal/netguard"
+	"todo-app/internal/notifier"
 	"todo-app/internal/repository"
 	"todo-app/internal/routes"
 	"todo-app/internal/service"
+	"todo-app/internal/worker"
 	"todo-app/pkg/database"
+	"todo-app/pkg/middleware"
+
+	// Embed the time zone database so reminders work on hosts without one
+	_ "time/tzdata"
 
 	_ "todo-app/docs"
 
@@ -47,14 +56,40 @@ func main() {
 	// Initialize repositories
 	todoRepo := repository.NewTodoRepository(mongoDB.Database, "todos")
 	userRepo := repository.NewUserRepository(mongoDB.Database, "users")
+	labelRepo := repository.NewLabelRepository(mongoDB.Database, "labels", "todos")
+	listRepo := repository.NewListRepository(mongoDB.Database, "lists", "todos")
+	workflowRepo := repository.NewWorkflowRepository(mongoDB.Database, "workflows")
+	revisionRepo := repository.NewRevisionRepository(mongoDB.Database, "revisions", "todos")
+	idempotencyRepo := repository.NewIdempotencyRepository(mongoDB.Database, "idempotency_keys")
+	calendarFeedRepo := repository.NewCalendarFeedRepository(mongoDB.Database, "calendar_feeds")
+	webhookRepo := repository.NewWebhookRepository(mongoDB.Database, "webhooks")
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(mongoDB.Database, "webhook_deliveries")
 
 	// Initialize services
-	authService := auth.NewAuthService(cfg.JWTSecret, cfg.JWTExpiration, cfg.PasswordPepper, userRepo)
-	todoService := service.NewTodoService(todoRepo)
+	authService := auth.NewAuthService(cfg.JWTSecret, cfg.JWTExpiration, cfg.PasswordPepper, userRepo, listRepo)
+	webhookGuard, err := netguard.New(cfg.WebhookAllowedNetworks)
+	if err != nil {
+		log.Fatalf("Failed to configure webhook networks: %v", err)
+	}
+	webhookService := service.NewWebhookService(webhookRepo, webhookDeliveryRepo, webhookGuard)
+	eventBus := events.NewBus(cfg.StreamHistory, cfg.StreamBuffer, cfg.StreamMaxPerUser)
+	todoService := service.NewTodoService(todoRepo, userRepo, labelRepo, listRepo, workflowRepo, revisionRepo, webhookService, eventBus)
+	labelService := service.NewLabelService(labelRepo)
+	listService := service.NewListService(listRepo, todoService)
+	workflowService := service.NewWorkflowService(workflowRepo, listRepo, todoRepo)
+	calendarService := service.NewCalendarService(todoRepo, labelRepo, calendarFeedRepo)
+	caldavService := service.NewCalDAVService(todoService, todoRepo, listRepo, labelRepo, userRepo)
 
 	// Initialize controllers
 	authController := controller.NewAuthController(authService)
-	todoController := controller.NewTodoController(todoService)
+	todoController := controller.NewTodoController(todoService, cfg.RequireIfMatch)
+	labelController := controller.NewLabelController(labelService)
+	listController := controller.NewListController(listService)
+	workflowController := controller.NewWorkflowController(workflowService)
+	calendarController := controller.NewCalendarController(calendarService, cfg.PublicURL)
+	caldavController := controller.NewCalDAVController(caldavService)
+	webhookController := controller.NewWebhookController(webhookService)
+	streamController := controller.NewStreamController(eventBus, cfg.StreamHeartbeat)
 
 	// Set up Gin
 	if cfg.TestMode {
@@ -66,11 +101,43 @@ func main() {
 	router := gin.New()
 
 	// Set up routes
-	routes.SetupRoutes(router, authController, todoController, authService)
+	routes.SetupRoutes(router, routes.Deps{
+		AuthController:     authController,
+		TodoController:     todoController,
+		LabelController:    labelController,
+		ListController:     listController,
+		WorkflowController: workflowController,
+		CalendarController: calendarController,
+		CalDAVController:   caldavController,
+		WebhookController:  webhookController,
+		StreamController:   streamController,
+		AuthService:        authService,
+		Idempotency:        middleware.Idempotency(idempotencyRepo, cfg.IdempotencyTTL),
+	})
 
 	// Setup Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	reminderNotifier, err := notifier.New(cfg.ReminderNotifier, cfg.ReminderWebhookURL)
+	if err != nil {
+		log.Fatalf("Failed to configure reminder notifier: %v", err)
+	}
+	reminderScheduler := worker.NewReminderScheduler(todoRepo, userRepo, reminderNotifier, cfg.ReminderMaxAttempts, cfg.ReminderBackoff, cfg.ReminderInterval)
+	go reminderScheduler.Start(workerCtx)
+
+	trashPurger := worker.NewTrashPurger(todoRepo, cfg.TrashRetention, cfg.TrashPurgeInterval)
+	go trashPurger.Start(workerCtx)
+
+	rankRebalancer := worker.NewRankRebalancer(todoRepo, cfg.RankMaxLength, cfg.RankRebalanceInterval)
+	go rankRebalancer.Start(workerCtx)
+
+	webhookDispatcher := worker.NewWebhookDispatcher(webhookDeliveryRepo, webhookRepo, webhookGuard, cfg.WebhookMaxAttempts, cfg.WebhookBackoff, cfg.WebhookInterval)
+	go webhookDispatcher.Start(workerCtx)
+
 	// Start server
 	go func() {
 		if err := router.Run(cfg.ServerPort); err != nil {
@@ -85,4 +152,5 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWorkers()
 }
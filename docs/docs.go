@@ -18,6 +18,20 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/caldav": {
+            "get": {
+                "description": "Redirect CalDAV clients looking for the server (RFC 6764) to where it is mounted",
+                "tags": [
+                    "caldav"
+                ],
+                "summary": "Discover the CalDAV server",
+                "responses": {
+                    "301": {
+                        "description": "Moved Permanently"
+                    }
+                }
+            }
+        },
         "/auth/login": {
             "post": {
                 "description": "Logs a user in and returns a JWT token",
@@ -67,6 +81,57 @@ const docTemplate = `{
                 }
             }
         },
+        "/auth/me": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the settings of the authenticated user. The time zone must be an IANA name and applies to todos created from then on, quick add and stats; existing todos keep the zone they were created in.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Update the signed-in user",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/register": {
             "post": {
                 "description": "Create a new user account",
@@ -107,28 +172,229 @@ const docTemplate = `{
                 }
             }
         },
-        "/todos": {
+        "/calendar/feed": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve all todos for the authenticated user",
+                "description": "Tell whether the authenticated user has a calendar feed and when it was created. The feed URL is only shown when it is created.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "calendar"
                 ],
-                "summary": "Get all todos",
+                "summary": "Get the calendar feed",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CalendarFeed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a secret URL calendar apps can subscribe to without signing in. Creating a feed again replaces the URL, so the old one stops working. Keep the URL private: anyone holding it can read the todos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Create a calendar feed",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.CalendarFeed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop the authenticated user's calendar feed URL from working",
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Revoke the calendar feed",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/feed/{token}": {
+            "get": {
+                "description": "The calendar of the user a feed token belongs to, for calendar apps to subscribe to without signing in. Takes the same query as /todos.ics. Revoked or replaced tokens get 404.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Get a subscribed calendar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token, optionally followed by .ics",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "vtodo",
+                            "vevent"
+                        ],
+                        "type": "string",
+                        "description": "Only include one kind of component",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include todos with this completion state",
+                        "name": "completed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The client's copy is current"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dav/{path}": {
+            "get": {
+                "description": "A CalDAV (RFC 4791) subset for two-way sync with calendar and task apps, authenticated with HTTP Basic using the account's email and password. Each list that is not archived is a calendar collection at /dav/calendars/{listId}/ holding a VTODO resource per todo. Supports OPTIONS, PROPFIND, REPORT (calendar-query and calendar-multiget), and GET, PUT and DELETE of resources with ETags and If-Match. The principal is at /dav/principal/ and the calendar home at /dav/calendars/. Recurrence, reminders and subtask parents are not synced and are kept when a client replaces a todo.",
+                "consumes": [
+                    "text/xml",
+                    "text/calendar"
+                ],
+                "produces": [
+                    "text/xml",
+                    "text/calendar"
+                ],
+                "tags": [
+                    "caldav"
+                ],
+                "summary": "CalDAV server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource below /dav",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "207": {
+                        "description": "Multistatus",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/labels": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all labels of the authenticated user, sorted by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Get all labels",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Todo"
+                                "$ref": "#/definitions/model.Label"
                             }
                         }
                     }
@@ -140,7 +406,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new todo item for the authenticated user",
+                "description": "Create a new label for the authenticated user. Names are unique per user, ignoring case.",
                 "consumes": [
                     "application/json"
                 ],
@@ -148,17 +414,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "labels"
                 ],
-                "summary": "Create a new todo",
+                "summary": "Create a label",
                 "parameters": [
                     {
-                        "description": "Todo details",
-                        "name": "todo",
+                        "description": "Label details",
+                        "name": "label",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.TodoCreate"
+                            "$ref": "#/definitions/model.LabelCreate"
                         }
                     }
                 ],
@@ -166,31 +432,49 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Todo"
+                            "$ref": "#/definitions/model.Label"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/todos/{id}": {
+        "/labels/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a todo item by ID",
+                "description": "Get a label by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "labels"
                 ],
-                "summary": "Get a single todo",
+                "summary": "Get a single label",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Todo ID",
+                        "description": "Label ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -200,7 +484,16 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Todo"
+                            "$ref": "#/definitions/model.Label"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
@@ -211,7 +504,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a todo item by ID",
+                "description": "Update a label. Todos reference labels by ID, so a rename applies to every todo at once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,24 +512,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "labels"
                 ],
-                "summary": "Update a todo",
+                "summary": "Rename or recolour a label",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Todo ID",
+                        "description": "Label ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated todo data",
-                        "name": "todo",
+                        "description": "New name and/or colour",
+                        "name": "label",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.TodoUpdate"
+                            "$ref": "#/definitions/model.LabelUpdate"
                         }
                     }
                 ],
@@ -244,126 +537,4344 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Todo"
+                            "$ref": "#/definitions/model.Label"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Delete a todo item by ID",
-                "tags": [
-                    "todos"
-                ],
-                "summary": "Delete a todo",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Todo ID",
-                        "name": "id",
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a label and detach it from every todo",
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Delete a label",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Label ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/labels/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the label with the target label on every todo, then delete it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Merge a label into another",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the label to merge away",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target label",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.LabelMerge"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Label"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/lists": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the lists of the authenticated user, Inbox first, then by sort order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Get all lists",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Include archived lists",
+                        "name": "archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.List"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new list for grouping todos",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Create a list",
+                "parameters": [
+                    {
+                        "description": "List details",
+                        "name": "list",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ListCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.List"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/lists/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Get a single list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.List"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename, re-icon, reorder, archive or unarchive a list. The Inbox cannot be archived.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Update a list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "List ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Changes to apply",
+                        "name": "list",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ListUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.List"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a list. Its todos move to the Inbox, or to the trash with todos=delete. The Inbox cannot be deleted.",
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Delete a list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "move",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "default": "move",
+                        "description": "What to do with the list's todos",
+                        "name": "todos",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/lists/{id}/todos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a page of the todos in a list. Accepts the same filtering, sorting and pagination options as GET /todos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Get the todos of a list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return todos with this completion state",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "createdAt",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort direction",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Count the todos completed on each day of a range and derive the completion rate, the average time from creating to completing a todo, current and longest streaks of days with completions, and overdue counts. Days follow the user's time zone. Trashed todos are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Productivity statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day (YYYY-MM-DD), defaults to six days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day (YYYY-MM-DD), defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the todos created or changed, and the IDs of those deleted, since the token of an earlier sync, oldest change first. Without a token every todo is returned. Keep fetching with the returned token while more is true, and keep the last token for the next sync. Tokens expire after 90 days; a client holding an expired one must sync from scratch.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Fetch changes since the last sync",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the last sync",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size (1-500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SyncChanges"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply up to 500 creates, updates and deletes made by a client while offline, in order. New todos carry an ObjectID generated by the client, so a retried push does not create them twice. Each mutation is applied on its own and reports the status the equivalent single request would have, except that an update or delete made to a version the todo has since moved on from is a 409 conflict carrying the todo as it is now. Deleting a todo that is already gone succeeds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Push changes made offline",
+                "parameters": [
+                    {
+                        "description": "Mutations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SyncRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key making the request safe to retry; a retry gets the first response replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a page of todos for the authenticated user, optionally filtered and sorted. Send the ETag or Last-Modified of a page you hold as If-None-Match or If-Modified-Since to get 304 while none of your todos has changed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Get all todos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return todos with this completion state",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return todos created after this RFC 3339 time",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return todos created before this RFC 3339 time",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "updatedAt",
+                            "title",
+                            "position",
+                            "priority"
+                        ],
+                        "type": "string",
+                        "default": "createdAt",
+                        "description": "Sort field; position is the manual order, and todos without a priority come after low ones",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort direction, ascending by default for position",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Label names, repeated or comma-separated",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "any"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "Whether todos need all or any of the labels",
+                        "name": "labelMatch",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the page held by the client",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the page held by the client",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPage"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak tag that changes whenever any of the user's todos changes"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "When any of the user's todos last changed"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "The client's copy is current",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak tag that changes whenever any of the user's todos changes"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "When any of the user's todos last changed"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new todo item for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Create a new todo",
+                "parameters": [
+                    {
+                        "description": "Todo details",
+                        "name": "todo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoCreate"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key making the request safe to retry; a retry gets the first response replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos.ics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download the authenticated user's todos as an iCalendar file. Every todo is a VTODO; todos with a due date are also a VEVENT, so calendars that do not show tasks still show deadlines. Due dates at midnight in the todo's time zone are shown as all-day. Labels become categories and reminders alarms.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Get todos as a calendar",
+                "parameters": [
+                    {
+                        "enum": [
+                            "vtodo",
+                            "vevent"
+                        ],
+                        "type": "string",
+                        "description": "Only include one kind of component",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only include todos with this completion state",
+                        "name": "completed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "The client's copy is current"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create, update, delete or complete up to 500 todos at once, chosen by ids or by a filter such as all completed todos last updated before a date. Updates apply a merge patch object, or a JSON Patch array, to each todo. Each item reports the status the equivalent single request would have. When the deployment supports transactions the request is atomic and one failure rolls back every item; otherwise successes are kept and failures reported. A 207 status means at least one item failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Apply an action to many todos",
+                "parameters": [
+                    {
+                        "description": "Bulk action",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoBulkRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key making the request safe to retry; a retry gets the first response replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoBulkResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download every todo of the authenticated user, subtasks included, as CSV, JSON or todo.txt. Lists and labels are exported by name. The file is streamed, so an error after the first todo ends it early.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Export todos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "todotxt"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TodoRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create todos from a CSV, JSON or todo.txt file sent as the request body, in the format exports use. Lists and labels are matched by name and created when missing. Todos the user already has, by exported id or by title and due date, are skipped. Every record is reported with the line it starts on, and records that cannot be imported are rejected without stopping the rest. With dryRun nothing is stored. At most 5000 todos and 10 MB are accepted.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Import todos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "todotxt"
+                        ],
+                        "type": "string",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what the import would do",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "description": "File contents",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key making the request safe to retry; a retry gets the first response replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/quick": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Read a todo from free text such as \"Pay rent tomorrow 9am #finance !high every month\" and create it. Dates and times are read in the user's time zone: today, tomorrow, weekdays, \"next week\", \"in 3 days\", \"march 15\" or 2024-03-15, with 9am, 9:30pm, 21:00 or noon. #label attaches a label, created when missing, with underscores for spaces; !high, !medium and !low set the priority; daily, \"every 2 weeks\" or \"every monday and thursday\" make the todo recur. The rest is the title, and words in double quotes always stay in it. The response shows how the text was read; with preview=true nothing is created.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Create a todo from a line of text",
+                "parameters": [
+                    {
+                        "description": "Text of the todo",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.QuickAdd"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only parse the text",
+                        "name": "preview",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key making the request safe to retry; a retry gets the first response replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Preview",
+                        "schema": {
+                            "$ref": "#/definitions/model.QuickAddResult"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.QuickAddResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Full-text search over the title and description of the authenticated user's todos. Highlights are HTML-escaped with matches wrapped in \u003cmark\u003e tags.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Search todos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search terms",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TodoSearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Push an event whenever a todo of the authenticated user is created, updated or deleted, as Server-Sent Events. Each event is named after its type and carries a model.StreamEvent as data. A client that reconnects with the Last-Event-ID header, or the lastEventId parameter, is first sent the events it missed. When those are no longer known, or on a first connection, a reset event is sent instead and the client should reload its todos. A comment is sent as a heartbeat on idle streams. Browsers may pass the token as access_token since EventSource cannot send headers. The stream is closed with an expired event when the token expires, and without one when the client falls too far behind, in which case it should reconnect.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Stream todo events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, when the Last-Event-ID header cannot be sent",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token, when the Authorization header cannot be sent",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.StreamEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/ws": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrade to a WebSocket that is sent the same events as /todos/stream, each as a JSON text message holding a model.StreamEvent, including heartbeat messages on idle connections. The token is checked during the handshake, from the Authorization header or the access_token parameter; pass lastEventId to be sent the events missed since then.",
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Stream todo events over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token, when the Authorization header cannot be sent",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/model.StreamEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a todo item by ID. Send the ETag or Last-Modified of a copy you hold as If-None-Match or If-Modified-Since to get 304 while it is current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Get a single todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy held by the client",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the copy held by the client",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo, followed by the progress of its subtasks and the rendering when they apply"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "When the todo last changed; left out for todos with subtasks"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "The client's copy is current",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo, followed by the progress of its subtasks and the rendering when they apply"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "When the todo last changed; left out for todos with subtasks"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the editable fields of a todo. Fields left out are cleared; use PATCH to change single fields. Parent, list and labels are kept. Send the ETag of the version the change is based on as If-Match to have it refused with 412 if someone else changed the todo in the meantime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Replace a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated todo data",
+                        "name": "todo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoUpdate"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPreconditionFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a todo to the trash, from where it can be restored until it is purged. Its subtasks are moved to the top level unless cascade=delete is given, which trashes them too.",
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Delete a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "orphan",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "default": "orphan",
+                        "description": "What to do with subtasks",
+                        "name": "cascade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the client last saw",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPreconditionFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902), chosen by Content-Type, to the editable fields of a todo. Any field may be set, including to false or null.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Partially update a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch object or JSON Patch operations",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the patch is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPreconditionFailed"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/children": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the direct children of a todo, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "List subtasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Also return the description rendered as sanitised HTML",
+                        "name": "render",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Todo"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the revisions of a todo, newest first. Each records the actor, the time, a field-level diff and the resulting state. The history of a deleted todo remains readable.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Get the revision history of a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of revisions (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only return revisions older than this revision number",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/labels/{labelId}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Attach one of the user's labels to a todo; attaching twice has no effect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Attach a label to a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label ID",
+                        "name": "labelId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a label from a todo",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Detach a label from a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label ID",
+                        "name": "labelId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/list": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a todo and its subtasks to another list. A subtask leaves its parent and becomes a top-level todo of the list.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Move a todo to another list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target list",
+                        "name": "move",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoListMove"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Place a todo right after one todo, right before another, or between two. Only the moved todo changes; list with sort=position to read the order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Move a todo in the manual order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Todos to place it between",
+                        "name": "reorder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoReorder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/occurrences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the due dates a recurring todo will take within a window. The window defaults to the next 90 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Preview occurrences of a recurring todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the window (RFC 3339), defaults to now",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the window (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of occurrences (1-366)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoOccurrences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/parent": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a todo and its subtasks under a new parent, or to the top level when parentId is null",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Move a todo under another parent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New parent",
+                        "name": "move",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoMove"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/revert/{revision}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore what a todo had at the given revision: title, description, status, priority, due date, reminders, recurrence, parent, list and labels. Labels deleted since are left out, and a revert to a parent or list that is gone fails. The revert is recorded as a new revision.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Revert a todo to an earlier revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}/transition": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a todo to another state of the workflow of its list. The workflow must allow the move from the todo's current state; the todo is completed exactly while it is in a done state.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Move a todo to another workflow state",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "State to move to",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoTransition"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version the client last saw",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPreconditionFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of the authenticated user's todos in the trash, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List deleted todos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TodoPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a todo from the trash for good, together with the subtasks deleted along with it",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Permanently delete a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Take a todo out of the trash together with the subtasks deleted along with it. It returns under its parent when the parent still exists, and to the top level otherwise.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore a deleted todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Todo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the webhooks of the authenticated user. Secrets are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Webhook"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register an endpoint that is sent a POST for every todo event it subscribes to: todo.created, todo.updated, todo.completed or todo.deleted, or all of them when events is left out. Each request carries the event in X-Todo-Event, the delivery ID in X-Todo-Delivery and \"sha256=\" followed by the hex HMAC-SHA256 of the body, keyed with the secret, in X-Todo-Signature-256. Anything but a 2xx answer is retried with exponential backoff until the delivery is marked dead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Endpoint, secret and events",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a webhook by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a single webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the endpoint, secret or events of a webhook. Deliveries already queued are sent to the new endpoint with the new secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Change a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a webhook together with its delivery log. Deliveries not sent yet are dropped.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the deliveries of a webhook, newest first, with every attempt at each. Pending deliveries are waiting to be sent or retried; dead ones ran out of attempts. Deliveries are kept for 30 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get the delivery log of a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries in this state",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries (1-100, default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryId}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue a dead delivery to be sent again straight away, with a fresh round of attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Retry a dead delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookDelivery"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all workflows of the authenticated user, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Get all workflows",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Workflow"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Define the states todos of the given lists move through, or those of every other list when default is set. A list belongs to one workflow and a user has one default, so this takes them over from other workflows.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Create a workflow",
+                "parameters": [
+                    {
+                        "description": "Workflow definition",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WorkflowCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Workflow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a specific workflow by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Get a workflow",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workflow ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Workflow"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the definition of a workflow. Todos in states it no longer has move to its first done or first open state, according to whether they are completed, and todos in a state that became done or open are completed or reopened to match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Update a workflow",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workflow ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New workflow definition",
+                        "name": "workflow",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.WorkflowUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Workflow"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a workflow. Its todos follow the user's default workflow, or the built-in todo and done states, from then on, and move to its first done or first open state when they are in a state it does not have.",
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Delete a workflow",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workflow ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "errors.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AuthUser": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "model.CalendarFeed": {
+            "description": "CalendarFeed is a user's calendar subscription; token and url are only returned when it is created",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "cT9k2v0xQ1b8s6GqYtFzq3Hh4JmWnLr7eDpA5uVyXcI"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://todo.example.com/calendar/feed/cT9k2v0xQ1b8s6GqYtFzq3Hh4JmWnLr7eDpA5uVyXcI.ics"
+                }
+            }
+        },
+        "model.FieldChange": {
+            "description": "FieldChange holds the values of a field before and after a change; a missing value means the field was empty",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "new": {
+                    "type": "string",
+                    "example": "Buy oat milk"
+                },
+                "old": {
+                    "type": "string",
+                    "example": "Buy milk"
+                }
+            }
+        },
+        "model.Label": {
+            "description": "Label is a named, coloured tag; names are unique per user regardless of case",
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#ff8800"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f210"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Work"
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                }
+            }
+        },
+        "model.LabelCreate": {
+            "description": "LabelCreate is used when creating a new label",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#ff8800"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "Work"
+                }
+            }
+        },
+        "model.LabelMerge": {
+            "description": "LabelMerge names the label that absorbs the merged one",
+            "type": "object",
+            "required": [
+                "targetId"
+            ],
+            "properties": {
+                "targetId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f211"
+                }
+            }
+        },
+        "model.LabelUpdate": {
+            "description": "LabelUpdate changes the name and/or colour of a label; empty fields are left unchanged",
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#0088ff"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "Office"
+                }
+            }
+        },
+        "model.List": {
+            "description": "List is a named group of todos; every user has exactly one Inbox list, which cannot be deleted or archived",
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "icon": {
+                    "type": "string",
+                    "example": "🛒"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                },
+                "inbox": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Groceries"
+                },
+                "sortOrder": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                }
+            }
+        },
+        "model.ListCreate": {
+            "description": "ListCreate is used when creating a new list",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "icon": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "🛒"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Groceries"
+                },
+                "sortOrder": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.ListUpdate": {
+            "description": "ListUpdate changes a list; omitted fields are left unchanged",
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "icon": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "🧺"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Weekly shop"
+                },
+                "sortOrder": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "model.QuickAdd": {
+            "description": "QuickAdd holds a todo as typed, such as \"Pay rent tomorrow 9am #finance !high every month\". Words in double quotes are kept in the title as they are.",
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Pay rent tomorrow 9am #finance !high every month"
+                }
+            }
+        },
+        "model.QuickAddMatch": {
+            "description": "QuickAddMatch names the field a phrase of the text was read as",
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "due",
+                        "label",
+                        "priority",
+                        "recurrence"
+                    ],
+                    "example": "due"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "tomorrow"
+                }
+            }
+        },
+        "model.QuickAddParse": {
+            "description": "QuickAddParse holds the fields read from quick-add text and the phrases each came from. Due dates are in the user's time zone; a date without a time is due at midnight, which shows as all-day.",
+            "type": "object",
+            "properties": {
+                "dueAt": {
+                    "type": "string",
+                    "example": "2024-03-05T09:00:00Z"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "finance"
+                    ]
+                },
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.QuickAddMatch"
+                    }
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "high"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=MONTHLY"
+                },
+                "timeZone": {
+                    "type": "string",
+                    "example": "Europe/London"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Pay rent"
+                }
+            }
+        },
+        "model.QuickAddResult": {
+            "description": "QuickAddResult holds how the text was read and, unless it was a preview, the todo created from it",
+            "type": "object",
+            "properties": {
+                "parsed": {
+                    "$ref": "#/definitions/model.QuickAddParse"
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.Reminder": {
+            "description": "Reminder fires at RemindAt, which is the todo's due date shifted by Offset in the todo's time zone",
+            "type": "object",
+            "properties": {
+                "failedAt": {
+                    "type": "string",
+                    "example": "2022-01-04T11:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f202"
+                },
+                "nextAttemptAt": {
+                    "description": "A reminder whose notification failed is retried from NextAttemptAt,\nuntil it has been attempted too often and FailedAt is set",
+                    "type": "string",
+                    "example": "2022-01-04T09:01:00Z"
+                },
+                "offset": {
+                    "type": "string",
+                    "example": "-1d"
+                },
+                "remindAt": {
+                    "type": "string",
+                    "example": "2022-01-04T09:00:00Z"
+                },
+                "sentAt": {
+                    "type": "string",
+                    "example": "2022-01-04T09:00:05Z"
+                }
+            }
+        },
+        "model.Revision": {
+            "description": "Revision records who changed a todo, when, and how each tracked field changed. State is the todo as it was after the change.",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore",
+                        "revert"
+                    ],
+                    "example": "update"
+                },
+                "actorId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FieldChange"
+                    }
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f230"
+                },
+                "revertedTo": {
+                    "description": "Revision whose state a revert brought back",
+                    "type": "integer",
+                    "example": 1
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "state": {
+                    "$ref": "#/definitions/model.TodoState"
+                },
+                "todoId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                }
+            }
+        },
+        "model.StatsDay": {
+            "description": "StatsDay counts the todos completed on a date in the user's time zone",
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-03-04"
+                }
+            }
+        },
+        "model.StreamEvent": {
+            "description": "StreamEvent is a todo.created, todo.updated or todo.deleted event, or reset, expired or heartbeat",
+            "type": "object",
+            "properties": {
+                "event": {
+                    "type": "string",
+                    "example": "todo.updated"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "m1x2k3-42"
+                },
+                "occurredAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.SyncChanges": {
+            "description": "SyncChanges holds the todos created or changed and the IDs of those deleted since the token that was sent. Pass token as since for the next page, or the next sync once more is false.",
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Todo"
+                    }
+                },
+                "deleted": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "5f8d0614db5c5c7b3a18f201"
+                    ]
+                },
+                "more": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJxIjo0Mn0"
+                }
+            }
+        },
+        "model.SyncMutation": {
+            "description": "SyncMutation creates a todo under a client-generated ObjectID, or updates or deletes one as of the version the client last saw",
+            "type": "object",
+            "required": [
+                "id",
+                "op"
+            ],
+            "properties": {
+                "baseVersion": {
+                    "description": "Version the change was made to; the change is a conflict when the\ntodo has moved on since. Required for update, optional for delete.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                },
+                "patch": {
+                    "description": "Merge patch object, or JSON Patch array, for update",
+                    "type": "object"
+                },
+                "todo": {
+                    "description": "Todo to create, for create",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.TodoCreate"
+                        }
+                    ]
+                }
+            }
+        },
+        "model.SyncRequest": {
+            "description": "SyncRequest holds changes in the order the client made them",
+            "type": "object",
+            "required": [
+                "mutations"
+            ],
+            "properties": {
+                "mutations": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/model.SyncMutation"
+                    }
+                }
+            }
+        },
+        "model.SyncResponse": {
+            "description": "SyncResponse holds a result per mutation, in the order they were sent",
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "conflicts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SyncResult"
+                    }
+                }
+            }
+        },
+        "model.SyncResult": {
+            "description": "SyncResult reports the status of a mutation as the equivalent single request would have, except that a change to a todo that has moved on is a 409 carrying the todo as it is now",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.Todo": {
+            "description": "Todo represents a task that a user wants to track",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "completedAt": {
+                    "type": "string",
+                    "example": "2022-01-04T17:30:00Z"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "deletedAt": {
+                    "type": "string",
+                    "example": "2022-01-06T10:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Get **oat** milk"
+                },
+                "descriptionHtml": {
+                    "type": "string",
+                    "example": "\u003cp\u003eGet \u003cstrong\u003eoat\u003c/strong\u003e milk\u003c/p\u003e"
+                },
+                "dueAt": {
+                    "type": "string",
+                    "example": "2022-01-05T09:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
+                },
+                "listId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                },
+                "nextOccurrenceId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f203"
+                },
+                "parentId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f204"
+                },
+                "position": {
+                    "type": "string",
+                    "example": "V"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "high"
+                },
+                "progress": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO"
+                },
+                "recurrenceStart": {
+                    "type": "string",
+                    "example": "2022-01-03T09:00:00Z"
+                },
+                "reminders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Reminder"
                     }
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "example": "in_progress"
+                },
+                "timeZone": {
+                    "type": "string",
+                    "example": "Europe/London"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy groceries"
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 4
                 }
             }
-        }
-    },
-    "definitions": {
-        "errors.APIError": {
+        },
+        "model.TodoBulkFilter": {
+            "description": "TodoBulkFilter matches todos by completion, age and list",
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string"
+                "completed": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "details": {
+                "createdAfter": {
+                    "type": "string",
+                    "example": "2021-01-01T00:00:00Z"
+                },
+                "createdBefore": {
+                    "type": "string",
+                    "example": "2022-01-01T00:00:00Z"
+                },
+                "listId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                },
+                "updatedBefore": {
+                    "type": "string",
+                    "example": "2022-01-01T00:00:00Z"
+                }
+            }
+        },
+        "model.TodoBulkRequest": {
+            "description": "TodoBulkRequest creates the given todos, or updates, deletes or completes the todos named by ids or matched by filter",
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "complete"
+                    ],
+                    "example": "complete"
+                },
+                "cascade": {
+                    "description": "What delete does with subtasks, as for DELETE /todos/{id}",
+                    "type": "string",
+                    "enum": [
+                        "orphan",
+                        "delete"
+                    ],
+                    "example": "orphan"
+                },
+                "completed": {
+                    "description": "Completion state set by complete, true when omitted",
+                    "type": "boolean",
+                    "example": true
+                },
+                "filter": {
+                    "$ref": "#/definitions/model.TodoBulkFilter"
+                },
+                "ids": {
+                    "description": "Todos to act on; give either ids or filter",
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "5f8d0614db5c5c7b3a18f201"
+                    ]
+                },
+                "patch": {
+                    "description": "Merge patch object, or JSON Patch array, applied to each todo by update",
+                    "type": "object"
+                },
+                "todos": {
+                    "description": "Todos to create, for the create action",
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "$ref": "#/definitions/model.TodoCreate"
+                    }
+                }
+            }
+        },
+        "model.TodoBulkResponse": {
+            "description": "TodoBulkResponse holds per-item results. When atomic is true the items ran in one transaction, so either all succeeded or none were applied.",
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "more": {
+                    "description": "More is set when the filter matched more todos than one request handles",
+                    "type": "boolean",
+                    "example": false
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TodoBulkResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "model.TodoBulkResult": {
+            "description": "TodoBulkResult reports the status of one item, as the equivalent single request would have",
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
-                "message": {
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "index": {
+                    "description": "Position of the item in todos or ids; filtered items are numbered in match order",
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.TodoCreate": {
+            "description": "TodoCreate is used when creating a new todo item",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 20000,
+                    "example": "Get **oat** milk"
+                },
+                "dueAt": {
+                    "type": "string",
+                    "example": "2022-01-05T09:00:00Z"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "listId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                },
+                "parentId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f204"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "high"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=MONTHLY;BYDAY=-1FR"
+                },
+                "remindAt": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-1d",
+                        "-15m"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "in_progress"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy groceries"
+                }
+            }
+        },
+        "model.TodoImportItem": {
+            "description": "TodoImportItem reports whether a record was created, skipped as a duplicate or rejected",
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
+                "id": {
+                    "description": "ID of the created todo, or of the existing todo a duplicate matches",
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "line": {
+                    "description": "Line of the file the record starts on",
+                    "type": "integer",
+                    "example": 2
+                },
                 "status": {
-                    "type": "integer"
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "skipped",
+                        "rejected"
+                    ],
+                    "example": "created"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy groceries"
                 }
             }
         },
-        "model.AuthUser": {
+        "model.TodoImportResult": {
+            "description": "TodoImportResult counts and lists the outcome of every record. With dryRun nothing was stored and created means would be created.",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "dryRun": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TodoImportItem"
+                    }
+                },
+                "rejected": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "skipped": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.TodoListMove": {
+            "description": "TodoListMove names the list a todo moves to",
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "listId"
             ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "listId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                }
+            }
+        },
+        "model.TodoMove": {
+            "description": "TodoMove names the new parent of a todo; null moves it to the top level",
+            "type": "object",
+            "properties": {
+                "parentId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f204"
+                }
+            }
+        },
+        "model.TodoOccurrences": {
+            "description": "TodoOccurrences lists the due dates of a recurring todo within a window, in the todo's time zone",
+            "type": "object",
+            "properties": {
+                "occurrences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO"
+                }
+            }
+        },
+        "model.TodoPage": {
+            "description": "TodoPage wraps a page of todos together with the cursor for the next page",
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Todo"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJzIjoiY3JlYXRlZEF0In0"
+                }
+            }
+        },
+        "model.TodoPreconditionFailed": {
+            "description": "TodoPreconditionFailed holds the todo as it is now, with its current version",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "The todo has been changed since the given version"
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.TodoRecord": {
+            "description": "TodoRecord is the portable form of a todo used by export and import",
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Get **oat** milk"
+                },
+                "dueAt": {
+                    "type": "string",
+                    "example": "2022-01-05T09:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f201"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Errands"
+                    ]
+                },
+                "list": {
+                    "type": "string",
+                    "example": "Groceries"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy groceries"
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                }
+            }
+        },
+        "model.TodoReorder": {
+            "description": "TodoReorder names the todos a todo should come after and before; at least one is required",
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f205"
+                },
+                "before": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f206"
+                }
+            }
+        },
+        "model.TodoSearchResult": {
+            "description": "TodoSearchResult is a todo matching a search together with its relevance and highlighted excerpts",
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "score": {
+                    "type": "number",
+                    "example": 3.5
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
                 }
             }
         },
-        "model.Todo": {
-            "description": "Todo represents a task that a user wants to track",
+        "model.TodoState": {
+            "description": "TodoState is a snapshot of the user-controlled fields of a todo",
             "type": "object",
-            "required": [
-                "title"
-            ],
             "properties": {
                 "completed": {
                     "type": "boolean",
                     "example": false
                 },
-                "createdAt": {
+                "description": {
                     "type": "string",
-                    "example": "2022-01-01T12:00:00Z"
+                    "example": "Get **oat** milk"
                 },
-                "id": {
+                "dueAt": {
                     "type": "string",
-                    "example": "5f8d0614db5c5c7b3a18f201"
+                    "example": "2022-01-05T09:00:00Z"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "listId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f220"
+                },
+                "parentId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f204"
+                },
+                "priority": {
+                    "type": "string",
+                    "example": "high"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO"
+                },
+                "remindAt": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-1d"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "example": "in_progress"
                 },
                 "title": {
                     "type": "string",
                     "example": "Buy groceries"
+                }
+            }
+        },
+        "model.TodoStats": {
+            "description": "TodoStats counts what was completed on each day of the range, in the user's time zone, and derives rates, streaks and overdue counts from it. Trashed todos are left out.",
+            "type": "object",
+            "properties": {
+                "averageCompletionSeconds": {
+                    "description": "Mean time from creating to completing the todos completed during the\nrange, in seconds",
+                    "type": "integer",
+                    "example": 93600
                 },
-                "updatedAt": {
+                "completed": {
+                    "description": "Todos completed during the range",
+                    "type": "integer",
+                    "example": 12
+                },
+                "completedLate": {
+                    "description": "Todos completed during the range after their due date",
+                    "type": "integer",
+                    "example": 1
+                },
+                "completionRate": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "created": {
+                    "description": "Todos created during the range, and the share of them completed by now",
+                    "type": "integer",
+                    "example": 15
+                },
+                "currentStreak": {
+                    "description": "Runs of consecutive days with at least one completion. The current\nstreak ends on the last day of the range, or the day before when that\nis today and nothing has been completed yet.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "days": {
+                    "description": "Completions on every day of the range, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StatsDay"
+                    }
+                },
+                "from": {
                     "type": "string",
-                    "example": "2022-01-01T12:00:00Z"
+                    "example": "2024-03-04"
                 },
-                "userId": {
+                "longestStreak": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "overdue": {
+                    "description": "Open todos due during the range whose due date has passed",
+                    "type": "integer",
+                    "example": 2
+                },
+                "timeZone": {
                     "type": "string",
-                    "example": "5f8d0614db5c5c7b3a18f200"
+                    "example": "Europe/London"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-03-10"
                 }
             }
         },
-        "model.TodoCreate": {
-            "description": "TodoCreate is used when creating a new todo item",
+        "model.TodoTransition": {
+            "description": "TodoTransition names the state of its workflow to move a todo to",
             "type": "object",
             "required": [
-                "title"
+                "status"
             ],
             "properties": {
-                "completed": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "title": {
+                "status": {
                     "type": "string",
-                    "example": "Buy groceries"
+                    "example": "review"
                 }
             }
         },
         "model.TodoUpdate": {
-            "description": "TodoUpdate is used when updating an existing todo item",
+            "description": "TodoUpdate is the full editable state of a todo, as sent to PUT and produced by applying a PATCH",
             "type": "object",
+            "required": [
+                "title"
+            ],
             "properties": {
                 "completed": {
                     "type": "boolean",
@@ -371,15 +4882,38 @@ const docTemplate = `{
                 },
                 "description": {
                     "type": "string",
-                    "example": "Need to get milk and eggs"
+                    "maxLength": 20000,
+                    "example": "Need to get **milk** and eggs"
                 },
-                "title": {
+                "dueAt": {
                     "type": "string",
-                    "example": "Buy more groceries"
+                    "example": "2022-01-06T09:00:00Z"
                 },
-                "updatedAt": {
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "medium",
+                        "high"
+                    ],
+                    "example": "medium"
+                },
+                "recurrence": {
                     "type": "string",
-                    "example": "2022-01-02T12:00:00Z"
+                    "example": "FREQ=WEEKLY;BYDAY=MO"
+                },
+                "remindAt": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-1d"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy more groceries"
                 }
             }
         },
@@ -409,6 +4943,9 @@ const docTemplate = `{
                     "type": "string",
                     "minLength": 6
                 },
+                "timeZone": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 }
@@ -433,6 +4970,352 @@ const docTemplate = `{
                 "password": {
                     "type": "string",
                     "minLength": 6
+                },
+                "timeZone": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.UserUpdate": {
+            "type": "object",
+            "properties": {
+                "timeZone": {
+                    "type": "string",
+                    "example": "Europe/London"
+                }
+            }
+        },
+        "model.Webhook": {
+            "description": "Webhook is an endpoint that receives the events it subscribes to, signed with its secret",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "todo.created",
+                        "todo.completed"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f240"
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://hooks.example.com/todos"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                }
+            }
+        },
+        "model.WebhookAttempt": {
+            "description": "WebhookAttempt is one try at a delivery; error is set when it failed",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:01Z"
+                },
+                "durationMs": {
+                    "type": "integer",
+                    "example": 84
+                },
+                "error": {
+                    "type": "string",
+                    "example": "webhook responded with status 503"
+                },
+                "statusCode": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "model.WebhookCreate": {
+            "description": "WebhookCreate registers an endpoint; without events it receives every event",
+            "type": "object",
+            "required": [
+                "secret",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "todo.created",
+                        "todo.completed"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 256,
+                    "minLength": 16,
+                    "example": "whsec_3f9a1c7e5b2d4068"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://hooks.example.com/todos"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "description": "WebhookDelivery is an event sent to a webhook with its attempts; status is pending, delivered or dead",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WebhookAttempt"
+                    }
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "deliveredAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:01Z"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "todo.created"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f250"
+                },
+                "nextAttemptAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:30Z"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "webhookId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f240"
+                }
+            }
+        },
+        "model.WebhookUpdate": {
+            "description": "WebhookUpdate changes a webhook; empty fields are left unchanged",
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "todo.deleted"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 256,
+                    "minLength": 16,
+                    "example": "whsec_8c2e6a0d4f1b3957"
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://hooks.example.com/v2/todos"
+                }
+            }
+        },
+        "model.Workflow": {
+            "description": "Workflow lists the states of todos in order and the transitions allowed between them; with no transitions any state can follow any other. A todo counts as completed while it is in a done state.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f250"
+                },
+                "listIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Team board"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowState"
+                    }
+                },
+                "transitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowTransition"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-01T12:00:00Z"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "5f8d0614db5c5c7b3a18f200"
+                }
+            }
+        },
+        "model.WorkflowCreate": {
+            "description": "WorkflowCreate defines a workflow. It needs at least one done state and one state that is not.",
+            "type": "object",
+            "required": [
+                "name",
+                "states"
+            ],
+            "properties": {
+                "default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "listIds": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Team board"
+                },
+                "states": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowState"
+                    }
+                },
+                "transitions": {
+                    "type": "array",
+                    "maxItems": 400,
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowTransition"
+                    }
+                }
+            }
+        },
+        "model.WorkflowState": {
+            "description": "WorkflowState is a state todos can be in; key is what todos store and transitions refer to",
+            "type": "object",
+            "required": [
+                "key",
+                "name"
+            ],
+            "properties": {
+                "done": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "key": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "review"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "In review"
+                }
+            }
+        },
+        "model.WorkflowTransition": {
+            "description": "WorkflowTransition allows moving a todo from the state keyed from to the state keyed to",
+            "type": "object",
+            "required": [
+                "from",
+                "to"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "in_progress"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "review"
+                }
+            }
+        },
+        "model.WorkflowUpdate": {
+            "description": "WorkflowUpdate is the whole new definition of a workflow; todos in states it drops count as being in its first done or first open state",
+            "type": "object",
+            "required": [
+                "name",
+                "states"
+            ],
+            "properties": {
+                "default": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "listIds": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Team board"
+                },
+                "states": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowState"
+                    }
+                },
+                "transitions": {
+                    "type": "array",
+                    "maxItems": 400,
+                    "items": {
+                        "$ref": "#/definitions/model.WorkflowTransition"
+                    }
                 }
             }
         }
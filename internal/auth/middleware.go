@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"todo-app/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +12,7 @@ import (
 const (
 	AuthorizationHeader = "Authorization"
 	BearerPrefix        = "Bearer "
+	AccessTokenParam    = "access_token"
 )
 
 func (s *authService) AuthMiddleware() gin.HandlerFunc {
@@ -49,3 +51,67 @@ func (s *authService) AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// BasicAuthMiddleware authenticates with the email and password of a user
+// sent as HTTP Basic credentials, for clients such as CalDAV apps that
+// cannot obtain a token. Failures are challenged so the client asks the
+// user for credentials.
+func (s *authService) BasicAuthMiddleware(realm string) gin.HandlerFunc {
+	challenge := `Basic realm="` + realm + `", charset="UTF-8"`
+	return func(c *gin.Context) {
+		email, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "basic authorization is required"})
+			return
+		}
+
+		user, err := s.Authenticate(c.Request.Context(), &model.AuthUser{Email: email, Password: password})
+		if err != nil {
+			log.Printf("Basic authentication failed: %v", err)
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			return
+		}
+
+		c.Set("userId", user.ID.Hex())
+		c.Set("email", user.Email)
+		c.Next()
+	}
+}
+
+// StreamAuthMiddleware authenticates event streams. Browsers cannot set
+// headers on EventSource or WebSocket connections, so the token may also be
+// sent in the access_token query parameter. The token is only checked when
+// the stream is opened; its expiry is stored as tokenExpiresAt so the
+// stream can be closed when it runs out.
+func (s *authService) StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.Query(AccessTokenParam)
+		if authHeader := c.GetHeader(AuthorizationHeader); authHeader != "" {
+			if !strings.HasPrefix(authHeader, BearerPrefix) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header must start with 'Bearer '"})
+				return
+			}
+			tokenString = strings.TrimSpace(authHeader[len(BearerPrefix):])
+		}
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header or access_token is required"})
+			return
+		}
+
+		claims, err := s.ParseToken(tokenString)
+		if err != nil {
+			log.Printf("Stream token validation failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set("userId", claims.UserID)
+		c.Set("email", claims.Email)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
+		c.Next()
+	}
+}
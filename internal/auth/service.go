@@ -22,9 +22,13 @@ type Claims struct {
 
 type Service interface {
 	Register(ctx context.Context, user *model.UserRegister) (*model.User, error)
+	UpdateUser(ctx context.Context, userId string, update *model.UserUpdate) (*model.User, error)
 	Login(ctx context.Context, authUser *model.AuthUser) (string, error)
+	Authenticate(ctx context.Context, authUser *model.AuthUser) (*model.User, error)
 	ParseToken(tokenString string) (*Claims, error)
 	AuthMiddleware() gin.HandlerFunc
+	BasicAuthMiddleware(realm string) gin.HandlerFunc
+	StreamAuthMiddleware() gin.HandlerFunc
 	GetPepper() string
 }
 
@@ -33,14 +37,16 @@ type authService struct {
 	jwtExpiration time.Duration
 	pepper        string
 	userRepo      repository.UserRepository
+	listRepo      repository.ListRepository
 }
 
-func NewAuthService(jwtSecret string, jwtExpiration time.Duration, pepper string, userRepo repository.UserRepository) *authService {
+func NewAuthService(jwtSecret string, jwtExpiration time.Duration, pepper string, userRepo repository.UserRepository, listRepo repository.ListRepository) *authService {
 	return &authService{
 		jwtSecret:     jwtSecret,
 		jwtExpiration: jwtExpiration,
 		pepper:        pepper,
 		userRepo:      userRepo,
+		listRepo:      listRepo,
 	}
 }
 
@@ -49,24 +55,43 @@ func (s *authService) Register(ctx context.Context, user *model.UserRegister) (*
 		Email:    user.Email,
 		Password: user.Password,
 		FullName: user.FullName,
+		TimeZone: user.TimeZone,
 	}
 	if err := newUser.HashPassword(s.pepper); err != nil {
 		return nil, errors.NewInternalServerError()
 	}
-	return s.userRepo.Create(ctx, newUser)
+
+	createdUser, err := s.userRepo.Create(ctx, newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Inbox is also created lazily on first use, so a failure here
+	// should not fail the registration
+	if _, err := s.listRepo.EnsureInbox(ctx, createdUser.ID.Hex()); err != nil {
+		log.Printf("Failed to create inbox for user %s: %v", createdUser.ID.Hex(), err)
+	}
+	return createdUser, nil
 }
 
-func (s *authService) Login(ctx context.Context, authUser *model.AuthUser) (string, error) {
-	user, err := s.userRepo.FindByEmail(ctx, authUser.Email)
+// UpdateUser changes the settings of a user. A new time zone applies to
+// todos created from then on, quick add and stats; existing todos keep the
+// zone they were created in.
+func (s *authService) UpdateUser(ctx context.Context, userId string, update *model.UserUpdate) (*model.User, error) {
+	user, err := s.userRepo.Update(ctx, userId, update)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", errors.ErrInvalidCredentials
+		return nil, errors.ErrNotFound
 	}
+	return user, nil
+}
 
-	if err := user.ComparePassword(authUser.Password, s.pepper); err != nil {
-		return "", errors.ErrInvalidCredentials
+func (s *authService) Login(ctx context.Context, authUser *model.AuthUser) (string, error) {
+	user, err := s.Authenticate(ctx, authUser)
+	if err != nil {
+		return "", err
 	}
 
 	claims := &Claims{
@@ -81,6 +106,23 @@ func (s *authService) Login(ctx context.Context, authUser *model.AuthUser) (stri
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
+// Authenticate checks an email and password and returns the user they
+// belong to
+func (s *authService) Authenticate(ctx context.Context, authUser *model.AuthUser) (*model.User, error) {
+	user, err := s.userRepo.FindByEmail(ctx, authUser.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if err := user.ComparePassword(authUser.Password, s.pepper); err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
 func (s *authService) ParseToken(tokenString string) (*Claims, error) {
 	// First verify the token is not empty
 	if tokenString == "" {
@@ -0,0 +1,316 @@
+// Package caldav reads and writes the XML of the WebDAV (RFC 4918) and
+// CalDAV (RFC 4791) requests the CalDAV endpoints support: PROPFIND, the
+// calendar-query and calendar-multiget REPORTs and the multistatus
+// responses to them. Property values are written as XML fragments that use
+// the prefixes declared on the multistatus element: d for DAV, c for CalDAV
+// and cs for CalendarServer.
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Namespaces
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// ContentType is the media type of the XML bodies
+const ContentType = "application/xml; charset=utf-8"
+
+// maxBodyBytes caps the size of a request body that is parsed
+const maxBodyBytes = 1 << 20
+
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+}
+
+// DAV names a property in the DAV namespace
+func DAV(local string) xml.Name {
+	return xml.Name{Space: NamespaceDAV, Local: local}
+}
+
+// CalDAV names a property in the CalDAV namespace
+func CalDAV(local string) xml.Name {
+	return xml.Name{Space: NamespaceCalDAV, Local: local}
+}
+
+// CalendarServer names a property in the CalendarServer namespace
+func CalendarServer(local string) xml.Name {
+	return xml.Name{Space: NamespaceCalendarServer, Local: local}
+}
+
+// Reports
+var (
+	CalendarQuery    = CalDAV("calendar-query")
+	CalendarMultiget = CalDAV("calendar-multiget")
+)
+
+// PropRequest is the properties a PROPFIND or REPORT asks for
+type PropRequest struct {
+	// AllProp asks for every property, as does a request without a body
+	AllProp bool
+	// PropName asks for the names of the properties without values
+	PropName bool
+	Names    []xml.Name
+}
+
+// Report is a parsed REPORT request
+type Report struct {
+	Name  xml.Name
+	Props PropRequest
+	// Hrefs of the resources a calendar-multiget asks for
+	Hrefs []string
+	// Component a calendar-query matches, such as VTODO
+	Component string
+	// Open asks a calendar-query for todos that are not completed only
+	Open bool
+}
+
+type propXML struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+type propfindXML struct {
+	XMLName  xml.Name  `xml:"DAV: propfind"`
+	AllProp  *struct{} `xml:"DAV: allprop"`
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *propXML  `xml:"DAV: prop"`
+}
+
+type compFilterXML struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []compFilterXML `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	PropFilters []propFilterXML `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+type propFilterXML struct {
+	Name         string    `xml:"name,attr"`
+	IsNotDefined *struct{} `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *struct {
+		Negate string `xml:"negate-condition,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type reportXML struct {
+	XMLName xml.Name
+	AllProp *struct{} `xml:"DAV: allprop"`
+	Prop    *propXML  `xml:"DAV: prop"`
+	Hrefs   []string  `xml:"DAV: href"`
+	Filter  *struct {
+		CompFilter compFilterXML `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// ParsePropfind reads the body of a PROPFIND. An empty body asks for all
+// properties.
+func ParsePropfind(r io.Reader) (*PropRequest, error) {
+	data, err := readBody(r)
+	if err != nil || len(data) == 0 {
+		return &PropRequest{AllProp: true}, err
+	}
+
+	var body propfindXML
+	if err := xml.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("invalid PROPFIND body: %v", err)
+	}
+	request := &PropRequest{AllProp: body.AllProp != nil, PropName: body.PropName != nil}
+	if body.Prop != nil {
+		request.Names = body.Prop.names()
+	}
+	if !request.AllProp && !request.PropName && body.Prop == nil {
+		return nil, errors.New("invalid PROPFIND body: expected prop, allprop or propname")
+	}
+	return request, nil
+}
+
+// ParseReport reads the body of a REPORT. Time ranges and filters other than
+// on the component and on completion are not understood and are ignored,
+// so a calendar-query may match more than was asked for.
+func ParseReport(r io.Reader) (*Report, error) {
+	data, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var body reportXML
+	if err := xml.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("invalid REPORT body: %v", err)
+	}
+	report := &Report{Name: body.XMLName, Hrefs: body.Hrefs, Props: PropRequest{AllProp: body.AllProp != nil}}
+	if body.Prop != nil {
+		report.Props.Names = body.Prop.names()
+	} else {
+		report.Props.AllProp = true
+	}
+
+	if body.Filter != nil && strings.EqualFold(body.Filter.CompFilter.Name, "VCALENDAR") {
+		for _, filter := range body.Filter.CompFilter.CompFilters {
+			report.Component = strings.ToUpper(filter.Name)
+			for _, prop := range filter.PropFilters {
+				switch {
+				case strings.EqualFold(prop.Name, "COMPLETED") && prop.IsNotDefined != nil:
+					report.Open = true
+				case strings.EqualFold(prop.Name, "STATUS") && prop.TextMatch != nil &&
+					prop.TextMatch.Negate == "yes" && strings.EqualFold(strings.TrimSpace(prop.TextMatch.Value), "COMPLETED"):
+					report.Open = true
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+func (p *propXML) names() []xml.Name {
+	names := make([]xml.Name, len(p.Names))
+	for i, name := range p.Names {
+		names[i] = name.XMLName
+	}
+	return names
+}
+
+func readBody(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBodyBytes {
+		return nil, errors.New("the request body is too large")
+	}
+	return data, nil
+}
+
+// Property is a property with its value as an XML fragment
+type Property struct {
+	Name  xml.Name
+	Value string
+}
+
+// Response is the part of a multistatus about one resource. A resource
+// with Status set, such as a missing one, has no properties.
+type Response struct {
+	Href    string
+	Status  int
+	Found   []Property
+	Missing []xml.Name
+}
+
+// Select picks the properties a request asks for out of those a resource
+// has. Properties the resource lacks are reported as missing.
+func (r *PropRequest) Select(available []Property) (found []Property, missing []xml.Name) {
+	switch {
+	case r.AllProp:
+		return available, nil
+	case r.PropName:
+		for _, prop := range available {
+			found = append(found, Property{Name: prop.Name})
+		}
+		return found, nil
+	}
+
+	for _, name := range r.Names {
+		ok := false
+		for _, prop := range available {
+			if prop.Name == name {
+				found = append(found, prop)
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			missing = append(missing, name)
+		}
+	}
+	return found, missing
+}
+
+// Has tells whether a request asks for a property by name. Requests for
+// all properties do not count, so costly ones can be left out of them.
+func (r *PropRequest) Has(name xml.Name) bool {
+	for _, asked := range r.Names {
+		if asked == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteMultistatus writes a multistatus body
+func WriteMultistatus(w io.Writer, responses []*Response) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	for _, response := range responses {
+		b.WriteString("<d:response>")
+		b.WriteString(Href(response.Href))
+		if response.Status != 0 {
+			b.WriteString(status(response.Status))
+		}
+		if len(response.Found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, prop := range response.Found {
+				b.WriteString(element(prop.Name, prop.Value))
+			}
+			b.WriteString("</d:prop>")
+			b.WriteString(status(http.StatusOK))
+			b.WriteString("</d:propstat>")
+		}
+		if len(response.Missing) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, name := range response.Missing {
+				b.WriteString(element(name, ""))
+			}
+			b.WriteString("</d:prop>")
+			b.WriteString(status(http.StatusNotFound))
+			b.WriteString("</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+	b.WriteString("</d:multistatus>")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// element writes a property, declaring its namespace when it has no prefix
+func element(name xml.Name, value string) string {
+	prefix, ok := prefixes[name.Space]
+	declaration := ""
+	if !ok {
+		prefix = "x"
+		declaration = fmt.Sprintf(` xmlns:x="%s"`, Escape(name.Space))
+	}
+	tag := prefix + ":" + name.Local
+	if value == "" {
+		return "<" + tag + declaration + "/>"
+	}
+	return "<" + tag + declaration + ">" + value + "</" + tag + ">"
+}
+
+func status(code int) string {
+	return fmt.Sprintf("<d:status>HTTP/1.1 %d %s</d:status>", code, http.StatusText(code))
+}
+
+// Href is an href element
+func Href(path string) string {
+	return "<d:href>" + Escape(path) + "</d:href>"
+}
+
+// Escape escapes text for use in XML
+func Escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
@@ -18,6 +18,37 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiration  time.Duration
 	PasswordPepper string
+	// Reminders
+	ReminderNotifier    string
+	ReminderWebhookURL  string
+	ReminderInterval    time.Duration
+	ReminderMaxAttempts int
+	ReminderBackoff     time.Duration
+	// Trash
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	// Manual order: positions longer than RankMaxLength are rebalanced
+	RankMaxLength         int
+	RankRebalanceInterval time.Duration
+	// Refuse changes to a todo that do not send If-Match
+	RequireIfMatch bool
+	// How long responses to requests with an Idempotency-Key are kept
+	IdempotencyTTL time.Duration
+	// Address the API is reached at from outside, for links such as
+	// calendar feed URLs; taken from each request when empty
+	PublicURL string
+	// Webhooks
+	WebhookInterval    time.Duration
+	WebhookMaxAttempts int
+	WebhookBackoff     time.Duration
+	// Networks webhooks may be sent to although they are loopback, private
+	// or link-local, in CIDR notation
+	WebhookAllowedNetworks []string
+	// Event streams
+	StreamHeartbeat  time.Duration
+	StreamBuffer     int
+	StreamHistory    int
+	StreamMaxPerUser int
 }
 
 func LoadConfig() *Config {
@@ -26,6 +57,7 @@ func LoadConfig() *Config {
 	}
 
 	testMode, _ := strconv.ParseBool(os.Getenv("TEST_MODE"))
+	requireIfMatch, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
 
 	defaultExpiration := int64(3600)
 
@@ -35,6 +67,86 @@ func LoadConfig() *Config {
 		jwtExpiration = defaultExpiration
 	}
 
+	reminderInterval, err := time.ParseDuration(getEnv("REMINDER_INTERVAL", "30s"))
+	if err != nil || reminderInterval <= 0 {
+		reminderInterval = 30 * time.Second
+	}
+
+	reminderMaxAttempts, err := strconv.Atoi(getEnv("REMINDER_MAX_ATTEMPTS", "5"))
+	if err != nil || reminderMaxAttempts <= 0 {
+		reminderMaxAttempts = 5
+	}
+
+	reminderBackoff, err := time.ParseDuration(getEnv("REMINDER_BACKOFF", "1m"))
+	if err != nil || reminderBackoff <= 0 {
+		reminderBackoff = time.Minute
+	}
+
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil || trashRetention <= 0 {
+		trashRetention = 30 * 24 * time.Hour
+	}
+
+	trashPurgeInterval, err := time.ParseDuration(getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil || trashPurgeInterval <= 0 {
+		trashPurgeInterval = time.Hour
+	}
+
+	rankMaxLength, err := strconv.Atoi(getEnv("RANK_MAX_LENGTH", "16"))
+	if err != nil || rankMaxLength <= 0 {
+		rankMaxLength = 16
+	}
+
+	rankRebalanceInterval, err := time.ParseDuration(getEnv("RANK_REBALANCE_INTERVAL", "1h"))
+	if err != nil || rankRebalanceInterval <= 0 {
+		rankRebalanceInterval = time.Hour
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || idempotencyTTL <= 0 {
+		idempotencyTTL = 24 * time.Hour
+	}
+
+	webhookInterval, err := time.ParseDuration(getEnv("WEBHOOK_INTERVAL", "5s"))
+	if err != nil || webhookInterval <= 0 {
+		webhookInterval = 5 * time.Second
+	}
+
+	webhookMaxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || webhookMaxAttempts <= 0 {
+		webhookMaxAttempts = 8
+	}
+
+	webhookBackoff, err := time.ParseDuration(getEnv("WEBHOOK_BACKOFF", "30s"))
+	if err != nil || webhookBackoff <= 0 {
+		webhookBackoff = 30 * time.Second
+	}
+
+	var webhookAllowedNetworks []string
+	if networks := getEnv("WEBHOOK_ALLOWED_NETWORKS", ""); networks != "" {
+		webhookAllowedNetworks = strings.Split(networks, ",")
+	}
+
+	streamHeartbeat, err := time.ParseDuration(getEnv("STREAM_HEARTBEAT", "25s"))
+	if err != nil || streamHeartbeat <= 0 {
+		streamHeartbeat = 25 * time.Second
+	}
+
+	streamBuffer, err := strconv.Atoi(getEnv("STREAM_BUFFER", "64"))
+	if err != nil || streamBuffer <= 0 {
+		streamBuffer = 64
+	}
+
+	streamHistory, err := strconv.Atoi(getEnv("STREAM_HISTORY", "1000"))
+	if err != nil || streamHistory < 0 {
+		streamHistory = 1000
+	}
+
+	streamMaxPerUser, err := strconv.Atoi(getEnv("STREAM_MAX_PER_USER", "10"))
+	if err != nil || streamMaxPerUser <= 0 {
+		streamMaxPerUser = 10
+	}
+
 	port := getEnv("PORT", getEnv("SERVER_PORT", "8080"))
 	if !strings.HasPrefix(port, ":") {
 		port = ":" + port
@@ -48,6 +160,34 @@ func LoadConfig() *Config {
 		JWTSecret:      getEnv("JWT_SECRET", "very-secret-key"),
 		JWTExpiration:  time.Duration(jwtExpiration) * time.Second,
 		PasswordPepper: getEnv("PASSWORD_PEPPER", "pepper"),
+
+		ReminderNotifier:    getEnv("REMINDER_NOTIFIER", "log"),
+		ReminderWebhookURL:  getEnv("REMINDER_WEBHOOK_URL", ""),
+		ReminderInterval:    reminderInterval,
+		ReminderMaxAttempts: reminderMaxAttempts,
+		ReminderBackoff:     reminderBackoff,
+
+		TrashRetention:     trashRetention,
+		TrashPurgeInterval: trashPurgeInterval,
+
+		RankMaxLength:         rankMaxLength,
+		RankRebalanceInterval: rankRebalanceInterval,
+
+		RequireIfMatch: requireIfMatch,
+		IdempotencyTTL: idempotencyTTL,
+
+		PublicURL: getEnv("PUBLIC_URL", ""),
+
+		WebhookInterval:    webhookInterval,
+		WebhookMaxAttempts: webhookMaxAttempts,
+		WebhookBackoff:     webhookBackoff,
+
+		WebhookAllowedNetworks: webhookAllowedNetworks,
+
+		StreamHeartbeat:  streamHeartbeat,
+		StreamBuffer:     streamBuffer,
+		StreamHistory:    streamHistory,
+		StreamMaxPerUser: streamMaxPerUser,
 	}
 }
 
@@ -35,44 +35,7 @@ func NewAuthController(authService auth.Service) *AuthController {
 func (c *AuthController) Register(ctx *gin.Context) {
 	var user model.UserRegister
 	if err := ctx.ShouldBindJSON(&user); err != nil {
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			var errorMessages []string
-			for _, fieldErr := range validationErrors {
-				log.Printf("Validation error: %s", fieldErr.Tag())
-				switch fieldErr.Tag() {
-				case "required":
-					errorMessages = append(errorMessages,
-						fmt.Sprintf("%s is required", fieldErr.Field()))
-				case "email":
-					errorMessages = append(errorMessages,
-						fmt.Sprintf("%s must be a valid email", fieldErr.Field()))
-				case "min":
-					errorMessages = append(errorMessages,
-						fmt.Sprintf("%s must be at least %s characters",
-							fieldErr.Field(), fieldErr.Param()))
-				case "max":
-					errorMessages = append(errorMessages,
-						fmt.Sprintf("%s must be at most %s characters",
-							fieldErr.Field(), fieldErr.Param()))
-				default:
-					errorMessages = append(errorMessages,
-						fmt.Sprintf("%s is invalid", fieldErr.Field()))
-				}
-			}
-			ctx.AbortWithError(400, errors.NewAPIError(
-				400,
-				"VALIDATION_ERROR",
-				strings.Join(errorMessages, ", "),
-			))
-			return
-		}
-
-		ctx.AbortWithError(400, errors.NewAPIErrorWithDetails(
-			400,
-			"INVALID_PAYLOAD",
-			"Invalid request body",
-			err.Error(),
-		))
+		ctx.AbortWithError(400, bindingError(err))
 		return
 	}
 
@@ -113,3 +76,83 @@ func (c *AuthController) Login(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// UpdateMe godoc
+// @Summary      Update the signed-in user
+// @Description  Change the settings of the authenticated user. The time zone must be an IANA name and applies to todos created from then on, quick add and stats; existing todos keep the zone they were created in.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user  body      model.UserUpdate  true  "Settings to change"
+// @Success      200   {object}  model.User
+// @Failure      400   {object}  errors.APIError
+// @Failure      401   {object}  errors.APIError
+// @Router       /auth/me [patch]
+func (c *AuthController) UpdateMe(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var update model.UserUpdate
+	if err := ctx.ShouldBindJSON(&update); err != nil {
+		ctx.AbortWithError(http.StatusBadRequest, bindingError(err))
+		return
+	}
+
+	user, err := c.authService.UpdateUser(ctx.Request.Context(), userId.(string), &update)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// bindingError describes why a request body was rejected, naming every
+// field that failed validation
+func bindingError(err error) error {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return errors.NewAPIErrorWithDetails(
+			400,
+			"INVALID_PAYLOAD",
+			"Invalid request body",
+			err.Error(),
+		)
+	}
+
+	var errorMessages []string
+	for _, fieldErr := range validationErrors {
+		log.Printf("Validation error: %s", fieldErr.Tag())
+		switch fieldErr.Tag() {
+		case "required":
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s is required", fieldErr.Field()))
+		case "email":
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s must be a valid email", fieldErr.Field()))
+		case "min":
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s must be at least %s characters",
+					fieldErr.Field(), fieldErr.Param()))
+		case "max":
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s must be at most %s characters",
+					fieldErr.Field(), fieldErr.Param()))
+		case "timezone":
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s must be an IANA time zone such as Europe/London", fieldErr.Field()))
+		default:
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("%s is invalid", fieldErr.Field()))
+		}
+	}
+	return errors.NewAPIError(
+		400,
+		"VALIDATION_ERROR",
+		strings.Join(errorMessages, ", "),
+	)
+}
@@ -0,0 +1,452 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-app/internal/caldav"
+	"todo-app/internal/errors"
+	"todo-app/internal/ical"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DAVPrefix is where the CalDAV endpoints are mounted
+const DAVPrefix = "/dav"
+
+// DAVMethods are the methods the CalDAV endpoints answer
+var DAVMethods = []string{"OPTIONS", "PROPFIND", "REPORT", "GET", "HEAD", "PUT", "DELETE"}
+
+const (
+	principalHref = DAVPrefix + "/principal/"
+	homeHref      = DAVPrefix + "/calendars/"
+	// objectContentType is the media type of todo resources
+	objectContentType = "text/calendar; charset=utf-8; component=vtodo"
+)
+
+type CalDAVController struct {
+	service service.CalDAVService
+}
+
+func NewCalDAVController(service service.CalDAVService) *CalDAVController {
+	return &CalDAVController{service: service}
+}
+
+// davResource is what a path below DAVPrefix names
+type davResource int
+
+const (
+	davRoot davResource = iota
+	davPrincipal
+	davHome
+	davCalendar
+	davObject
+)
+
+// davPath is a parsed path below DAVPrefix
+type davPath struct {
+	resource davResource
+	listId   string
+	name     string
+}
+
+// parseDAVPath parses the part of a path after DAVPrefix:
+// /principal/, /calendars/, /calendars/{listId}/ and
+// /calendars/{listId}/{name}
+func parseDAVPath(path string) (davPath, bool) {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return davPath{resource: davRoot}, true
+	}
+
+	parts := strings.Split(trimmed, "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "principal":
+		return davPath{resource: davPrincipal}, true
+	case len(parts) == 1 && parts[0] == "calendars":
+		return davPath{resource: davHome}, true
+	case len(parts) == 2 && parts[0] == "calendars":
+		return davPath{resource: davCalendar, listId: parts[1]}, true
+	case len(parts) == 3 && parts[0] == "calendars" && parts[2] != "":
+		return davPath{resource: davObject, listId: parts[1], name: parts[2]}, true
+	}
+	return davPath{}, false
+}
+
+// parseDAVHref parses an href sent by a client, which may be a full URL
+func parseDAVHref(href string) (davPath, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return davPath{}, false
+	}
+	path, ok := strings.CutPrefix(parsed.Path, DAVPrefix)
+	if !ok {
+		return davPath{}, false
+	}
+	return parseDAVPath(path)
+}
+
+func calendarHref(list *model.List) string {
+	return homeHref + list.ID.Hex() + "/"
+}
+
+func objectHref(todo *model.Todo) string {
+	return DAVPrefix + "/calendars/" + todo.ListID.Hex() + "/" + url.PathEscape(service.ResourceName(todo))
+}
+
+// WellKnown godoc
+// @Summary Discover the CalDAV server
+// @Description Redirect CalDAV clients looking for the server (RFC 6764) to where it is mounted
+// @Tags caldav
+// @Success 301
+// @Router /.well-known/caldav [get]
+func (c *CalDAVController) WellKnown(ctx *gin.Context) {
+	ctx.Redirect(http.StatusMovedPermanently, DAVPrefix+"/")
+}
+
+// Serve godoc
+// @Summary CalDAV server
+// @Description A CalDAV (RFC 4791) subset for two-way sync with calendar and task apps, authenticated with HTTP Basic using the account's email and password. Each list that is not archived is a calendar collection at /dav/calendars/{listId}/ holding a VTODO resource per todo. Supports OPTIONS, PROPFIND, REPORT (calendar-query and calendar-multiget), and GET, PUT and DELETE of resources with ETags and If-Match. The principal is at /dav/principal/ and the calendar home at /dav/calendars/. Recurrence, reminders and subtask parents are not synced and are kept when a client replaces a todo.
+// @Tags caldav
+// @Accept xml
+// @Accept text/calendar
+// @Produce xml
+// @Produce text/calendar
+// @Param path path string true "Resource below /dav"
+// @Success 200 {string} string "iCalendar data"
+// @Success 207 {string} string "Multistatus"
+// @Failure 401 {object} map[string]string
+// @Failure 404 {string} string
+// @Failure 412 {string} string
+// @Router /dav/{path} [get]
+func (c *CalDAVController) Serve(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	path, ok := parseDAVPath(ctx.Param("path"))
+	if !ok {
+		ctx.String(http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch ctx.Request.Method {
+	case "OPTIONS":
+		ctx.Header("DAV", "1, 3, calendar-access")
+		ctx.Header("Allow", strings.Join(DAVMethods, ", "))
+		ctx.Status(http.StatusOK)
+	case "PROPFIND":
+		c.propfind(ctx, userId.(string), path)
+	case "REPORT":
+		c.report(ctx, userId.(string), path)
+	case "GET", "HEAD":
+		c.get(ctx, userId.(string), path)
+	case "PUT":
+		c.put(ctx, userId.(string), path)
+	case "DELETE":
+		c.delete(ctx, userId.(string), path)
+	default:
+		ctx.String(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (c *CalDAVController) propfind(ctx *gin.Context, userId string, path davPath) {
+	request, err := caldav.ParsePropfind(ctx.Request.Body)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	children := ctx.GetHeader("Depth") != "0"
+
+	respond := func(href string, props []caldav.Property) *caldav.Response {
+		found, missing := request.Select(props)
+		return &caldav.Response{Href: href, Found: found, Missing: missing}
+	}
+
+	var responses []*caldav.Response
+	switch path.resource {
+	case davRoot:
+		responses = append(responses, respond(DAVPrefix+"/", collectionProps("")))
+	case davPrincipal:
+		responses = append(responses, respond(principalHref, principalProps(ctx.GetString("email"))))
+	case davHome:
+		responses = append(responses, respond(homeHref, collectionProps("Calendars")))
+		if children {
+			lists, ctag, err := c.calendars(ctx, userId)
+			if err != nil {
+				writeDAVError(ctx, err)
+				return
+			}
+			for _, list := range lists {
+				responses = append(responses, respond(calendarHref(list), calendarProps(list, ctag)))
+			}
+		}
+	case davCalendar:
+		list, err := c.service.GetCalendar(ctx.Request.Context(), userId, path.listId)
+		if err != nil {
+			writeDAVError(ctx, err)
+			return
+		}
+		version, err := c.service.GetVersion(ctx.Request.Context(), userId)
+		if err != nil {
+			writeDAVError(ctx, err)
+			return
+		}
+		responses = append(responses, respond(calendarHref(list), calendarProps(list, calendarCTag(version))))
+		if children {
+			todos, err := c.service.ListTodos(ctx.Request.Context(), userId, path.listId)
+			if err != nil {
+				writeDAVError(ctx, err)
+				return
+			}
+			objects, err := c.objects(ctx, userId, request, todos)
+			if err != nil {
+				writeDAVError(ctx, err)
+				return
+			}
+			responses = append(responses, objects...)
+		}
+	case davObject:
+		todo, err := c.service.GetTodo(ctx.Request.Context(), userId, path.listId, path.name)
+		if err != nil {
+			writeDAVError(ctx, err)
+			return
+		}
+		objects, err := c.objects(ctx, userId, request, []*model.Todo{todo})
+		if err != nil {
+			writeDAVError(ctx, err)
+			return
+		}
+		responses = append(responses, objects...)
+	}
+
+	writeMultistatus(ctx, responses)
+}
+
+func (c *CalDAVController) report(ctx *gin.Context, userId string, path davPath) {
+	if path.resource != davCalendar {
+		ctx.String(http.StatusForbidden, "Reports are only supported on calendars")
+		return
+	}
+	report, err := caldav.ParseReport(ctx.Request.Body)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var todos []*model.Todo
+	var responses []*caldav.Response
+	switch report.Name {
+	case caldav.CalendarQuery:
+		all, err := c.service.ListTodos(ctx.Request.Context(), userId, path.listId)
+		if err != nil {
+			writeDAVError(ctx, err)
+			return
+		}
+		if report.Component != "" && report.Component != "VTODO" {
+			break
+		}
+		for _, todo := range all {
+			if !report.Open || !todo.Completed {
+				todos = append(todos, todo)
+			}
+		}
+	case caldav.CalendarMultiget:
+		for _, href := range report.Hrefs {
+			missing := &caldav.Response{Href: href, Status: http.StatusNotFound}
+			target, ok := parseDAVHref(href)
+			if !ok || target.resource != davObject {
+				responses = append(responses, missing)
+				continue
+			}
+			todo, err := c.service.GetTodo(ctx.Request.Context(), userId, target.listId, target.name)
+			if err != nil {
+				if errors.HTTPStatus(err) != http.StatusNotFound {
+					writeDAVError(ctx, err)
+					return
+				}
+				responses = append(responses, missing)
+				continue
+			}
+			todos = append(todos, todo)
+		}
+	default:
+		ctx.String(http.StatusForbidden, "Unsupported report")
+		return
+	}
+
+	objects, err := c.objects(ctx, userId, &report.Props, todos)
+	if err != nil {
+		writeDAVError(ctx, err)
+		return
+	}
+	writeMultistatus(ctx, append(objects, responses...))
+}
+
+// objects describes todo resources. Their calendar data is only rendered
+// when it is asked for by name, or by a REPORT for all properties.
+func (c *CalDAVController) objects(ctx *gin.Context, userId string, request *caldav.PropRequest, todos []*model.Todo) ([]*caldav.Response, error) {
+	var data []string
+	if request.Has(caldav.CalDAV("calendar-data")) || (request.AllProp && ctx.Request.Method == "REPORT") {
+		var err error
+		if data, err = c.service.RenderTodos(ctx.Request.Context(), userId, todos); err != nil {
+			return nil, err
+		}
+	}
+
+	responses := make([]*caldav.Response, len(todos))
+	for i, todo := range todos {
+		props := []caldav.Property{
+			{Name: caldav.DAV("resourcetype")},
+			{Name: caldav.DAV("getetag"), Value: caldav.Escape(todoETag(todo, nil))},
+			{Name: caldav.DAV("getcontenttype"), Value: objectContentType},
+			{Name: caldav.DAV("getlastmodified"), Value: todo.UpdatedAt.UTC().Format(http.TimeFormat)},
+		}
+		if data != nil {
+			props = append(props, caldav.Property{Name: caldav.CalDAV("calendar-data"), Value: caldav.Escape(data[i])})
+		}
+		found, missing := request.Select(props)
+		responses[i] = &caldav.Response{Href: objectHref(todo), Found: found, Missing: missing}
+	}
+	return responses, nil
+}
+
+func (c *CalDAVController) calendars(ctx *gin.Context, userId string) ([]*model.List, string, error) {
+	lists, err := c.service.ListCalendars(ctx.Request.Context(), userId)
+	if err != nil {
+		return nil, "", err
+	}
+	version, err := c.service.GetVersion(ctx.Request.Context(), userId)
+	if err != nil {
+		return nil, "", err
+	}
+	return lists, calendarCTag(version), nil
+}
+
+func (c *CalDAVController) get(ctx *gin.Context, userId string, path davPath) {
+	if path.resource != davObject {
+		ctx.String(http.StatusMethodNotAllowed, "Only todo resources can be downloaded")
+		return
+	}
+	todo, err := c.service.GetTodo(ctx.Request.Context(), userId, path.listId, path.name)
+	if err != nil {
+		writeDAVError(ctx, err)
+		return
+	}
+
+	if checkCache(ctx, todoETag(todo, nil), todo.UpdatedAt) {
+		return
+	}
+	data, err := c.service.RenderTodos(ctx.Request.Context(), userId, []*model.Todo{todo})
+	if err != nil {
+		writeDAVError(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, ical.ContentType, []byte(data[0]))
+}
+
+func (c *CalDAVController) put(ctx *gin.Context, userId string, path davPath) {
+	if path.resource != davObject {
+		ctx.String(http.StatusMethodNotAllowed, "Only todo resources can be stored")
+		return
+	}
+
+	var expected model.Precondition
+	if header := ctx.GetHeader("If-Match"); header != "" {
+		expected = parseIfMatch(header)
+	}
+	mustCreate := strings.TrimSpace(ctx.GetHeader("If-None-Match")) == "*"
+
+	_, created, err := c.service.PutTodo(ctx.Request.Context(), userId, path.listId, path.name, ctx.Request.Body, expected, mustCreate)
+	if err != nil {
+		writeDAVError(ctx, err)
+		return
+	}
+	// No ETag: the todo is not stored byte for byte as sent, so clients
+	// must fetch it again (RFC 4791, section 5.3.4)
+	if created {
+		ctx.Status(http.StatusCreated)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *CalDAVController) delete(ctx *gin.Context, userId string, path davPath) {
+	if path.resource != davObject {
+		ctx.String(http.StatusForbidden, "Only todo resources can be deleted")
+		return
+	}
+
+	var expected model.Precondition
+	if header := ctx.GetHeader("If-Match"); header != "" {
+		expected = parseIfMatch(header)
+	}
+	if err := c.service.DeleteTodo(ctx.Request.Context(), userId, path.listId, path.name, expected); err != nil {
+		writeDAVError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func collectionProps(name string) []caldav.Property {
+	props := []caldav.Property{
+		{Name: caldav.DAV("resourcetype"), Value: "<d:collection/>"},
+		{Name: caldav.DAV("current-user-principal"), Value: caldav.Href(principalHref)},
+	}
+	if name != "" {
+		props = append(props, caldav.Property{Name: caldav.DAV("displayname"), Value: caldav.Escape(name)})
+	}
+	return props
+}
+
+func principalProps(email string) []caldav.Property {
+	return []caldav.Property{
+		{Name: caldav.DAV("resourcetype"), Value: "<d:principal/>"},
+		{Name: caldav.DAV("displayname"), Value: caldav.Escape(email)},
+		{Name: caldav.DAV("current-user-principal"), Value: caldav.Href(principalHref)},
+		{Name: caldav.DAV("principal-URL"), Value: caldav.Href(principalHref)},
+		{Name: caldav.CalDAV("calendar-home-set"), Value: caldav.Href(homeHref)},
+		{Name: caldav.CalDAV("calendar-user-address-set"), Value: caldav.Href("mailto:" + email)},
+	}
+}
+
+func calendarProps(list *model.List, ctag string) []caldav.Property {
+	return []caldav.Property{
+		{Name: caldav.DAV("resourcetype"), Value: "<d:collection/><c:calendar/>"},
+		{Name: caldav.DAV("displayname"), Value: caldav.Escape(list.Name)},
+		{Name: caldav.DAV("current-user-principal"), Value: caldav.Href(principalHref)},
+		{Name: caldav.DAV("owner"), Value: caldav.Href(principalHref)},
+		{Name: caldav.CalDAV("supported-calendar-component-set"), Value: `<c:comp name="VTODO"/>`},
+		{Name: caldav.CalendarServer("getctag"), Value: caldav.Escape(ctag)},
+		{Name: caldav.DAV("supported-report-set"), Value: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>"},
+		{Name: caldav.DAV("current-user-privilege-set"), Value: "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>" +
+			"<d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>"},
+	}
+}
+
+// calendarCTag changes whenever any of the user's todos does, which tells
+// clients to look for changes in their calendars
+func calendarCTag(version *model.TodoListVersion) string {
+	return strconv.FormatInt(version.Seq, 10)
+}
+
+func writeMultistatus(ctx *gin.Context, responses []*caldav.Response) {
+	ctx.Header("Content-Type", caldav.ContentType)
+	ctx.Status(http.StatusMultiStatus)
+	if err := caldav.WriteMultistatus(ctx.Writer, responses); err != nil {
+		log.Printf("CalDAV error: %v", err)
+	}
+}
+
+// writeDAVError reports an error as plain text, which is what CalDAV
+// clients show
+func writeDAVError(ctx *gin.Context, err error) {
+	ctx.String(errors.HTTPStatus(err), errors.Message(err))
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"todo-app/internal/ical"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	service   service.CalendarService
+	publicURL string
+}
+
+// NewCalendarController creates the calendar endpoints. Feed URLs start
+// with publicURL, or with the address requests arrive at when it is empty.
+func NewCalendarController(service service.CalendarService, publicURL string) *CalendarController {
+	return &CalendarController{service: service, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// GetCalendar godoc
+// @Summary Get todos as a calendar
+// @Description Download the authenticated user's todos as an iCalendar file. Every todo is a VTODO; todos with a due date are also a VEVENT, so calendars that do not show tasks still show deadlines. Due dates at midnight in the todo's time zone are shown as all-day. Labels become categories and reminders alarms.
+// @Tags calendar
+// @Produce text/calendar
+// @Security BearerAuth
+// @Param component query string false "Only include one kind of component" Enums(vtodo, vevent)
+// @Param completed query bool false "Only include todos with this completion state"
+// @Success 200 {string} string "iCalendar data"
+// @Success 304 "The client's copy is current"
+// @Failure 400 {object} map[string]string
+// @Router /todos.ics [get]
+func (c *CalendarController) GetCalendar(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	c.writeCalendar(ctx, userId.(string))
+}
+
+// GetFeedCalendar godoc
+// @Summary Get a subscribed calendar
+// @Description The calendar of the user a feed token belongs to, for calendar apps to subscribe to without signing in. Takes the same query as /todos.ics. Revoked or replaced tokens get 404.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token, optionally followed by .ics"
+// @Param component query string false "Only include one kind of component" Enums(vtodo, vevent)
+// @Param completed query bool false "Only include todos with this completion state"
+// @Success 200 {string} string "iCalendar data"
+// @Success 304 "The client's copy is current"
+// @Failure 404 {object} map[string]string
+// @Router /calendar/feed/{token} [get]
+func (c *CalendarController) GetFeedCalendar(ctx *gin.Context) {
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	userId, err := c.service.FindFeedOwner(ctx.Request.Context(), token)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	c.writeCalendar(ctx, userId)
+}
+
+func (c *CalendarController) writeCalendar(ctx *gin.Context, userId string) {
+	var query model.CalendarQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := c.service.GetVersion(ctx.Request.Context(), userId)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if checkCache(ctx, listETag(ctx, version), version.LastModified) {
+		return
+	}
+
+	ctx.Header("Content-Type", ical.ContentType)
+	ctx.Status(http.StatusOK)
+	if err := c.service.WriteCalendar(ctx.Request.Context(), userId, &query, ctx.Writer); err != nil {
+		if !ctx.Writer.Written() {
+			ctx.Header("Content-Type", "")
+			writeError(ctx, err)
+			return
+		}
+		log.Printf("Calendar error: %v", err)
+	}
+}
+
+// GetFeed godoc
+// @Summary Get the calendar feed
+// @Description Tell whether the authenticated user has a calendar feed and when it was created. The feed URL is only shown when it is created.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.CalendarFeed
+// @Failure 404 {object} map[string]string
+// @Router /calendar/feed [get]
+func (c *CalendarController) GetFeed(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	feed, err := c.service.GetFeed(ctx.Request.Context(), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, feed)
+}
+
+// CreateFeed godoc
+// @Summary Create a calendar feed
+// @Description Create a secret URL calendar apps can subscribe to without signing in. Creating a feed again replaces the URL, so the old one stops working. Keep the URL private: anyone holding it can read the todos.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} model.CalendarFeed
+// @Router /calendar/feed [post]
+func (c *CalendarController) CreateFeed(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	feed, err := c.service.CreateFeed(ctx.Request.Context(), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	feed.URL = c.baseURL(ctx) + "/calendar/feed/" + feed.Token + ".ics"
+	// The token is only ever shown here; nothing on the way may keep it,
+	// including the idempotency middleware
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, feed)
+}
+
+// RevokeFeed godoc
+// @Summary Revoke the calendar feed
+// @Description Stop the authenticated user's calendar feed URL from working
+// @Tags calendar
+// @Security BearerAuth
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /calendar/feed [delete]
+func (c *CalendarController) RevokeFeed(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	if err := c.service.RevokeFeed(ctx.Request.Context(), userId.(string)); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// baseURL is where the API is reached from outside
+func (c *CalendarController) baseURL(ctx *gin.Context) string {
+	if c.publicURL != "" {
+		return c.publicURL
+	}
+	scheme := "http"
+	if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host
+}
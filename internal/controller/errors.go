@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"todo-app/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeError maps a service error onto the JSON error body used by the
+// todo endpoints. API errors keep their status, missing todos become 404
+// and anything else is reported as an internal error.
+func writeError(ctx *gin.Context, err error) {
+	ctx.JSON(errors.HTTPStatus(err), gin.H{"error": errors.Message(err)})
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	stderrors "errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sends the strong entity tag of a todo, rendered as asked by
+// render, which is nil when the request cannot ask for HTML
+func setETag(ctx *gin.Context, todo *model.Todo, render *model.RenderQuery) {
+	ctx.Header("ETag", todoETag(todo, render))
+}
+
+// todoETag starts with the version of a todo, which is what If-Match
+// checks. The progress of its subtasks and the rendering of its description
+// change the body without changing the todo, so they are added after it,
+// as in "3-p50-html".
+func todoETag(todo *model.Todo, render *model.RenderQuery) string {
+	tag := strconv.FormatInt(todo.Version, 10)
+	if todo.Progress != nil {
+		tag += "-p" + strconv.Itoa(*todo.Progress)
+	}
+	if render != nil && render.Render != "" {
+		tag += "-" + render.Render
+	}
+	return strconv.Quote(tag)
+}
+
+// listETag is a weak entity tag for a page of todos. Besides the version of
+// the user's todos it covers the query, since each query is its own
+// representation.
+func listETag(ctx *gin.Context, version *model.TodoListVersion) string {
+	hash := fnv.New64a()
+	fmt.Fprint(hash, version.Seq, version.Labels, ctx.Request.URL.Query().Encode())
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// checkCache sends the cache headers of a read and reports whether the
+// client's copy, described by If-None-Match or If-Modified-Since, is still
+// current. In that case it has answered 304 and the handler is done.
+func checkCache(ctx *gin.Context, etag string, modified time.Time) bool {
+	// Responses depend on who is asking and must be revalidated before reuse
+	ctx.Header("Cache-Control", "private, no-cache")
+	ctx.Header("Vary", "Authorization")
+	ctx.Header("ETag", etag)
+	if !modified.IsZero() {
+		ctx.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(ctx.Request, etag, modified) {
+		ctx.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// when there is none. If-None-Match compares tags weakly.
+func notModified(request *http.Request, etag string, modified time.Time) bool {
+	if values := request.Header.Values("If-None-Match"); len(values) > 0 {
+		for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// Last-Modified only has whole seconds
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// bindIfMatch reads the If-Match header of a request that changes a todo.
+// It responds with 428 and returns false when the header is required but
+// missing.
+func (c *TodoController) bindIfMatch(ctx *gin.Context) (model.Precondition, bool) {
+	values := ctx.Request.Header.Values("If-Match")
+	if len(values) == 0 {
+		if c.requireIfMatch {
+			writeError(ctx, errors.ErrPreconditionRequired)
+			return nil, false
+		}
+		return nil, true
+	}
+	return parseIfMatch(strings.Join(values, ",")), true
+}
+
+// parseIfMatch turns an If-Match header into the versions it allows, read
+// from the start of each tag. "*" allows any version. Tags are compared strongly, so weak tags and tags
+// this server never issued match nothing.
+func parseIfMatch(header string) model.Precondition {
+	expected := model.Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		number, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			continue
+		}
+		expected = append(expected, number)
+	}
+	return expected
+}
+
+// writeChangeError reports a failed change to a todo. When the todo has
+// moved on from the version the client expected, the response carries the
+// todo as it is now, so the client can merge and retry.
+func (c *TodoController) writeChangeError(ctx *gin.Context, userId string, render *model.RenderQuery, err error) {
+	if !stderrors.Is(err, errors.ErrPreconditionFailed) {
+		writeError(ctx, err)
+		return
+	}
+
+	current, getErr := c.service.GetTodo(ctx.Request.Context(), ctx.Param("id"), userId)
+	if getErr != nil {
+		writeError(ctx, getErr)
+		return
+	}
+	renderTodos(render, current)
+	setETag(ctx, current, render)
+	ctx.JSON(http.StatusPreconditionFailed, model.TodoPreconditionFailed{Error: errors.Message(err), Todo: current})
+}
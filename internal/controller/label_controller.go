@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"net/http"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabelController struct {
+	service service.LabelService
+}
+
+func NewLabelController(service service.LabelService) *LabelController {
+	return &LabelController{service: service}
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Create a new label for the authenticated user. Names are unique per user, ignoring case.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param label body model.LabelCreate true "Label details"
+// @Success 201 {object} model.Label
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /labels [post]
+func (c *LabelController) CreateLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var labelCreate model.LabelCreate
+	if err := ctx.ShouldBindJSON(&labelCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := c.service.CreateLabel(ctx.Request.Context(), userId.(string), &labelCreate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, label)
+}
+
+// GetAllLabels godoc
+// @Summary Get all labels
+// @Description Retrieve all labels of the authenticated user, sorted by name
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Label
+// @Router /labels [get]
+func (c *LabelController) GetAllLabels(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	labels, err := c.service.GetAllLabels(ctx.Request.Context(), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, labels)
+}
+
+// GetLabel godoc
+// @Summary Get a single label
+// @Description Get a label by ID
+// @Tags labels
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Label ID"
+// @Success 200 {object} model.Label
+// @Failure 404 {object} map[string]string
+// @Router /labels/{id} [get]
+func (c *LabelController) GetLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	label, err := c.service.GetLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, label)
+}
+
+// UpdateLabel godoc
+// @Summary Rename or recolour a label
+// @Description Update a label. Todos reference labels by ID, so a rename applies to every todo at once.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Label ID"
+// @Param label body model.LabelUpdate true "New name and/or colour"
+// @Success 200 {object} model.Label
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /labels/{id} [put]
+func (c *LabelController) UpdateLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var labelUpdate model.LabelUpdate
+	if err := ctx.ShouldBindJSON(&labelUpdate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := c.service.UpdateLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string), &labelUpdate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Delete a label and detach it from every todo
+// @Tags labels
+// @Security BearerAuth
+// @Param id path string true "Label ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]string
+// @Router /labels/{id} [delete]
+func (c *LabelController) DeleteLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	if err := c.service.DeleteLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string)); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// MergeLabel godoc
+// @Summary Merge a label into another
+// @Description Replace the label with the target label on every todo, then delete it
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID of the label to merge away"
+// @Param merge body model.LabelMerge true "Target label"
+// @Success 200 {object} model.Label
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /labels/{id}/merge [post]
+func (c *LabelController) MergeLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var merge model.LabelMerge
+	if err := ctx.ShouldBindJSON(&merge); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := c.service.MergeLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string), &merge)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, label)
+}
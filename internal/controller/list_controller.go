@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"net/http"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListController struct {
+	service service.ListService
+}
+
+func NewListController(service service.ListService) *ListController {
+	return &ListController{service: service}
+}
+
+// CreateList godoc
+// @Summary Create a list
+// @Description Create a new list for grouping todos
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param list body model.ListCreate true "List details"
+// @Success 201 {object} model.List
+// @Failure 400 {object} map[string]string
+// @Router /lists [post]
+func (c *ListController) CreateList(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var listCreate model.ListCreate
+	if err := ctx.ShouldBindJSON(&listCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := c.service.CreateList(ctx.Request.Context(), userId.(string), &listCreate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, list)
+}
+
+// GetAllLists godoc
+// @Summary Get all lists
+// @Description Retrieve the lists of the authenticated user, Inbox first, then by sort order
+// @Tags lists
+// @Produce json
+// @Security BearerAuth
+// @Param archived query bool false "Include archived lists"
+// @Success 200 {array} model.List
+// @Router /lists [get]
+func (c *ListController) GetAllLists(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lists, err := c.service.GetAllLists(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lists)
+}
+
+// GetList godoc
+// @Summary Get a single list
+// @Description Get a list by ID
+// @Tags lists
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "List ID"
+// @Success 200 {object} model.List
+// @Failure 404 {object} map[string]string
+// @Router /lists/{id} [get]
+func (c *ListController) GetList(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	list, err := c.service.GetList(ctx.Request.Context(), ctx.Param("id"), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// GetListTodos godoc
+// @Summary Get the todos of a list
+// @Description Retrieve a page of the todos in a list. Accepts the same filtering, sorting and pagination options as GET /todos.
+// @Tags lists
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "List ID"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param completed query bool false "Only return todos with this completion state"
+// @Param sort query string false "Sort field" Enums(createdAt, updatedAt, title) default(createdAt)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
+// @Success 200 {object} model.TodoPage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /lists/{id}/todos [get]
+func (c *ListController) GetListTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
+	var query model.TodoQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.service.GetListTodos(ctx.Request.Context(), ctx.Param("id"), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	renderTodos(render, page.Items...)
+	ctx.JSON(http.StatusOK, page)
+}
+
+// UpdateList godoc
+// @Summary Update a list
+// @Description Rename, re-icon, reorder, archive or unarchive a list. The Inbox cannot be archived.
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "List ID"
+// @Param list body model.ListUpdate true "Changes to apply"
+// @Success 200 {object} model.List
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /lists/{id} [put]
+func (c *ListController) UpdateList(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var listUpdate model.ListUpdate
+	if err := ctx.ShouldBindJSON(&listUpdate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := c.service.UpdateList(ctx.Request.Context(), ctx.Param("id"), userId.(string), &listUpdate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// DeleteList godoc
+// @Summary Delete a list
+// @Description Delete a list. Its todos move to the Inbox, or to the trash with todos=delete. The Inbox cannot be deleted.
+// @Tags lists
+// @Security BearerAuth
+// @Param id path string true "List ID"
+// @Param todos query string false "What to do with the list's todos" Enums(move, delete) default(move)
+// @Success 204 {object} nil
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /lists/{id} [delete]
+func (c *ListController) DeleteList(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.ListDeleteQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.DeleteList(ctx.Request.Context(), ctx.Param("id"), userId.(string), &query); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"todo-app/internal/markdown"
+	"todo-app/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindRender reads the ?render option. It responds with 400 and returns
+// false when the option is invalid.
+func bindRender(ctx *gin.Context) (*model.RenderQuery, bool) {
+	var query model.RenderQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &query, true
+}
+
+// renderTodos fills in the description representations asked for by query
+func renderTodos(query *model.RenderQuery, todos ...*model.Todo) {
+	if query.Render != "html" {
+		return
+	}
+	for _, todo := range todos {
+		if todo.Description != "" {
+			todo.DescriptionHTML = markdown.ToHTML(todo.Description)
+		}
+	}
+}
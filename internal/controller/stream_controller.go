@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"time"
+	"todo-app/internal/events"
+	"todo-app/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// LastEventIDHeader is sent by EventSource when it reconnects
+	LastEventIDHeader = "Last-Event-ID"
+	// streamWriteTimeout is how long a client has to take each message
+	// before its stream is closed
+	streamWriteTimeout = 10 * time.Second
+	// maxStreamMessageBytes caps what a WebSocket client may send; it is
+	// never expected to send anything but control frames
+	maxStreamMessageBytes = 4 << 10
+)
+
+// errStreamBehind ends a stream the bus dropped because the client did not
+// keep up; the client reconnects and resumes from its last event
+var errStreamBehind = stderrors.New("client fell behind")
+
+// streamedEvents are the todo events sent on streams. A completion is
+// already sent as an update.
+var streamedEvents = map[string]bool{
+	model.EventTodoCreated: true,
+	model.EventTodoUpdated: true,
+	model.EventTodoDeleted: true,
+}
+
+type StreamController struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+}
+
+// NewStreamController takes the bus todo events are read from and how
+// often idle streams are sent a heartbeat
+func NewStreamController(bus *events.Bus, heartbeat time.Duration) *StreamController {
+	return &StreamController{bus: bus, heartbeat: heartbeat}
+}
+
+// StreamTodos godoc
+// @Summary Stream todo events
+// @Description Push an event whenever a todo of the authenticated user is created, updated or deleted, as Server-Sent Events. Each event is named after its type and carries a model.StreamEvent as data. A client that reconnects with the Last-Event-ID header, or the lastEventId parameter, is first sent the events it missed. When those are no longer known, or on a first connection, a reset event is sent instead and the client should reload its todos. A comment is sent as a heartbeat on idle streams. Browsers may pass the token as access_token since EventSource cannot send headers. The stream is closed with an expired event when the token expires, and without one when the client falls too far behind, in which case it should reconnect.
+// @Tags todos
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param lastEventId query string false "ID of the last event received, when the Last-Event-ID header cannot be sent"
+// @Param access_token query string false "Token, when the Authorization header cannot be sent"
+// @Success 200 {object} model.StreamEvent
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /todos/stream [get]
+func (c *StreamController) StreamTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	sub, replay, resumed, err := c.bus.Subscribe(userId.(string), lastEventID(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	defer c.bus.Unsubscribe(sub)
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Keep proxies such as nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	out := &sseOutput{writer: ctx.Writer, controller: http.NewResponseController(ctx.Writer)}
+	if err := c.pump(ctx.Request.Context().Done(), sub, replay, resumed, tokenExpiry(ctx), out); err != nil {
+		log.Printf("Event stream closed: %v", err)
+	}
+}
+
+// StreamTodosWebSocket godoc
+// @Summary Stream todo events over a WebSocket
+// @Description Upgrade to a WebSocket that is sent the same events as /todos/stream, each as a JSON text message holding a model.StreamEvent, including heartbeat messages on idle connections. The token is checked during the handshake, from the Authorization header or the access_token parameter; pass lastEventId to be sent the events missed since then.
+// @Tags todos
+// @Security BearerAuth
+// @Param lastEventId query string false "ID of the last event received"
+// @Param access_token query string false "Token, when the Authorization header cannot be sent"
+// @Success 101 {object} model.StreamEvent
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /todos/ws [get]
+func (c *StreamController) StreamTodosWebSocket(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	// Subscribe before upgrading so a refusal is still an HTTP response
+	sub, replay, resumed, err := c.bus.Subscribe(userId.(string), lastEventID(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	defer c.bus.Unsubscribe(sub)
+
+	expiresAt := tokenExpiry(ctx)
+	server := websocket.Server{
+		// The token authenticates the connection rather than cookies, so
+		// pages on any origin may open one
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxStreamMessageBytes
+
+			// Nothing is expected from the client; reading is how a closed
+			// connection is noticed
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var message []byte
+				for websocket.Message.Receive(ws, &message) == nil {
+				}
+			}()
+
+			if err := c.pump(closed, sub, replay, resumed, expiresAt, &wsOutput{conn: ws}); err != nil {
+				log.Printf("Event stream closed: %v", err)
+			}
+		},
+	}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// streamOutput writes stream events in the format of a transport
+type streamOutput interface {
+	send(event *model.StreamEvent) error
+	heartbeat() error
+}
+
+// pump sends a reset or the replayed events, then every event of the
+// subscription, until the client goes away, the subscription is dropped for
+// falling behind or the token expires
+func (c *StreamController) pump(done <-chan struct{}, sub *events.Subscription, replay []*events.Message, resumed bool, expiresAt time.Time, out streamOutput) error {
+	if !resumed {
+		if err := out.send(&model.StreamEvent{ID: sub.Cursor, Event: model.StreamReset, OccurredAt: time.Now()}); err != nil {
+			return err
+		}
+	}
+	for _, message := range replay {
+		if err := sendMessage(out, message); err != nil {
+			return err
+		}
+	}
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case message, ok := <-sub.C:
+			if !ok {
+				return errStreamBehind
+			}
+			if err := sendMessage(out, message); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if err := out.heartbeat(); err != nil {
+				return err
+			}
+		case <-expired:
+			return out.send(&model.StreamEvent{Event: model.StreamExpired, OccurredAt: time.Now()})
+		}
+	}
+}
+
+func sendMessage(out streamOutput, message *events.Message) error {
+	if !streamedEvents[message.Event.Type] {
+		return nil
+	}
+	return out.send(&model.StreamEvent{
+		ID:         message.ID,
+		Event:      message.Event.Type,
+		OccurredAt: message.Event.OccurredAt,
+		Todo:       message.Event.Todo,
+	})
+}
+
+// sseOutput writes Server-Sent Events
+type sseOutput struct {
+	writer     gin.ResponseWriter
+	controller *http.ResponseController
+}
+
+func (o *sseOutput) send(event *model.StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	frame := "event: " + event.Event + "\ndata: " + string(data) + "\n\n"
+	if event.ID != "" {
+		frame = "id: " + event.ID + "\n" + frame
+	}
+	return o.write(frame)
+}
+
+func (o *sseOutput) heartbeat() error {
+	return o.write(": heartbeat\n\n")
+}
+
+func (o *sseOutput) write(frame string) error {
+	// Not every writer supports deadlines, in which case there is none
+	o.controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := o.writer.WriteString(frame); err != nil {
+		return err
+	}
+	o.writer.Flush()
+	return nil
+}
+
+// wsOutput writes WebSocket text messages
+type wsOutput struct {
+	conn *websocket.Conn
+}
+
+func (o *wsOutput) send(event *model.StreamEvent) error {
+	o.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return websocket.JSON.Send(o.conn, event)
+}
+
+func (o *wsOutput) heartbeat() error {
+	return o.send(&model.StreamEvent{Event: model.StreamHeartbeat, OccurredAt: time.Now()})
+}
+
+func lastEventID(ctx *gin.Context) string {
+	if id := ctx.GetHeader(LastEventIDHeader); id != "" {
+		return id
+	}
+	return ctx.Query("lastEventId")
+}
+
+// tokenExpiry is when the token the stream was opened with expires, or the
+// zero time when it does not
+func tokenExpiry(ctx *gin.Context) time.Time {
+	expiresAt, _ := ctx.Get("tokenExpiresAt")
+	t, _ := expiresAt.(time.Time)
+	return t
+}
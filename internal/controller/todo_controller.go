@@ -1,19 +1,26 @@
 package controller
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 	"todo-app/internal/model"
+	"todo-app/internal/portability"
 	"todo-app/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TodoController struct {
-	service service.TodoService
+	service        service.TodoService
+	requireIfMatch bool
 }
 
-func NewTodoController(service service.TodoService) *TodoController {
-	return &TodoController{service: service}
+// NewTodoController creates the todo endpoints. With requireIfMatch, changes
+// to a todo without an If-Match header are refused with 428.
+func NewTodoController(service service.TodoService, requireIfMatch bool) *TodoController {
+	return &TodoController{service: service, requireIfMatch: requireIfMatch}
 }
 
 // CreateTodo godoc
@@ -24,7 +31,11 @@ func NewTodoController(service service.TodoService) *TodoController {
 // @Produce json
 // @Security BearerAuth
 // @Param todo body model.TodoCreate true "Todo details"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; a retry gets the first response replayed"
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
 // @Success 201 {object} model.Todo
+// @Header 201 {string} ETag "Version of the todo"
+// @Failure 400 {object} map[string]string
 // @Router /todos [post]
 func (c *TodoController) CreateTodo(ctx *gin.Context) {
 	userId, exists := ctx.Get("userId")
@@ -33,6 +44,11 @@ func (c *TodoController) CreateTodo(ctx *gin.Context) {
 		return
 	}
 
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
 	var todoCreate model.TodoCreate
 	if err := ctx.ShouldBindJSON(&todoCreate); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -41,21 +57,76 @@ func (c *TodoController) CreateTodo(ctx *gin.Context) {
 
 	createdTodo, err := c.service.CreateTodo(ctx.Request.Context(), userId.(string), &todoCreate)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
+	renderTodos(render, createdTodo)
+	setETag(ctx, createdTodo, render)
 	ctx.JSON(http.StatusCreated, createdTodo)
 }
 
+// QuickAddTodo godoc
+// @Summary Create a todo from a line of text
+// @Description Read a todo from free text such as "Pay rent tomorrow 9am #finance !high every month" and create it. Dates and times are read in the user's time zone: today, tomorrow, weekdays, "next week", "in 3 days", "march 15" or 2024-03-15, with 9am, 9:30pm, 21:00 or noon. #label attaches a label, created when missing, with underscores for spaces; !high, !medium and !low set the priority; daily, "every 2 weeks" or "every monday and thursday" make the todo recur. The rest is the title, and words in double quotes always stay in it. The response shows how the text was read; with preview=true nothing is created.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.QuickAdd true "Text of the todo"
+// @Param preview query bool false "Only parse the text"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; a retry gets the first response replayed"
+// @Success 200 {object} model.QuickAddResult "Preview"
+// @Success 201 {object} model.QuickAddResult
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /todos/quick [post]
+func (c *TodoController) QuickAddTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.QuickAddQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var quickAdd model.QuickAdd
+	if err := ctx.ShouldBindJSON(&quickAdd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.service.QuickAddTodo(ctx.Request.Context(), userId.(string), &quickAdd, query.Preview)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if result.Todo == nil {
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+	setETag(ctx, result.Todo, nil)
+	ctx.JSON(http.StatusCreated, result)
+}
+
 // GetTodo godoc
 // @Summary Get a single todo
-// @Description Get a todo item by ID
+// @Description Get a todo item by ID. Send the ETag or Last-Modified of a copy you hold as If-None-Match or If-Modified-Since to get 304 while it is current.
 // @Tags todos
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
+// @Param If-None-Match header string false "ETag of the copy held by the client"
+// @Param If-Modified-Since header string false "Last-Modified of the copy held by the client"
 // @Success 200 {object} model.Todo
+// @Success 304 "The client's copy is current"
+// @Header 200,304 {string} ETag "Version of the todo, followed by the progress of its subtasks and the rendering when they apply"
+// @Header 200,304 {string} Last-Modified "When the todo last changed; left out for todos with subtasks"
 // @Router /todos/{id} [get]
 func (c *TodoController) GetTodo(ctx *gin.Context) {
 	userId, exists := ctx.Get("userId")
@@ -64,6 +135,11 @@ func (c *TodoController) GetTodo(ctx *gin.Context) {
 		return
 	}
 
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
 	id := ctx.Param("id")
 
 	todo, err := c.service.GetTodo(ctx.Request.Context(), id, userId.(string))
@@ -76,16 +152,42 @@ func (c *TodoController) GetTodo(ctx *gin.Context) {
 		return
 	}
 
+	// Subtasks change the progress of their parent without changing when it
+	// was modified, so only its ETag can tell whether a copy is current
+	modified := todo.UpdatedAt
+	if todo.Progress != nil {
+		modified = time.Time{}
+	}
+	if checkCache(ctx, todoETag(todo, render), modified) {
+		return
+	}
+	renderTodos(render, todo)
 	ctx.JSON(http.StatusOK, todo)
 }
 
 // GetAllTodos godoc
 // @Summary Get all todos
-// @Description Retrieve all todos for the authenticated user
+// @Description Retrieve a page of todos for the authenticated user, optionally filtered and sorted. Send the ETag or Last-Modified of a page you hold as If-None-Match or If-Modified-Since to get 304 while none of your todos has changed.
 // @Tags todos
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {array} model.Todo
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param completed query bool false "Only return todos with this completion state"
+// @Param createdAfter query string false "Only return todos created after this RFC 3339 time"
+// @Param createdBefore query string false "Only return todos created before this RFC 3339 time"
+// @Param sort query string false "Sort field; position is the manual order, and todos without a priority come after low ones" Enums(createdAt, updatedAt, title, position, priority) default(createdAt)
+// @Param order query string false "Sort direction, ascending by default for position" Enums(asc, desc) default(desc)
+// @Param label query []string false "Label names, repeated or comma-separated" collectionFormat(multi)
+// @Param labelMatch query string false "Whether todos need all or any of the labels" Enums(all, any) default(all)
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
+// @Param If-None-Match header string false "ETag of the page held by the client"
+// @Param If-Modified-Since header string false "Last-Modified of the page held by the client"
+// @Success 200 {object} model.TodoPage
+// @Success 304 "The client's copy is current"
+// @Header 200,304 {string} ETag "Weak tag that changes whenever any of the user's todos changes"
+// @Header 200,304 {string} Last-Modified "When any of the user's todos last changed"
+// @Failure 400 {object} map[string]string
 // @Router /todos [get]
 func (c *TodoController) GetAllTodos(ctx *gin.Context) {
 	userId, exists := ctx.Get("userId")
@@ -94,25 +196,208 @@ func (c *TodoController) GetAllTodos(ctx *gin.Context) {
 		return
 	}
 
-	todos, err := c.service.GetAllTodos(ctx.Request.Context(), userId.(string))
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
+	var query model.TodoQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := c.service.GetListVersion(ctx.Request.Context(), userId.(string), &query)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(ctx, err)
+		return
+	}
+	if checkCache(ctx, listETag(ctx, version), version.LastModified) {
+		return
+	}
+
+	page, err := c.service.ListTodos(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, todos)
+	renderTodos(render, page.Items...)
+	ctx.JSON(http.StatusOK, page)
+}
+
+// SearchTodos godoc
+// @Summary Search todos
+// @Description Full-text search over the title and description of the authenticated user's todos. Highlights are HTML-escaped with matches wrapped in <mark> tags.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search terms"
+// @Param limit query int false "Maximum number of results (1-100)" default(20)
+// @Success 200 {array} model.TodoSearchResult
+// @Failure 400 {object} map[string]string
+// @Router /todos/search [get]
+func (c *TodoController) SearchTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.TodoSearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := c.service.SearchTodos(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
+
+// BulkTodos godoc
+// @Summary Apply an action to many todos
+// @Description Create, update, delete or complete up to 500 todos at once, chosen by ids or by a filter such as all completed todos last updated before a date. Updates apply a merge patch object, or a JSON Patch array, to each todo. Each item reports the status the equivalent single request would have. When the deployment supports transactions the request is atomic and one failure rolls back every item; otherwise successes are kept and failures reported. A 207 status means at least one item failed.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.TodoBulkRequest true "Bulk action"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; a retry gets the first response replayed"
+// @Success 200 {object} model.TodoBulkResponse
+// @Success 207 {object} model.TodoBulkResponse
+// @Failure 400 {object} map[string]string
+// @Router /todos/bulk [post]
+func (c *TodoController) BulkTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var request model.TodoBulkRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.service.BulkTodos(ctx.Request.Context(), userId.(string), &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	ctx.JSON(status, response)
+}
+
+// ExportTodos godoc
+// @Summary Export todos
+// @Description Download every todo of the authenticated user, subtasks included, as CSV, JSON or todo.txt. Lists and labels are exported by name. The file is streamed, so an error after the first todo ends it early.
+// @Tags todos
+// @Produce json
+// @Produce text/csv
+// @Produce text/plain
+// @Security BearerAuth
+// @Param format query string false "File format" Enums(csv, json, todotxt) default(json)
+// @Success 200 {array} model.TodoRecord
+// @Failure 400 {object} map[string]string
+// @Router /todos/export [get]
+func (c *TodoController) ExportTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.TodoExportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.Format == "" {
+		query.Format = portability.JSON
+	}
+
+	ctx.Header("Content-Type", portability.ContentType(query.Format))
+	ctx.Header("Content-Disposition", `attachment; filename="`+portability.FileName(query.Format)+`"`)
+	ctx.Status(http.StatusOK)
+	if err := c.service.ExportTodos(ctx.Request.Context(), userId.(string), query.Format, ctx.Writer); err != nil {
+		if !ctx.Writer.Written() {
+			ctx.Header("Content-Type", "")
+			ctx.Header("Content-Disposition", "")
+			writeError(ctx, err)
+			return
+		}
+		// The status is already sent; all that is left is cutting the file short
+		log.Printf("Export error: %v", err)
+	}
+}
+
+// ImportTodos godoc
+// @Summary Import todos
+// @Description Create todos from a CSV, JSON or todo.txt file sent as the request body, in the format exports use. Lists and labels are matched by name and created when missing. Todos the user already has, by exported id or by title and due date, are skipped. Every record is reported with the line it starts on, and records that cannot be imported are rejected without stopping the rest. With dryRun nothing is stored. At most 5000 todos and 10 MB are accepted.
+// @Tags todos
+// @Accept json
+// @Accept text/csv
+// @Accept text/plain
+// @Produce json
+// @Security BearerAuth
+// @Param format query string true "File format" Enums(csv, json, todotxt)
+// @Param dryRun query bool false "Only report what the import would do"
+// @Param file body string true "File contents"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; a retry gets the first response replayed"
+// @Success 200 {object} model.TodoImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /todos/import [post]
+func (c *TodoController) ImportTodos(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.TodoImportQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, model.MaxImportBytes)
+	result, err := c.service.ImportTodos(ctx.Request.Context(), userId.(string), query.Format, body, query.DryRun)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
 }
 
 // UpdateTodo godoc
-// @Summary Update a todo
-// @Description Update a todo item by ID
+// @Summary Replace a todo
+// @Description Replace the editable fields of a todo. Fields left out are cleared; use PATCH to change single fields. Parent, list and labels are kept. Send the ETag of the version the change is based on as If-Match to have it refused with 412 if someone else changed the todo in the meantime.
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
 // @Param todo body model.TodoUpdate true "Updated todo data"
+// @Param If-Match header string false "ETag of the version the change is based on"
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
 // @Success 200 {object} model.Todo
+// @Header 200 {string} ETag "Version of the todo"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} model.TodoPreconditionFailed
+// @Failure 428 {object} map[string]string
 // @Router /todos/{id} [put]
 func (c *TodoController) UpdateTodo(ctx *gin.Context) {
 	userId, exists := ctx.Get("userId")
@@ -121,6 +406,11 @@ func (c *TodoController) UpdateTodo(ctx *gin.Context) {
 		return
 	}
 
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
 	id := ctx.Param("id")
 
 	var updateData model.TodoUpdate
@@ -128,27 +418,89 @@ func (c *TodoController) UpdateTodo(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if updateData.IfMatch, ok = c.bindIfMatch(ctx); !ok {
+		return
+	}
 
 	updatedTodo, err := c.service.UpdateTodo(ctx.Request.Context(), id, userId.(string), &updateData)
 	if err != nil {
-		if err.Error() == "todo not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.writeChangeError(ctx, userId.(string), render, err)
 		return
 	}
 
+	renderTodos(render, updatedTodo)
+	setETag(ctx, updatedTodo, render)
+	ctx.JSON(http.StatusOK, updatedTodo)
+}
+
+// PatchTodo godoc
+// @Summary Partially update a todo
+// @Description Apply a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902), chosen by Content-Type, to the editable fields of a todo. Any field may be set, including to false or null.
+// @Tags todos
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Param If-Match header string false "ETag of the version the patch is based on"
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
+// @Success 200 {object} model.Todo
+// @Header 200 {string} ETag "Version of the todo"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} model.TodoPreconditionFailed
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 428 {object} map[string]string
+// @Router /todos/{id} [patch]
+func (c *TodoController) PatchTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
+	id := ctx.Param("id")
+
+	document, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	patch := model.TodoPatch{ContentType: ctx.ContentType(), Document: document}
+	if patch.IfMatch, ok = c.bindIfMatch(ctx); !ok {
+		return
+	}
+
+	updatedTodo, err := c.service.PatchTodo(ctx.Request.Context(), id, userId.(string), &patch)
+	if err != nil {
+		c.writeChangeError(ctx, userId.(string), render, err)
+		return
+	}
+
+	renderTodos(render, updatedTodo)
+	setETag(ctx, updatedTodo, render)
 	ctx.JSON(http.StatusOK, updatedTodo)
 }
 
 // DeleteTodo godoc
 // @Summary Delete a todo
-// @Description Delete a todo item by ID
+// @Description Move a todo to the trash, from where it can be restored until it is purged. Its subtasks are moved to the top level unless cascade=delete is given, which trashes them too.
 // @Tags todos
 // @Security BearerAuth
 // @Param id path string true "Todo ID"
+// @Param cascade query string false "What to do with subtasks" Enums(orphan, delete) default(orphan)
+// @Param If-Match header string false "ETag of the version the client last saw"
 // @Success 204 {object} nil
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} model.TodoPreconditionFailed
+// @Failure 428 {object} map[string]string
 // @Router /todos/{id} [delete]
 func (c *TodoController) DeleteTodo(ctx *gin.Context) {
 	userId, exists := ctx.Get("userId")
@@ -159,15 +511,568 @@ func (c *TodoController) DeleteTodo(ctx *gin.Context) {
 
 	id := ctx.Param("id")
 
-	err := c.service.DeleteTodo(ctx.Request.Context(), id, userId.(string))
+	var query model.TodoDeleteQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expected, ok := c.bindIfMatch(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.service.DeleteTodo(ctx.Request.Context(), id, userId.(string), query.Cascade == "delete", expected)
 	if err != nil {
-		if err.Error() == "todo not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.writeChangeError(ctx, userId.(string), &model.RenderQuery{}, err)
 		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+// GetOccurrences godoc
+// @Summary Preview occurrences of a recurring todo
+// @Description List the due dates a recurring todo will take within a window. The window defaults to the next 90 days.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param from query string false "Start of the window (RFC 3339), defaults to now"
+// @Param to query string false "End of the window (RFC 3339)"
+// @Param limit query int false "Maximum number of occurrences (1-366)" default(50)
+// @Success 200 {object} model.TodoOccurrences
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/occurrences [get]
+func (c *TodoController) GetOccurrences(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	id := ctx.Param("id")
+
+	var query model.TodoOccurrencesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	occurrences, err := c.service.GetOccurrences(ctx.Request.Context(), id, userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, occurrences)
+}
+
+// GetChildren godoc
+// @Summary List subtasks
+// @Description List the direct children of a todo, oldest first
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param render query string false "Also return the description rendered as sanitised HTML" Enums(html)
+// @Success 200 {array} model.Todo
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/children [get]
+func (c *TodoController) GetChildren(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
+	id := ctx.Param("id")
+
+	children, err := c.service.GetChildren(ctx.Request.Context(), id, userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	renderTodos(render, children...)
+	ctx.JSON(http.StatusOK, children)
+}
+
+// GetHistory godoc
+// @Summary Get the revision history of a todo
+// @Description List the revisions of a todo, newest first. Each records the actor, the time, a field-level diff and the resulting state. The history of a deleted todo remains readable.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param limit query int false "Maximum number of revisions (1-100)" default(20)
+// @Param before query int false "Only return revisions older than this revision number"
+// @Success 200 {array} model.Revision
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/history [get]
+func (c *TodoController) GetHistory(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.RevisionQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revisions, err := c.service.GetHistory(ctx.Request.Context(), ctx.Param("id"), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// RevertTodo godoc
+// @Summary Revert a todo to an earlier revision
+// @Description Restore what a todo had at the given revision: title, description, status, priority, due date, reminders, recurrence, parent, list and labels. Labels deleted since are left out, and a revert to a parent or list that is gone fails. The revert is recorded as a new revision.
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /todos/{id}/revert/{revision} [post]
+func (c *TodoController) RevertTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	revision, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || revision < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive number"})
+		return
+	}
+
+	todo, err := c.service.RevertTodo(ctx.Request.Context(), ctx.Param("id"), userId.(string), revision)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// MoveTodo godoc
+// @Summary Move a todo under another parent
+// @Description Move a todo and its subtasks under a new parent, or to the top level when parentId is null
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param move body model.TodoMove true "New parent"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /todos/{id}/parent [put]
+func (c *TodoController) MoveTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	id := ctx.Param("id")
+
+	var move model.TodoMove
+	if err := ctx.ShouldBindJSON(&move); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todo, err := c.service.MoveTodo(ctx.Request.Context(), id, userId.(string), &move)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// ReorderTodo godoc
+// @Summary Move a todo in the manual order
+// @Description Place a todo right after one todo, right before another, or between two. Only the moved todo changes; list with sort=position to read the order.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param reorder body model.TodoReorder true "Todos to place it between"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/move [post]
+func (c *TodoController) ReorderTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var reorder model.TodoReorder
+	if err := ctx.ShouldBindJSON(&reorder); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todo, err := c.service.ReorderTodo(ctx.Request.Context(), ctx.Param("id"), userId.(string), &reorder)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// TransitionTodo godoc
+// @Summary Move a todo to another workflow state
+// @Description Move a todo to another state of the workflow of its list. The workflow must allow the move from the todo's current state; the todo is completed exactly while it is in a done state.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param transition body model.TodoTransition true "State to move to"
+// @Param If-Match header string false "ETag of the version the client last saw"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} model.TodoPreconditionFailed
+// @Failure 422 {object} map[string]string
+// @Router /todos/{id}/transition [post]
+func (c *TodoController) TransitionTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	render, ok := bindRender(ctx)
+	if !ok {
+		return
+	}
+
+	var transition model.TodoTransition
+	if err := ctx.ShouldBindJSON(&transition); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if transition.IfMatch, ok = c.bindIfMatch(ctx); !ok {
+		return
+	}
+
+	todo, err := c.service.TransitionTodo(ctx.Request.Context(), ctx.Param("id"), userId.(string), &transition)
+	if err != nil {
+		c.writeChangeError(ctx, userId.(string), render, err)
+		return
+	}
+
+	renderTodos(render, todo)
+	setETag(ctx, todo, render)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// AttachLabel godoc
+// @Summary Attach a label to a todo
+// @Description Attach one of the user's labels to a todo; attaching twice has no effect
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param labelId path string true "Label ID"
+// @Success 200 {object} model.Todo
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/labels/{labelId} [post]
+func (c *TodoController) AttachLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	todo, err := c.service.AttachLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string), ctx.Param("labelId"))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// DetachLabel godoc
+// @Summary Detach a label from a todo
+// @Description Remove a label from a todo
+// @Tags todos
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param labelId path string true "Label ID"
+// @Success 200 {object} model.Todo
+// @Failure 404 {object} map[string]string
+// @Router /todos/{id}/labels/{labelId} [delete]
+func (c *TodoController) DetachLabel(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	todo, err := c.service.DetachLabel(ctx.Request.Context(), ctx.Param("id"), userId.(string), ctx.Param("labelId"))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// MoveToList godoc
+// @Summary Move a todo to another list
+// @Description Move a todo and its subtasks to another list. A subtask leaves its parent and becomes a top-level todo of the list.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Param move body model.TodoListMove true "Target list"
+// @Success 200 {object} model.Todo
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /todos/{id}/list [put]
+func (c *TodoController) MoveToList(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var move model.TodoListMove
+	if err := ctx.ShouldBindJSON(&move); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todo, err := c.service.MoveToList(ctx.Request.Context(), ctx.Param("id"), userId.(string), &move)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// ListTrash godoc
+// @Summary List deleted todos
+// @Description Get a page of the authenticated user's todos in the trash, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} model.TodoPage
+// @Failure 400 {object} map[string]string
+// @Router /trash [get]
+func (c *TodoController) ListTrash(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.TrashQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.service.ListTrash(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// RestoreTodo godoc
+// @Summary Restore a deleted todo
+// @Description Take a todo out of the trash together with the subtasks deleted along with it. It returns under its parent when the parent still exists, and to the top level otherwise.
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Success 200 {object} model.Todo
+// @Failure 404 {object} map[string]string
+// @Router /trash/{id}/restore [post]
+func (c *TodoController) RestoreTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	todo, err := c.service.RestoreTodo(ctx.Request.Context(), ctx.Param("id"), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	setETag(ctx, todo, nil)
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// PurgeTodo godoc
+// @Summary Permanently delete a todo
+// @Description Remove a todo from the trash for good, together with the subtasks deleted along with it
+// @Tags trash
+// @Security BearerAuth
+// @Param id path string true "Todo ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]string
+// @Router /trash/{id} [delete]
+func (c *TodoController) PurgeTodo(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	if err := c.service.PurgeTodo(ctx.Request.Context(), ctx.Param("id"), userId.(string)); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetSyncChanges godoc
+// @Summary Fetch changes since the last sync
+// @Description Get the todos created or changed, and the IDs of those deleted, since the token of an earlier sync, oldest change first. Without a token every todo is returned. Keep fetching with the returned token while more is true, and keep the last token for the next sync. Tokens expire after 90 days; a client holding an expired one must sync from scratch.
+// @Tags sync
+// @Produce json
+// @Security BearerAuth
+// @Param since query string false "Token from the last sync"
+// @Param limit query int false "Page size (1-500)" default(100)
+// @Success 200 {object} model.SyncChanges
+// @Failure 400 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /sync [get]
+func (c *TodoController) GetSyncChanges(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.SyncQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes, err := c.service.SyncChanges(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
+
+// PushSyncChanges godoc
+// @Summary Push changes made offline
+// @Description Apply up to 500 creates, updates and deletes made by a client while offline, in order. New todos carry an ObjectID generated by the client, so a retried push does not create them twice. Each mutation is applied on its own and reports the status the equivalent single request would have, except that an update or delete made to a version the todo has since moved on from is a 409 conflict carrying the todo as it is now. Deleting a todo that is already gone succeeds.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SyncRequest true "Mutations"
+// @Param Idempotency-Key header string false "Unique key making the request safe to retry; a retry gets the first response replayed"
+// @Success 200 {object} model.SyncResponse
+// @Failure 400 {object} map[string]string
+// @Router /sync [post]
+func (c *TodoController) PushSyncChanges(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var request model.SyncRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.service.ApplySync(ctx.Request.Context(), userId.(string), &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetStats godoc
+// @Summary Productivity statistics
+// @Description Count the todos completed on each day of a range and derive the completion rate, the average time from creating to completing a todo, current and longest streaks of days with completions, and overdue counts. Days follow the user's time zone. Trashed todos are left out.
+// @Tags stats
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "First day (YYYY-MM-DD), defaults to six days before to"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} model.TodoStats
+// @Failure 400 {object} map[string]string
+// @Router /stats [get]
+func (c *TodoController) GetStats(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.StatsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := c.service.GetStats(ctx.Request.Context(), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
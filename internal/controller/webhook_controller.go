@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"net/http"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	service service.WebhookService
+}
+
+func NewWebhookController(service service.WebhookService) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register an endpoint that is sent a POST for every todo event it subscribes to: todo.created, todo.updated, todo.completed or todo.deleted, or all of them when events is left out. Each request carries the event in X-Todo-Event, the delivery ID in X-Todo-Delivery and "sha256=" followed by the hex HMAC-SHA256 of the body, keyed with the secret, in X-Todo-Signature-256. Anything but a 2xx answer is retried with exponential backoff until the delivery is marked dead.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook body model.WebhookCreate true "Endpoint, secret and events"
+// @Success 201 {object} model.Webhook
+// @Failure 400 {object} map[string]string
+// @Router /webhooks [post]
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var webhookCreate model.WebhookCreate
+	if err := ctx.ShouldBindJSON(&webhookCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := c.service.CreateWebhook(ctx.Request.Context(), userId.(string), &webhookCreate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+// GetAllWebhooks godoc
+// @Summary Get all webhooks
+// @Description Retrieve the webhooks of the authenticated user. Secrets are never returned.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Webhook
+// @Router /webhooks [get]
+func (c *WebhookController) GetAllWebhooks(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	webhooks, err := c.service.GetAllWebhooks(ctx.Request.Context(), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary Get a single webhook
+// @Description Get a webhook by ID
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} model.Webhook
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	webhook, err := c.service.GetWebhook(ctx.Request.Context(), ctx.Param("id"), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Change a webhook
+// @Description Change the endpoint, secret or events of a webhook. Deliveries already queued are sent to the new endpoint with the new secret.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param webhook body model.WebhookUpdate true "Fields to change"
+// @Success 200 {object} model.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [put]
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var webhookUpdate model.WebhookUpdate
+	if err := ctx.ShouldBindJSON(&webhookUpdate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := c.service.UpdateWebhook(ctx.Request.Context(), ctx.Param("id"), userId.(string), &webhookUpdate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook together with its delivery log. Deliveries not sent yet are dropped.
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	if err := c.service.DeleteWebhook(ctx.Request.Context(), ctx.Param("id"), userId.(string)); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary Get the delivery log of a webhook
+// @Description List the deliveries of a webhook, newest first, with every attempt at each. Pending deliveries are waiting to be sent or retried; dead ones ran out of attempts. Deliveries are kept for 30 days.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Only deliveries in this state" Enums(pending, delivered, dead)
+// @Param limit query int false "Maximum number of deliveries (1-100, default 50)"
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var query model.WebhookDeliveryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := c.service.ListDeliveries(ctx.Request.Context(), ctx.Param("id"), userId.(string), &query)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// RetryDelivery godoc
+// @Summary Retry a dead delivery
+// @Description Queue a dead delivery to be sent again straight away, with a fresh round of attempts
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} model.WebhookDelivery
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{deliveryId}/retry [post]
+func (c *WebhookController) RetryDelivery(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	delivery, err := c.service.RetryDelivery(ctx.Request.Context(), ctx.Param("id"), userId.(string), ctx.Param("deliveryId"))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
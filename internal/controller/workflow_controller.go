@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkflowController struct {
+	service service.WorkflowService
+}
+
+func NewWorkflowController(service service.WorkflowService) *WorkflowController {
+	return &WorkflowController{service: service}
+}
+
+// CreateWorkflow godoc
+// @Summary Create a workflow
+// @Description Define the states todos of the given lists move through, or those of every other list when default is set. A list belongs to one workflow and a user has one default, so this takes them over from other workflows.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workflow body model.WorkflowCreate true "Workflow definition"
+// @Success 201 {object} model.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workflows [post]
+func (c *WorkflowController) CreateWorkflow(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var workflowCreate model.WorkflowCreate
+	if err := ctx.ShouldBindJSON(&workflowCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workflow, err := c.service.CreateWorkflow(ctx.Request.Context(), userId.(string), &workflowCreate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, workflow)
+}
+
+// GetAllWorkflows godoc
+// @Summary Get all workflows
+// @Description Retrieve all workflows of the authenticated user, oldest first
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.Workflow
+// @Router /workflows [get]
+func (c *WorkflowController) GetAllWorkflows(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	workflows, err := c.service.GetAllWorkflows(ctx.Request.Context(), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workflows)
+}
+
+// GetWorkflow godoc
+// @Summary Get a workflow
+// @Description Retrieve a specific workflow by its ID
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} model.Workflow
+// @Failure 404 {object} map[string]string
+// @Router /workflows/{id} [get]
+func (c *WorkflowController) GetWorkflow(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	workflow, err := c.service.GetWorkflow(ctx.Request.Context(), ctx.Param("id"), userId.(string))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workflow)
+}
+
+// UpdateWorkflow godoc
+// @Summary Update a workflow
+// @Description Replace the definition of a workflow. Todos in states it no longer has move to its first done or first open state, according to whether they are completed, and todos in a state that became done or open are completed or reopened to match.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Workflow ID"
+// @Param workflow body model.WorkflowUpdate true "New workflow definition"
+// @Success 200 {object} model.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workflows/{id} [put]
+func (c *WorkflowController) UpdateWorkflow(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	var workflowUpdate model.WorkflowUpdate
+	if err := ctx.ShouldBindJSON(&workflowUpdate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workflow, err := c.service.UpdateWorkflow(ctx.Request.Context(), ctx.Param("id"), userId.(string), &workflowUpdate)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workflow)
+}
+
+// DeleteWorkflow godoc
+// @Summary Delete a workflow
+// @Description Delete a workflow. Its todos follow the user's default workflow, or the built-in todo and done states, from then on, and move to its first done or first open state when they are in a state it does not have.
+// @Tags workflows
+// @Security BearerAuth
+// @Param id path string true "Workflow ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]string
+// @Router /workflows/{id} [delete]
+func (c *WorkflowController) DeleteWorkflow(ctx *gin.Context) {
+	userId, exists := ctx.Get("userId")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found in context"})
+		return
+	}
+
+	if err := c.service.DeleteWorkflow(ctx.Request.Context(), ctx.Param("id"), userId.(string)); err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
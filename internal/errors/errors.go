@@ -64,6 +64,216 @@ var (
 		Message: "Resource not found",
 	}
 
+	ErrInvalidCursor = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_CURSOR",
+		Message: "Invalid or expired cursor",
+	}
+
+	ErrParentNotFound = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_PARENT",
+		Message: "Parent todo not found",
+	}
+
+	ErrTodoCycle = APIError{
+		Status:  http.StatusConflict,
+		Code:    "TODO_CYCLE",
+		Message: "A todo cannot be nested under itself or its own subtasks",
+	}
+
+	ErrMaxDepthExceeded = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "MAX_DEPTH_EXCEEDED",
+		Message: "Todos cannot be nested more than 5 levels deep",
+	}
+
+	ErrLabelNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Label not found",
+	}
+
+	ErrDuplicateLabel = APIError{
+		Status:  http.StatusConflict,
+		Code:    "DUPLICATE_RESOURCE",
+		Message: "A label with this name already exists",
+	}
+
+	ErrInvalidLabelMerge = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_MERGE",
+		Message: "A label cannot be merged into itself",
+	}
+
+	ErrListNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "List not found",
+	}
+
+	ErrInboxProtected = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INBOX_PROTECTED",
+		Message: "The Inbox list cannot be deleted or archived",
+	}
+
+	ErrListArchived = APIError{
+		Status:  http.StatusConflict,
+		Code:    "LIST_ARCHIVED",
+		Message: "Todos cannot be added to an archived list",
+	}
+
+	ErrInvalidBulk = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_BULK",
+		Message: "A bulk create needs todos, other actions need either ids or a filter, and update needs a patch",
+	}
+
+	ErrRevisionNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Revision not found",
+	}
+
+	ErrPreconditionFailed = APIError{
+		Status:  http.StatusPreconditionFailed,
+		Code:    "PRECONDITION_FAILED",
+		Message: "The todo has been changed since the given version",
+	}
+
+	ErrPreconditionRequired = APIError{
+		Status:  http.StatusPreconditionRequired,
+		Code:    "PRECONDITION_REQUIRED",
+		Message: "An If-Match header is required to change a todo",
+	}
+
+	ErrInvalidIdempotencyKey = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_IDEMPOTENCY_KEY",
+		Message: "Idempotency-Key must be at most 255 characters",
+	}
+
+	ErrIdempotencyKeyReused = APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "IDEMPOTENCY_KEY_REUSED",
+		Message: "This Idempotency-Key was already used for a different request",
+	}
+
+	ErrIdempotencyInProgress = APIError{
+		Status:  http.StatusConflict,
+		Code:    "IDEMPOTENCY_IN_PROGRESS",
+		Message: "A request with this Idempotency-Key is still being processed",
+	}
+
+	ErrRequestTooLarge = APIError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "REQUEST_TOO_LARGE",
+		Message: "Requests sent with an Idempotency-Key can have a body of at most 10 MB",
+	}
+
+	ErrImportTooLarge = APIError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "IMPORT_TOO_LARGE",
+		Message: "An import can hold at most 5000 todos and 10 MB",
+	}
+
+	ErrCalendarFeedNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Calendar feed not found",
+	}
+
+	ErrWebhookNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Webhook not found",
+	}
+
+	ErrWebhookDeliveryNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Webhook delivery not found",
+	}
+
+	ErrWebhookURLNotAllowed = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "WEBHOOK_URL_NOT_ALLOWED",
+		Message: "Webhooks cannot be sent to loopback, private or link-local addresses",
+	}
+
+	ErrDeliveryNotDead = APIError{
+		Status:  http.StatusConflict,
+		Code:    "DELIVERY_NOT_DEAD",
+		Message: "Only dead deliveries can be retried",
+	}
+
+	ErrInvalidSyncToken = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_SYNC_TOKEN",
+		Message: "Invalid sync token",
+	}
+
+	ErrSyncTokenExpired = APIError{
+		Status:  http.StatusGone,
+		Code:    "SYNC_TOKEN_EXPIRED",
+		Message: "The sync token is too old; sync again without one",
+	}
+
+	ErrInvalidSyncMutation = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_SYNC_MUTATION",
+		Message: "create needs todo, update needs patch and baseVersion",
+	}
+
+	ErrTodoIDTaken = APIError{
+		Status:  http.StatusConflict,
+		Code:    "TODO_ID_TAKEN",
+		Message: "Another todo already has this ID",
+	}
+
+	ErrTooManyStreams = APIError{
+		Status:  http.StatusTooManyRequests,
+		Code:    "TOO_MANY_STREAMS",
+		Message: "Too many event streams are open; close one first",
+	}
+
+	ErrQuickAddNoTitle = APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "QUICK_ADD_NO_TITLE",
+		Message: "Nothing is left for the title; put words in double quotes to keep them in it",
+	}
+
+	ErrInvalidStatsRange = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_STATS_RANGE",
+		Message: "from must not be after to, and the range may span at most 366 days",
+	}
+
+	ErrInvalidReorder = APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_REORDER",
+		Message: "Give the todo to place it after, before or both, neither of them the todo itself, and the first ahead of the second",
+	}
+
+	ErrWorkflowNotFound = APIError{
+		Status:  http.StatusNotFound,
+		Code:    "NOT_FOUND",
+		Message: "Workflow not found",
+	}
+
+	ErrUnknownTodoStatus = APIError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "UNKNOWN_STATUS",
+		Message: "The todo's workflow has no state with this key",
+	}
+
+	ErrTransitionNotAllowed = APIError{
+		Status:  http.StatusConflict,
+		Code:    "TRANSITION_NOT_ALLOWED",
+		Message: "The todo's workflow does not allow moving it from its current state to this one",
+	}
+
 	ErrInternalServerError = APIError{
 		Status:  http.StatusInternalServerError,
 		Code:    "INTERNAL_SERVER_ERROR",
@@ -95,3 +305,26 @@ func IsAPIError(err error) bool {
 	var apiErr APIError
 	return errors.As(err, &apiErr)
 }
+
+// HTTPStatus returns the status an error is reported with. API errors keep
+// their own status, missing todos are 404 and anything else is 500.
+func HTTPStatus(err error) int {
+	var apiErr APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Status
+	case err.Error() == "todo not found":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Message returns the text an error is reported with
+func Message(err error) string {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Message
+	}
+	return err.Error()
+}
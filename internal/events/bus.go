@@ -0,0 +1,161 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+)
+
+// Message is an event as it is sent to streams. Its ID is unique for the
+// life of the process and orders messages, so a client that reconnects
+// with the last ID it saw can be sent what it missed.
+type Message struct {
+	ID    string
+	Event *model.TodoEvent
+	seq   uint64
+}
+
+// Subscription is an open stream of one user's events. C is closed when
+// the subscriber falls too far behind; the client is expected to reconnect
+// with the ID of the last message it received. Cursor is the ID of the last
+// message broadcast before the stream opened, for a client to resume from
+// when it has received nothing yet.
+type Subscription struct {
+	C      <-chan *Message
+	Cursor string
+	c      chan *Message
+	userID string
+}
+
+// Bus hands the events of each user to that user's open streams. It keeps
+// the most recent messages so a client that reconnects can be sent the ones
+// it missed. Message IDs carry the bus's start time, so IDs handed out by
+// an earlier process are recognised as unknown rather than resumed from.
+type Bus struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	history     []*Message
+	next        int
+	subscribers map[string]map[*Subscription]struct{}
+	buffer      int
+	maxStreams  int
+}
+
+// NewBus creates a bus remembering the last historySize messages, where
+// each stream may fall buffer messages behind before it is dropped, and
+// each user may open at most maxStreams streams
+func NewBus(historySize, buffer, maxStreams int) *Bus {
+	return &Bus{
+		epoch:       strconv.FormatInt(time.Now().UnixMilli(), 36),
+		history:     make([]*Message, historySize),
+		subscribers: map[string]map[*Subscription]struct{}{},
+		buffer:      buffer,
+		maxStreams:  maxStreams,
+	}
+}
+
+// Broadcast sends events to the streams of their users. It never blocks:
+// a stream whose buffer is full is closed instead.
+func (b *Bus) Broadcast(events ...*model.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.seq++
+		message := &Message{ID: b.id(b.seq), Event: event, seq: b.seq}
+		if len(b.history) > 0 {
+			b.history[b.next] = message
+			b.next = (b.next + 1) % len(b.history)
+		}
+
+		for sub := range b.subscribers[event.UserID.Hex()] {
+			select {
+			case sub.c <- message:
+			default:
+				b.remove(sub)
+			}
+		}
+	}
+}
+
+// Subscribe opens a stream of the user's events. When lastEventID is the ID
+// of a message the bus still remembers, the messages of the user that
+// followed it are returned to be sent first and resumed is true. Otherwise
+// the client may have missed events and should reload its todos.
+func (b *Bus) Subscribe(userID string, lastEventID string) (sub *Subscription, replay []*Message, resumed bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subscribers[userID]) >= b.maxStreams {
+		return nil, nil, false, errors.ErrTooManyStreams
+	}
+
+	if lastEventID != "" {
+		replay, resumed = b.since(userID, lastEventID)
+	}
+
+	c := make(chan *Message, b.buffer)
+	sub = &Subscription{C: c, Cursor: b.id(b.seq), c: c, userID: userID}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = map[*Subscription]struct{}{}
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	return sub, replay, resumed, nil
+}
+
+// Unsubscribe closes a stream. Closing one that was dropped is a no-op.
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+func (b *Bus) id(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	subs := b.subscribers[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+	close(sub.c)
+}
+
+// since returns the user's messages after the one with the given ID, and
+// whether every message since then is still remembered
+func (b *Bus) since(userID string, lastEventID string) ([]*Message, bool) {
+	epoch, seqText, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != b.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > b.seq {
+		return nil, false
+	}
+	// Messages up to oldest have been forgotten
+	var oldest uint64
+	if b.seq > uint64(len(b.history)) {
+		oldest = b.seq - uint64(len(b.history))
+	}
+	if seq < oldest {
+		return nil, false
+	}
+
+	var replay []*Message
+	for i := range len(b.history) {
+		message := b.history[(b.next+i)%len(b.history)]
+		if message != nil && message.seq > seq && message.Event.UserID.Hex() == userID {
+			replay = append(replay, message)
+		}
+	}
+	return replay, true
+}
@@ -0,0 +1,118 @@
+// Package ical writes iCalendar (RFC 5545) data: the line folding, escaping
+// and date formats calendar apps expect, and the VTODO and VEVENT
+// components todos are shown as.
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProdID names this app as the producer of calendars
+const ProdID = "-//todo-app//Todo API//EN"
+
+// ContentType is the media type of iCalendar data
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+// Writer writes content lines. The first write error is kept and returned
+// by Err, and later writes do nothing.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter returns a writer of content lines to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Begin starts a component such as VCALENDAR or VTODO
+func (w *Writer) Begin(component string) {
+	w.Property("BEGIN", component)
+}
+
+// End ends a component
+func (w *Writer) End(component string) {
+	w.Property("END", component)
+}
+
+// Property writes a property with a value that is already formatted. The
+// name may carry parameters, as in "DTSTART;VALUE=DATE".
+func (w *Writer) Property(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes a property whose values are text, escaping them and joining
+// several with commas
+func (w *Writer) Text(name string, values ...string) {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = EscapeText(value)
+	}
+	w.Property(name, strings.Join(escaped, ","))
+}
+
+// Time writes a date-time property in UTC
+func (w *Writer) Time(name string, t time.Time) {
+	w.Property(name, FormatTime(t))
+}
+
+// Date writes a date property, for things that last all day
+func (w *Writer) Date(name string, t time.Time) {
+	w.Property(name+";VALUE=DATE", t.Format("20060102"))
+}
+
+// Err returns the first error writing failed with
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// line writes a content line, folding it so no line is longer than 75
+// octets without splitting a character
+func (w *Writer) line(content string) {
+	if w.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	length := 0
+	for _, r := range content {
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			r, size = utf8.RuneError, 3
+		}
+		if length+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	b.WriteString("\r\n")
+
+	_, w.err = io.WriteString(w.w, b.String())
+}
+
+// EscapeText escapes a value of type TEXT
+func EscapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return textEscaper.Replace(value)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// FormatTime formats a DATE-TIME in UTC
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
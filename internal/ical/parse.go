@@ -0,0 +1,223 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxObjectBytes caps the size of an iCalendar object that is parsed
+const maxObjectBytes = 1 << 20
+
+// Component is a parsed component with its properties and the components
+// nested in it
+type Component struct {
+	Name       string
+	Properties []*Property
+	Children   []*Component
+}
+
+// Property is a parsed property. Values are kept as written, escapes
+// included.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse reads one iCalendar object, such as a VCALENDAR
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(io.LimitReader(r, maxObjectBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, component)
+			} else if root != nil {
+				return nil, errors.New("only one object may be sent")
+			} else {
+				root = component
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", i+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no iCalendar object found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%s is not ended", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold splits data into content lines, joining folded ones
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxObjectBytes)
+
+	var lines []string
+	size := 0
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		size += len(line) + 2
+		if size > maxObjectBytes {
+			return nil, errors.New("the iCalendar object is too large")
+		}
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseLine parses name;param=value:value, where parameter values may be
+// quoted and hold colons
+func parseLine(line string) (*Property, error) {
+	prop := &Property{Params: map[string]string{}}
+
+	quoted := false
+	start, paramName := 0, ""
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '=' && prop.Name != "" && paramName == "":
+			paramName = strings.ToUpper(line[start:i])
+			start = i + 1
+		case c == ';' || c == ':':
+			part := line[start:i]
+			if prop.Name == "" {
+				prop.Name = strings.ToUpper(part)
+			} else if paramName != "" {
+				prop.Params[paramName] = strings.Trim(part, `"`)
+				paramName = ""
+			}
+			start = i + 1
+			if c == ':' {
+				prop.Value = line[start:]
+				if prop.Name == "" {
+					return nil, errors.New("property without a name")
+				}
+				return prop, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("malformed content line %q", line)
+}
+
+// Property returns the first property with the given name, or nil
+func (c *Component) Property(name string) *Property {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// Child returns the first nested component with the given name, or nil
+func (c *Component) Child(name string) *Component {
+	for _, child := range c.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Text is the unescaped value of a TEXT property
+func (p *Property) Text() string {
+	values := p.Texts()
+	return strings.Join(values, ",")
+}
+
+// Texts splits a multi-valued TEXT property, such as CATEGORIES, on its
+// unescaped commas and unescapes each value
+func (p *Property) Texts() []string {
+	var values []string
+	var b strings.Builder
+	for i := 0; i < len(p.Value); i++ {
+		c := p.Value[i]
+		switch {
+		case c == '\\' && i+1 < len(p.Value):
+			i++
+			switch p.Value[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(p.Value[i])
+			}
+		case c == ',':
+			values = append(values, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(values, b.String())
+}
+
+// Time parses a DATE or DATE-TIME property. Times without a UTC marker are
+// in the zone named by TZID, or in location when it is missing or unknown.
+// allDay reports a DATE, which is midnight in location.
+func (p *Property) Time(location *time.Location) (t time.Time, allDay bool, err error) {
+	if location == nil {
+		location = time.UTC
+	}
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+
+	value := strings.TrimSpace(p.Value)
+	switch {
+	case p.Params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, location)
+		allDay = true
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, location)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q", p.Name, p.Value)
+	}
+	return t, allDay, nil
+}
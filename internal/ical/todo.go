@@ -0,0 +1,218 @@
+package ical
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// refreshInterval is how often subscribed calendars are asked to check
+// for changes
+const refreshInterval = "PT1H"
+
+// Item is a todo as calendars show it
+type Item struct {
+	ID string
+	// UID overrides the UID derived from ID, for todos that were created
+	// by a calendar client
+	UID         string
+	Title       string
+	Description string
+	Completed   bool
+	CompletedAt *time.Time
+	Created     time.Time
+	Modified    time.Time
+	Due         *time.Time
+	// AllDay marks a due date that is midnight in Location, which
+	// calendars show as a date rather than a time
+	AllDay     bool
+	Location   *time.Location
+	Categories []string
+	ParentID   string
+	Alarms     []time.Time
+}
+
+// UID is the unique identifier of the VTODO of a todo
+func UID(id string) string {
+	return id + "@todo-app"
+}
+
+// eventUID is the unique identifier of the VEVENT of a todo, which must
+// not be the same as that of its VTODO
+func eventUID(id string) string {
+	return id + "-due@todo-app"
+}
+
+// BeginObject starts an iCalendar object, the VCALENDAR around the
+// components of a single resource
+func (w *Writer) BeginObject() {
+	w.Begin("VCALENDAR")
+	w.Property("VERSION", "2.0")
+	w.Property("PRODID", ProdID)
+	w.Property("CALSCALE", "GREGORIAN")
+}
+
+// BeginCalendar starts a calendar with the given name, for feeds that are
+// subscribed to
+func (w *Writer) BeginCalendar(name string) {
+	w.BeginObject()
+	w.Text("X-WR-CALNAME", name)
+	w.Property("REFRESH-INTERVAL;VALUE=DURATION", refreshInterval)
+	w.Property("X-PUBLISHED-TTL", refreshInterval)
+}
+
+// EndCalendar ends a calendar
+func (w *Writer) EndCalendar() {
+	w.End("VCALENDAR")
+}
+
+// Todo writes item as a VTODO
+func (w *Writer) Todo(item *Item) {
+	w.Begin("VTODO")
+	if item.UID != "" {
+		w.Text("UID", item.UID)
+	} else {
+		w.Property("UID", UID(item.ID))
+	}
+	w.Time("DTSTAMP", item.Modified)
+	w.Time("CREATED", item.Created)
+	w.Time("LAST-MODIFIED", item.Modified)
+	w.common(item)
+	if item.Due != nil {
+		if item.AllDay {
+			w.Date("DUE", item.Due.In(item.location()))
+		} else {
+			w.Time("DUE", *item.Due)
+		}
+	}
+	if item.Completed {
+		w.Property("STATUS", "COMPLETED")
+		w.Property("PERCENT-COMPLETE", "100")
+		if item.CompletedAt != nil {
+			w.Time("COMPLETED", *item.CompletedAt)
+		}
+	} else {
+		w.Property("STATUS", "NEEDS-ACTION")
+	}
+	if item.ParentID != "" {
+		w.Property("RELATED-TO", UID(item.ParentID))
+	}
+	w.alarms(item)
+	w.End("VTODO")
+}
+
+// Event writes the due date of item as a VEVENT, for calendars that do not
+// show VTODOs. Items without a due date are left out.
+func (w *Writer) Event(item *Item) {
+	if item.Due == nil {
+		return
+	}
+
+	w.Begin("VEVENT")
+	w.Property("UID", eventUID(item.ID))
+	w.Time("DTSTAMP", item.Modified)
+	w.Time("CREATED", item.Created)
+	w.Time("LAST-MODIFIED", item.Modified)
+	w.common(item)
+	if item.AllDay {
+		day := item.Due.In(item.location())
+		w.Date("DTSTART", day)
+		w.Date("DTEND", day.AddDate(0, 0, 1))
+	} else {
+		// Without an end the event takes no time, as a deadline should
+		w.Time("DTSTART", *item.Due)
+	}
+	w.Property("TRANSP", "TRANSPARENT")
+	w.alarms(item)
+	w.End("VEVENT")
+}
+
+func (w *Writer) common(item *Item) {
+	w.Text("SUMMARY", item.Title)
+	if item.Description != "" {
+		w.Text("DESCRIPTION", item.Description)
+	}
+	if len(item.Categories) > 0 {
+		w.Text("CATEGORIES", item.Categories...)
+	}
+}
+
+func (w *Writer) alarms(item *Item) {
+	if item.Completed {
+		return
+	}
+	for _, at := range item.Alarms {
+		w.Begin("VALARM")
+		w.Property("ACTION", "DISPLAY")
+		w.Text("DESCRIPTION", item.Title)
+		w.Time("TRIGGER;VALUE=DATE-TIME", at)
+		w.End("VALARM")
+	}
+}
+
+func (item *Item) location() *time.Location {
+	if item.Location == nil {
+		return time.UTC
+	}
+	return item.Location
+}
+
+// ReadTodo reads the VTODO of an iCalendar object. Dates and floating
+// times are taken to be in location. Properties todos have no place for
+// are ignored.
+func ReadTodo(r io.Reader, location *time.Location) (*Item, error) {
+	object, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if object.Name != "VCALENDAR" {
+		return nil, errors.New("the object must be a VCALENDAR")
+	}
+	todo := object.Child("VTODO")
+	if todo == nil {
+		return nil, errors.New("the calendar has no VTODO")
+	}
+
+	item := &Item{Location: location}
+	if uid := todo.Property("UID"); uid != nil {
+		item.UID = uid.Text()
+	}
+	if summary := todo.Property("SUMMARY"); summary != nil {
+		item.Title = strings.TrimSpace(summary.Text())
+	}
+	if description := todo.Property("DESCRIPTION"); description != nil {
+		item.Description = description.Text()
+	}
+	if completed := todo.Property("COMPLETED"); completed != nil {
+		at, _, err := completed.Time(location)
+		if err != nil {
+			return nil, err
+		}
+		item.CompletedAt = &at
+	}
+	// Some clients leave COMPLETED behind when a task is reopened, so
+	// STATUS wins when there is one
+	item.Completed = item.CompletedAt != nil
+	if status := todo.Property("STATUS"); status != nil {
+		item.Completed = strings.EqualFold(status.Value, "COMPLETED")
+	}
+	if due := todo.Property("DUE"); due != nil {
+		at, allDay, err := due.Time(location)
+		if err != nil {
+			return nil, err
+		}
+		item.Due, item.AllDay = &at, allDay
+	}
+	for _, prop := range todo.Properties {
+		if prop.Name != "CATEGORIES" {
+			continue
+		}
+		for _, name := range prop.Texts() {
+			if name = strings.TrimSpace(name); name != "" {
+				item.Categories = append(item.Categories, name)
+			}
+		}
+	}
+	return item, nil
+}
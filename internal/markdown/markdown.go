@@ -0,0 +1,637 @@
+// Package markdown renders the Markdown used in todo descriptions to HTML.
+// It covers the common subset: paragraphs, headings, emphasis, code, links,
+// block quotes, rules and (task) lists. The output is safe by construction:
+// raw HTML in the source is escaped rather than passed through, and links
+// are only emitted for http, https and mailto URLs.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// linkRel is set on every link so rendered descriptions cannot be used to
+// boost or track other sites
+const linkRel = "nofollow noopener noreferrer"
+
+// ToHTML renders Markdown source to HTML
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+
+	var b strings.Builder
+	renderBlocks(&b, lines, false)
+	return b.String()
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := 4 - col%4
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// renderBlocks renders a sequence of block-level elements. In a tight list
+// item, paragraphs are written without their <p> wrapper.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case fenceMarker(trimmed) != "":
+			i = renderFence(b, lines, i)
+		case headingLevel(trimmed) > 0:
+			renderHeading(b, trimmed)
+			i++
+		case isRule(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			i = renderQuote(b, lines, i)
+		default:
+			if _, ok := parseMarker(lines[i]); ok {
+				i = renderList(b, lines, i)
+			} else {
+				i = renderParagraph(b, lines, i, tight)
+			}
+		}
+	}
+}
+
+// startsBlock reports whether a line opens a block that interrupts a paragraph
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if fenceMarker(trimmed) != "" || headingLevel(trimmed) > 0 || isRule(trimmed) || strings.HasPrefix(trimmed, ">") {
+		return true
+	}
+	_, ok := parseMarker(line)
+	return ok
+}
+
+func fenceMarker(line string) string {
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, fence) {
+			return fence
+		}
+	}
+	return ""
+}
+
+func renderFence(b *strings.Builder, lines []string, start int) int {
+	opening := strings.TrimSpace(lines[start])
+	fence := fenceMarker(opening)
+	info := strings.Fields(strings.TrimLeft(opening, fence[:1]))
+
+	b.WriteString("<pre><code")
+	if len(info) > 0 {
+		if language := cleanLanguage(info[0]); language != "" {
+			b.WriteString(` class="language-` + language + `"`)
+		}
+	}
+	b.WriteString(">")
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		b.WriteString(html.EscapeString(lines[i]))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// cleanLanguage keeps a fence's language name only if it is a plain identifier
+func cleanLanguage(language string) string {
+	for _, r := range language {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+-_#.", r) {
+			return ""
+		}
+	}
+	return language
+}
+
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func renderHeading(b *strings.Builder, line string) {
+	level := headingLevel(line)
+	text := strings.TrimSpace(line[level:])
+	// A closing sequence of #s is not part of the heading
+	if stripped := strings.TrimRight(text, "#"); stripped == "" || strings.HasSuffix(stripped, " ") {
+		text = strings.TrimSpace(stripped)
+	}
+
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ">")
+	renderInline(b, text, false)
+	b.WriteString("</" + tag + ">\n")
+}
+
+func isRule(line string) bool {
+	compact := strings.ReplaceAll(line, " ", "")
+	if len(compact) < 3 {
+		return false
+	}
+	char := compact[0]
+	if char != '-' && char != '*' && char != '_' {
+		return false
+	}
+	return strings.Count(compact, string(char)) == len(compact)
+}
+
+func renderQuote(b *strings.Builder, lines []string, start int) int {
+	var inner []string
+	i := start
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		inner = append(inner, strings.TrimPrefix(trimmed, " "))
+	}
+
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, inner, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// marker is a parsed list item marker such as "- ", "* " or "3. "
+type marker struct {
+	ordered bool
+	number  int
+	indent  int
+	// width is the column where the item's content starts
+	width int
+}
+
+func parseMarker(line string) (marker, bool) {
+	indent := indentOf(line)
+	if indent > 3 {
+		return marker{}, false
+	}
+	rest := line[indent:]
+
+	m := marker{indent: indent}
+	var size int
+	switch {
+	case rest == "":
+		return marker{}, false
+	case rest[0] == '-' || rest[0] == '*' || rest[0] == '+':
+		size = 1
+	default:
+		digits := 0
+		for digits < len(rest) && digits < 9 && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 || digits >= len(rest) || (rest[digits] != '.' && rest[digits] != ')') {
+			return marker{}, false
+		}
+		m.ordered = true
+		m.number, _ = strconv.Atoi(rest[:digits])
+		size = digits + 1
+	}
+
+	after := rest[size:]
+	if after == "" {
+		m.width = indent + size + 1
+		return m, true
+	}
+	if after[0] != ' ' {
+		return marker{}, false
+	}
+	spaces := len(after) - len(strings.TrimLeft(after, " "))
+	if spaces > 4 {
+		spaces = 1
+	}
+	m.width = indent + size + spaces
+	return m, true
+}
+
+// contentAfter strips a list marker, or the indentation of a continuation
+// line, from line
+func contentAfter(line string, width int) string {
+	if len(line) <= width {
+		return strings.TrimLeft(line, " ")
+	}
+	return line[width:]
+}
+
+func renderList(b *strings.Builder, lines []string, start int) int {
+	first, _ := parseMarker(lines[start])
+	sibling := func(line string) bool {
+		m, ok := parseMarker(line)
+		return ok && m.ordered == first.ordered && m.indent < first.width
+	}
+
+	var items [][]string
+	loose := false
+	i := start
+	for i < len(lines) && sibling(lines[i]) {
+		m, _ := parseMarker(lines[i])
+		item, next, separated := collectItem(lines, i, m)
+		items = append(items, item)
+		for _, line := range item {
+			if line == "" {
+				loose = true
+			}
+		}
+
+		i = next
+		if separated {
+			// Blank lines end the list unless another item follows them
+			if i >= len(lines) || !sibling(lines[i]) {
+				break
+			}
+			loose = true
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.number != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.number) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		item[0] = renderTaskBox(b, item[0])
+		renderBlocks(b, item, !loose)
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// collectItem gathers the lines of the list item starting at start, with
+// the marker and indentation removed. It returns the index of the first line
+// after the item and whether blank lines were skipped to reach it.
+func collectItem(lines []string, start int, m marker) ([]string, int, bool) {
+	item := []string{contentAfter(lines[start], m.width)}
+	i := start + 1
+	for i < len(lines) {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && indentOf(lines[next]) >= m.width {
+				for ; i < next; i++ {
+					item = append(item, "")
+				}
+				continue
+			}
+			return item, next, true
+		}
+
+		switch {
+		case indentOf(line) >= m.width:
+			item = append(item, line[m.width:])
+		case startsBlock(line), strings.TrimSpace(item[len(item)-1]) == "":
+			return item, i, false
+		default:
+			// Lazy continuation of the item's paragraph
+			item = append(item, strings.TrimSpace(line))
+		}
+		i++
+	}
+	return item, i, false
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// renderTaskBox writes a disabled checkbox for "[ ]" and "[x]" items and
+// returns the rest of the line
+func renderTaskBox(b *strings.Builder, line string) string {
+	switch {
+	case strings.HasPrefix(line, "[ ] "):
+		b.WriteString(`<input type="checkbox" disabled> `)
+		return line[4:]
+	case strings.HasPrefix(line, "[x] "), strings.HasPrefix(line, "[X] "):
+		b.WriteString(`<input type="checkbox" checked disabled> `)
+		return line[4:]
+	}
+	return line
+}
+
+func renderParagraph(b *strings.Builder, lines []string, start int, tight bool) int {
+	i := start
+	var text []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || (i > start && startsBlock(lines[i])) {
+			break
+		}
+		text = append(text, lines[i])
+	}
+
+	if !tight {
+		b.WriteString("<p>")
+	}
+	for j, line := range text {
+		line = strings.TrimLeft(line, " ")
+		hardBreak := false
+		if j < len(text)-1 {
+			if strings.HasSuffix(line, "  ") {
+				hardBreak = true
+			} else if strings.HasSuffix(line, "\\") {
+				hardBreak = true
+				line = strings.TrimSuffix(line, "\\")
+			}
+		}
+		renderInline(b, strings.TrimRight(line, " "), false)
+		if j < len(text)-1 {
+			if hardBreak {
+				b.WriteString("<br>")
+			}
+			b.WriteString("\n")
+		}
+	}
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+	return i
+}
+
+// renderInline renders emphasis, code spans and links. Links are not
+// nested, so inLink disables them inside link text.
+func renderInline(b *strings.Builder, s string, inLink bool) {
+	var text strings.Builder
+	flush := func() {
+		b.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			run := runLength(s, i, '`')
+			if end := findBacktickRun(s, i+run, run); end >= 0 {
+				flush()
+				code := s[i+run : end]
+				if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + run
+				continue
+			}
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case (c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '[')) && !inLink:
+			open := i
+			if c == '!' {
+				open++
+			}
+			if label, dest, end, ok := parseLink(s, open); ok {
+				flush()
+				writeLink(b, label, dest)
+				i = end
+				continue
+			}
+
+		case c == '<' && !inLink:
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				dest := s[i+1 : i+end]
+				if !strings.ContainsAny(dest, " <") && safeURL(dest) {
+					flush()
+					writeLink(b, dest, dest)
+					i += end + 1
+					continue
+				}
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if tag, inner, end, ok := parseEmphasis(s, i); ok {
+				flush()
+				b.WriteString("<" + tag + ">")
+				renderInline(b, inner, inLink)
+				b.WriteString("</" + tag + ">")
+				i = end
+				continue
+			}
+			run := runLength(s, i, c)
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		text.WriteString(s[i : i+size])
+		i += size
+	}
+	flush()
+}
+
+func isPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("`^|~<>+=$", c) >= 0
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// findBacktickRun finds a run of exactly n backticks at or after from
+func findBacktickRun(s string, from, n int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := runLength(s, i, '`')
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// parseEmphasis matches *em*, _em_, **strong**, __strong__ and ~~del~~
+// starting at i
+func parseEmphasis(s string, i int) (tag, inner string, end int, ok bool) {
+	c := s[i]
+	run := runLength(s, i, c)
+	size, tag := 1, "em"
+	switch {
+	case c == '~':
+		if run != 2 {
+			return "", "", 0, false
+		}
+		size, tag = 2, "del"
+	case run >= 2:
+		size, tag = 2, "strong"
+	}
+
+	// An underscore inside a word, as in snake_case, is not emphasis
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", "", 0, false
+	}
+
+	start := i + size
+	if start >= len(s) || s[start] == ' ' {
+		return "", "", 0, false
+	}
+
+	delimiter := s[i : i+size]
+	for j := start + 1; j+size <= len(s); j++ {
+		if s[j:j+size] != delimiter || s[j-1] == ' ' {
+			continue
+		}
+		// A single delimiter must not be half of a double one
+		if size == 1 && (s[j-1] == c || (j+1 < len(s) && s[j+1] == c)) {
+			continue
+		}
+		if c == '_' && j+size < len(s) && isWordByte(s[j+size]) {
+			continue
+		}
+		return tag, s[start:j], j + size, true
+	}
+	return "", "", 0, false
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// parseLink parses "[label](destination "title")" starting at the opening
+// bracket. The title is accepted but not rendered.
+func parseLink(s string, open int) (label, dest string, end int, ok bool) {
+	depth := 0
+	closeBracket := -1
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeBracket = i
+			}
+		}
+		if closeBracket >= 0 {
+			break
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(s) || s[closeBracket+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	closeParen := -1
+	for i := closeBracket + 1; i < len(s) && closeParen < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				closeParen = i
+			}
+		}
+	}
+	if closeParen < 0 {
+		return "", "", 0, false
+	}
+
+	target := strings.TrimSpace(s[closeBracket+2 : closeParen])
+	if strings.HasPrefix(target, "<") {
+		if gt := strings.IndexByte(target, '>'); gt > 0 {
+			target = target[1:gt]
+		}
+	} else if fields := strings.Fields(target); len(fields) > 0 {
+		target = fields[0]
+	}
+	return s[open+1 : closeBracket], target, closeParen + 1, true
+}
+
+// writeLink emits a link when the destination is safe and just its label
+// otherwise
+func writeLink(b *strings.Builder, label, dest string) {
+	if !safeURL(dest) {
+		renderInline(b, label, true)
+		return
+	}
+	b.WriteString(`<a href="` + html.EscapeString(dest) + `" rel="` + linkRel + `">`)
+	renderInline(b, label, true)
+	b.WriteString("</a>")
+}
+
+// safeURL accepts absolute http and https URLs and mailto addresses only
+func safeURL(raw string) bool {
+	for _, r := range raw {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxBulkItems caps how many todos one bulk request creates or touches
+const MaxBulkItems = 500
+
+// TodoBulkRequest applies one action to many todos
+// @Description TodoBulkRequest creates the given todos, or updates, deletes or completes the todos named by ids or matched by filter
+type TodoBulkRequest struct {
+	Action string `json:"action" binding:"required,oneof=create update delete complete" example:"complete"`
+	// Todos to create, for the create action
+	Todos []TodoCreate `json:"todos" binding:"omitempty,max=500,dive"`
+	// Todos to act on; give either ids or filter
+	IDs    []string        `json:"ids" binding:"omitempty,max=500" example:"5f8d0614db5c5c7b3a18f201"`
+	Filter *TodoBulkFilter `json:"filter"`
+	// Merge patch object, or JSON Patch array, applied to each todo by update
+	Patch json.RawMessage `json:"patch" swaggertype:"object"`
+	// Completion state set by complete, true when omitted
+	Completed *bool `json:"completed" example:"true"`
+	// What delete does with subtasks, as for DELETE /todos/{id}
+	Cascade string `json:"cascade" binding:"omitempty,oneof=orphan delete" example:"orphan"`
+}
+
+// TodoBulkFilter selects the todos a bulk action applies to. All given
+// conditions must match.
+// @Description TodoBulkFilter matches todos by completion, age and list
+type TodoBulkFilter struct {
+	Completed     *bool               `json:"completed" example:"true"`
+	CreatedBefore *time.Time          `json:"createdBefore" example:"2022-01-01T00:00:00Z"`
+	CreatedAfter  *time.Time          `json:"createdAfter" example:"2021-01-01T00:00:00Z"`
+	UpdatedBefore *time.Time          `json:"updatedBefore" example:"2022-01-01T00:00:00Z"`
+	ListID        *primitive.ObjectID `json:"listId" example:"5f8d0614db5c5c7b3a18f220"`
+}
+
+// TodoBulkResult is the outcome of a bulk action on one todo
+// @Description TodoBulkResult reports the status of one item, as the equivalent single request would have
+type TodoBulkResult struct {
+	// Position of the item in todos or ids; filtered items are numbered in match order
+	Index  int    `json:"index" example:"0"`
+	ID     string `json:"id,omitempty" example:"5f8d0614db5c5c7b3a18f201"`
+	Status int    `json:"status" example:"200"`
+	Error  string `json:"error,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+}
+
+// TodoBulkResponse lists the results of a bulk request
+// @Description TodoBulkResponse holds per-item results. When atomic is true the items ran in one transaction, so either all succeeded or none were applied.
+type TodoBulkResponse struct {
+	Atomic    bool `json:"atomic" example:"true"`
+	Succeeded int  `json:"succeeded" example:"2"`
+	Failed    int  `json:"failed" example:"0"`
+	// More is set when the filter matched more todos than one request handles
+	More    bool              `json:"more" example:"false"`
+	Results []*TodoBulkResult `json:"results"`
+}
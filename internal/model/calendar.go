@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Calendar components todos can be shown as
+const (
+	CalendarTodos  = "vtodo"
+	CalendarEvents = "vevent"
+)
+
+// CalendarQuery chooses what a calendar of todos holds
+type CalendarQuery struct {
+	// Only VTODOs or only VEVENTs; both when empty
+	Component string `form:"component" binding:"omitempty,oneof=vtodo vevent"`
+	Completed *bool  `form:"completed"`
+}
+
+// CalendarFeed is the secret address a calendar app subscribes to instead
+// of signing in. Only a hash of its token is stored, so the token and URL
+// are returned once, when the feed is created.
+// @Description CalendarFeed is a user's calendar subscription; token and url are only returned when it is created
+type CalendarFeed struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"-" bson:"userId"`
+	TokenHash string             `json:"-" bson:"tokenHash"`
+	Token     string             `json:"token,omitempty" bson:"-" example:"cT9k2v0xQ1b8s6GqYtFzq3Hh4JmWnLr7eDpA5uVyXcI"`
+	URL       string             `json:"url,omitempty" bson:"-" example:"https://todo.example.com/calendar/feed/cT9k2v0xQ1b8s6GqYtFzq3Hh4JmWnLr7eDpA5uVyXcI.ics"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+}
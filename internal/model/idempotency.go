@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has been handled, the response to replay when the request is retried
+type IdempotencyRecord struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	UserID string             `bson:"userId"`
+	Key    string             `bson:"key"`
+	// Hash of the method, path and body, to tell retries from other requests
+	Fingerprint string              `bson:"fingerprint"`
+	Completed   bool                `bson:"completed"`
+	Status      int                 `bson:"status,omitempty"`
+	Header      map[string][]string `bson:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty"`
+	CreatedAt   time.Time           `bson:"createdAt"`
+	ExpiresAt   time.Time           `bson:"expiresAt"`
+}
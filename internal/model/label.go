@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Label represents a user-defined tag that can be attached to todos
+// @Description Label is a named, coloured tag; names are unique per user regardless of case
+type Label struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f210"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
+	Name      string             `json:"name" bson:"name" example:"Work"`
+	NameKey   string             `json:"-" bson:"nameKey"`
+	Color     string             `json:"color,omitempty" bson:"color,omitempty" example:"#ff8800"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+}
+
+// LabelCreate is used for creating new labels
+// @Description LabelCreate is used when creating a new label
+type LabelCreate struct {
+	Name  string `json:"name" binding:"required,min=1,max=50" example:"Work"`
+	Color string `json:"color" binding:"omitempty,hexcolor" example:"#ff8800"`
+}
+
+// LabelUpdate is used for renaming or recolouring a label
+// @Description LabelUpdate changes the name and/or colour of a label; empty fields are left unchanged
+type LabelUpdate struct {
+	Name  string `json:"name" binding:"omitempty,min=1,max=50" example:"Office"`
+	Color string `json:"color" binding:"omitempty,hexcolor" example:"#0088ff"`
+}
+
+// LabelMerge is used for folding one label into another
+// @Description LabelMerge names the label that absorbs the merged one
+type LabelMerge struct {
+	TargetID primitive.ObjectID `json:"targetId" binding:"required" example:"5f8d0614db5c5c7b3a18f211"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InboxListName is the name of the list every user starts with
+const InboxListName = "Inbox"
+
+// List groups todos into a project
+// @Description List is a named group of todos; every user has exactly one Inbox list, which cannot be deleted or archived
+type List struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f220"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
+	Name      string             `json:"name" bson:"name" example:"Groceries"`
+	Icon      string             `json:"icon,omitempty" bson:"icon,omitempty" example:"🛒"`
+	Archived  bool               `json:"archived" bson:"archived" example:"false"`
+	SortOrder int                `json:"sortOrder" bson:"sortOrder" example:"1"`
+	Inbox     bool               `json:"inbox" bson:"inbox" example:"false"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+}
+
+// ListCreate is used for creating new lists
+// @Description ListCreate is used when creating a new list
+type ListCreate struct {
+	Name      string `json:"name" binding:"required,min=1,max=100" example:"Groceries"`
+	Icon      string `json:"icon" binding:"omitempty,max=32" example:"🛒"`
+	SortOrder int    `json:"sortOrder" example:"1"`
+}
+
+// ListUpdate is used for updating a list
+// @Description ListUpdate changes a list; omitted fields are left unchanged
+type ListUpdate struct {
+	Name      string `json:"name" binding:"omitempty,min=1,max=100" example:"Weekly shop"`
+	Icon      string `json:"icon" binding:"omitempty,max=32" example:"🧺"`
+	Archived  *bool  `json:"archived" example:"true"`
+	SortOrder *int   `json:"sortOrder" example:"2"`
+}
+
+// ListQuery holds the options for listing lists
+type ListQuery struct {
+	Archived bool `form:"archived"`
+}
+
+// ListDeleteQuery chooses what happens to the todos of a deleted list:
+// "move" sends them to the Inbox, "delete" removes them
+type ListDeleteQuery struct {
+	Todos string `form:"todos" binding:"omitempty,oneof=move delete"`
+}
+
+// TodoListMove is used when moving a todo, with its subtasks, to another list
+// @Description TodoListMove names the list a todo moves to
+type TodoListMove struct {
+	ListID primitive.ObjectID `json:"listId" binding:"required" example:"5f8d0614db5c5c7b3a18f220"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+const (
+	// MaxImportItems caps how many todos one import reads
+	MaxImportItems = 5000
+	// MaxImportBytes caps the size of an uploaded import
+	MaxImportBytes = 10 << 20
+)
+
+// Import item statuses
+const (
+	ImportCreated  = "created"
+	ImportSkipped  = "skipped"
+	ImportRejected = "rejected"
+)
+
+// TodoRecord is a todo as it is exported and imported, with its list and
+// labels given by name
+// @Description TodoRecord is the portable form of a todo used by export and import
+type TodoRecord struct {
+	ID          string     `json:"id,omitempty" example:"5f8d0614db5c5c7b3a18f201"`
+	Title       string     `json:"title" example:"Buy groceries"`
+	Description string     `json:"description,omitempty" example:"Get **oat** milk"`
+	Completed   bool       `json:"completed" example:"false"`
+	DueAt       *time.Time `json:"dueAt,omitempty" example:"2022-01-05T09:00:00Z"`
+	Recurrence  string     `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO"`
+	List        string     `json:"list,omitempty" example:"Groceries"`
+	Labels      []string   `json:"labels,omitempty" example:"Errands"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty" example:"2022-01-01T12:00:00Z"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty" example:"2022-01-01T12:00:00Z"`
+}
+
+// TodoExportQuery chooses the format todos are exported in
+type TodoExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json todotxt"`
+}
+
+// TodoImportQuery chooses the format of an import and whether to only
+// report what it would do
+type TodoImportQuery struct {
+	Format string `form:"format" binding:"required,oneof=csv json todotxt"`
+	DryRun bool   `form:"dryRun"`
+}
+
+// TodoImportItem is the outcome of importing one record
+// @Description TodoImportItem reports whether a record was created, skipped as a duplicate or rejected
+type TodoImportItem struct {
+	// Line of the file the record starts on
+	Line   int    `json:"line" example:"2"`
+	Title  string `json:"title,omitempty" example:"Buy groceries"`
+	Status string `json:"status" enums:"created,skipped,rejected" example:"created"`
+	// ID of the created todo, or of the existing todo a duplicate matches
+	ID    string `json:"id,omitempty" example:"5f8d0614db5c5c7b3a18f201"`
+	Error string `json:"error,omitempty"`
+}
+
+// TodoImportResult summarises an import
+// @Description TodoImportResult counts and lists the outcome of every record. With dryRun nothing was stored and created means would be created.
+type TodoImportResult struct {
+	DryRun   bool              `json:"dryRun" example:"false"`
+	Created  int               `json:"created" example:"2"`
+	Skipped  int               `json:"skipped" example:"1"`
+	Rejected int               `json:"rejected" example:"0"`
+	Items    []*TodoImportItem `json:"items"`
+}
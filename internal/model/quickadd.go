@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Kinds of phrase quick-add recognises
+const (
+	QuickAddDue        = "due"
+	QuickAddLabel      = "label"
+	QuickAddPriority   = "priority"
+	QuickAddRecurrence = "recurrence"
+)
+
+// QuickAdd is a todo written as a line of free text
+// @Description QuickAdd holds a todo as typed, such as "Pay rent tomorrow 9am #finance !high every month". Words in double quotes are kept in the title as they are.
+type QuickAdd struct {
+	Text string `json:"text" binding:"required,max=500" example:"Pay rent tomorrow 9am #finance !high every month"`
+}
+
+// QuickAddQuery asks for the text to be parsed without creating the todo
+type QuickAddQuery struct {
+	Preview bool `form:"preview"`
+}
+
+// QuickAddParse is how a line of quick-add text was read
+// @Description QuickAddParse holds the fields read from quick-add text and the phrases each came from. Due dates are in the user's time zone; a date without a time is due at midnight, which shows as all-day.
+type QuickAddParse struct {
+	Title      string          `json:"title" example:"Pay rent"`
+	DueAt      *time.Time      `json:"dueAt,omitempty" example:"2024-03-05T09:00:00Z"`
+	TimeZone   string          `json:"timeZone" example:"Europe/London"`
+	Labels     []string        `json:"labels" example:"finance"`
+	Priority   string          `json:"priority,omitempty" enums:"low,medium,high" example:"high"`
+	Recurrence string          `json:"recurrence,omitempty" example:"FREQ=MONTHLY"`
+	Matches    []QuickAddMatch `json:"matches"`
+}
+
+// QuickAddMatch is a phrase that was read as a field rather than as part of
+// the title
+// @Description QuickAddMatch names the field a phrase of the text was read as
+type QuickAddMatch struct {
+	Kind string `json:"kind" enums:"due,label,priority,recurrence" example:"due"`
+	Text string `json:"text" example:"tomorrow"`
+}
+
+// QuickAddResult is the outcome of a quick add
+// @Description QuickAddResult holds how the text was read and, unless it was a preview, the todo created from it
+type QuickAddResult struct {
+	Parsed *QuickAddParse `json:"parsed"`
+	Todo   *Todo          `json:"todo,omitempty"`
+}
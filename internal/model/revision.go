@@ -0,0 +1,94 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revision actions
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+)
+
+// Revision is an immutable record of one change made to a todo
+// @Description Revision records who changed a todo, when, and how each tracked field changed. State is the todo as it was after the change.
+type Revision struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f230"`
+	TodoID   primitive.ObjectID `json:"todoId" bson:"todoId" example:"5f8d0614db5c5c7b3a18f201"`
+	UserID   primitive.ObjectID `json:"-" bson:"userId"`
+	Revision int                `json:"revision" bson:"revision" example:"3"`
+	Action   string             `json:"action" bson:"action" enums:"create,update,delete,restore,revert" example:"update"`
+	ActorID  primitive.ObjectID `json:"actorId" bson:"actorId" example:"5f8d0614db5c5c7b3a18f200"`
+	// Revision whose state a revert brought back
+	RevertedTo int           `json:"revertedTo,omitempty" bson:"revertedTo,omitempty" example:"1"`
+	CreatedAt  time.Time     `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	Changes    []FieldChange `json:"changes" bson:"changes"`
+	State      TodoState     `json:"state" bson:"state"`
+}
+
+// FieldChange is the old and new value of one field of a todo
+// @Description FieldChange holds the values of a field before and after a change; a missing value means the field was empty
+type FieldChange struct {
+	Field string      `json:"field" bson:"field" example:"title"`
+	Old   interface{} `json:"old,omitempty" bson:"old,omitempty" swaggertype:"string" example:"Buy milk"`
+	New   interface{} `json:"new,omitempty" bson:"new,omitempty" swaggertype:"string" example:"Buy oat milk"`
+}
+
+// TodoState is the part of a todo that revisions track
+// @Description TodoState is a snapshot of the user-controlled fields of a todo
+type TodoState struct {
+	Title       string               `json:"title" bson:"title" example:"Buy groceries"`
+	Description string               `json:"description,omitempty" bson:"description,omitempty" example:"Get **oat** milk"`
+	Completed   bool                 `json:"completed" bson:"completed" example:"false"`
+	Status      string               `json:"status,omitempty" bson:"status,omitempty" example:"in_progress"`
+	Priority    string               `json:"priority,omitempty" bson:"priority,omitempty" example:"high"`
+	DueAt       *time.Time           `json:"dueAt,omitempty" bson:"dueAt,omitempty" example:"2022-01-05T09:00:00Z"`
+	RemindAt    []string             `json:"remindAt,omitempty" bson:"remindAt,omitempty" example:"-1d"`
+	Recurrence  string               `json:"recurrence,omitempty" bson:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO"`
+	ParentID    *primitive.ObjectID  `json:"parentId,omitempty" bson:"parentId,omitempty" example:"5f8d0614db5c5c7b3a18f204"`
+	ListID      *primitive.ObjectID  `json:"listId,omitempty" bson:"listId,omitempty" example:"5f8d0614db5c5c7b3a18f220"`
+	Labels      []primitive.ObjectID `json:"labels,omitempty" bson:"labels,omitempty"`
+}
+
+// State returns the tracked fields of the todo
+func (t *Todo) State() *TodoState {
+	return &TodoState{
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		DueAt:       t.DueAt,
+		RemindAt:    t.ReminderOffsets(),
+		Recurrence:  t.Recurrence,
+		ParentID:    t.ParentID,
+		ListID:      t.ListID,
+		Labels:      t.Labels,
+	}
+}
+
+// Editable returns the fields of the state that a TodoUpdate replaces
+func (s *TodoState) Editable() *TodoUpdate {
+	return &TodoUpdate{
+		Title:       s.Title,
+		Description: s.Description,
+		Completed:   s.Completed,
+		Status:      s.Status,
+		Priority:    s.Priority,
+		DueAt:       s.DueAt,
+		RemindAt:    s.RemindAt,
+		Recurrence:  s.Recurrence,
+	}
+}
+
+// RevisionQuery pages through the history of a todo, newest first
+type RevisionQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+	// Only return revisions older than this one
+	Before int `form:"before" binding:"omitempty,min=1"`
+}
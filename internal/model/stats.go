@@ -0,0 +1,48 @@
+package model
+
+// MaxStatsDays is the longest range statistics can be asked for
+const MaxStatsDays = 366
+
+// StatsQuery picks the days statistics cover, as dates in the user's time
+// zone. Both ends are included.
+type StatsQuery struct {
+	// First day; defaults to six days before to, so a week is covered
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	// Last day; defaults to today
+	To string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// TodoStats summarises how a user got on with their todos over a range of
+// days
+// @Description TodoStats counts what was completed on each day of the range, in the user's time zone, and derives rates, streaks and overdue counts from it. Trashed todos are left out.
+type TodoStats struct {
+	From     string `json:"from" example:"2024-03-04"`
+	To       string `json:"to" example:"2024-03-10"`
+	TimeZone string `json:"timeZone" example:"Europe/London"`
+	// Todos completed during the range
+	Completed int `json:"completed" example:"12"`
+	// Todos created during the range, and the share of them completed by now
+	Created        int     `json:"created" example:"15"`
+	CompletionRate float64 `json:"completionRate" example:"0.8"`
+	// Mean time from creating to completing the todos completed during the
+	// range, in seconds
+	AverageCompletionSeconds int64 `json:"averageCompletionSeconds" example:"93600"`
+	// Runs of consecutive days with at least one completion. The current
+	// streak ends on the last day of the range, or the day before when that
+	// is today and nothing has been completed yet.
+	CurrentStreak int `json:"currentStreak" example:"3"`
+	LongestStreak int `json:"longestStreak" example:"5"`
+	// Open todos due during the range whose due date has passed
+	Overdue int `json:"overdue" example:"2"`
+	// Todos completed during the range after their due date
+	CompletedLate int `json:"completedLate" example:"1"`
+	// Completions on every day of the range, oldest first
+	Days []*StatsDay `json:"days"`
+}
+
+// StatsDay counts the todos completed on one day
+// @Description StatsDay counts the todos completed on a date in the user's time zone
+type StatsDay struct {
+	Date      string `json:"date" example:"2024-03-04"`
+	Completed int    `json:"completed" example:"2"`
+}
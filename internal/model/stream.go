@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Events sent on event streams besides the todo events
+const (
+	// StreamReset tells the client it may have missed events and should
+	// reload its todos
+	StreamReset = "reset"
+	// StreamExpired is sent just before the stream is closed because the
+	// token it was opened with has expired
+	StreamExpired = "expired"
+	// StreamHeartbeat keeps idle WebSocket connections open
+	StreamHeartbeat = "heartbeat"
+)
+
+// StreamEvent is a message sent on an event stream. ID is what a client
+// resumes from after reconnecting; the todo is left out of the events that
+// are not about one.
+// @Description StreamEvent is a todo.created, todo.updated or todo.deleted event, or reset, expired or heartbeat
+type StreamEvent struct {
+	ID         string    `json:"id,omitempty" example:"m1x2k3-42"`
+	Event      string    `json:"event" example:"todo.updated"`
+	OccurredAt time.Time `json:"occurredAt" example:"2022-01-01T12:00:00Z"`
+	Todo       *Todo     `json:"todo,omitempty"`
+}
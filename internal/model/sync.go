@@ -0,0 +1,69 @@
+package model
+
+import "encoding/json"
+
+const (
+	// DefaultSyncLimit is how many changes one sync page holds by default
+	DefaultSyncLimit = 100
+	// MaxSyncLimit is the largest sync page a client may request
+	MaxSyncLimit = 500
+	// MaxSyncMutations caps how many changes a client may push at once
+	MaxSyncMutations = 500
+)
+
+// SyncQuery asks for the changes since a token from an earlier sync
+type SyncQuery struct {
+	// Token from the last sync; without one every todo is sent
+	Since string `form:"since"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// SyncChanges is a page of the changes to the user's todos, oldest first
+// @Description SyncChanges holds the todos created or changed and the IDs of those deleted since the token that was sent. Pass token as since for the next page, or the next sync once more is false.
+type SyncChanges struct {
+	Changed []*Todo  `json:"changed"`
+	Deleted []string `json:"deleted" example:"5f8d0614db5c5c7b3a18f201"`
+	Token   string   `json:"token" example:"eyJxIjo0Mn0"`
+	More    bool     `json:"more" example:"false"`
+}
+
+// SyncRequest pushes changes a client made while offline
+// @Description SyncRequest holds changes in the order the client made them
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations" binding:"required,min=1,max=500,dive"`
+}
+
+// SyncMutation is one change made by a client. Todos created by a client
+// take the ID it generated, so later changes to them can be sent in the
+// same batch and a retried batch does not create them twice.
+// @Description SyncMutation creates a todo under a client-generated ObjectID, or updates or deletes one as of the version the client last saw
+type SyncMutation struct {
+	Op string `json:"op" binding:"required,oneof=create update delete" example:"update"`
+	ID string `json:"id" binding:"required" example:"5f8d0614db5c5c7b3a18f201"`
+	// Version the change was made to; the change is a conflict when the
+	// todo has moved on since. Required for update, optional for delete.
+	BaseVersion *int64 `json:"baseVersion" example:"3"`
+	// Todo to create, for create
+	Todo *TodoCreate `json:"todo"`
+	// Merge patch object, or JSON Patch array, for update
+	Patch json.RawMessage `json:"patch" swaggertype:"object"`
+}
+
+// SyncResult is the outcome of one mutation
+// @Description SyncResult reports the status of a mutation as the equivalent single request would have, except that a change to a todo that has moved on is a 409 carrying the todo as it is now
+type SyncResult struct {
+	Index  int    `json:"index" example:"0"`
+	ID     string `json:"id" example:"5f8d0614db5c5c7b3a18f201"`
+	Status int    `json:"status" example:"200"`
+	Error  string `json:"error,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+}
+
+// SyncResponse lists the results of pushed mutations
+// @Description SyncResponse holds a result per mutation, in the order they were sent
+type SyncResponse struct {
+	Applied   int           `json:"applied" example:"2"`
+	Conflicts int           `json:"conflicts" example:"1"`
+	Failed    int           `json:"failed" example:"0"`
+	Results   []*SyncResult `json:"results"`
+}
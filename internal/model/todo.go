@@ -6,29 +6,325 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Priorities of a todo, from least to most urgent. Todos without one have
+// no priority.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// PriorityRank orders priorities for sorting, from 1 for low to 3 for high.
+// Todos without a priority rank 0 and are stored without one, so they sort
+// after all others in descending order.
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityLow:
+		return 1
+	case PriorityMedium:
+		return 2
+	case PriorityHigh:
+		return 3
+	}
+	return 0
+}
+
 // Todo represents a todo item
 // @Description Todo represents a task that a user wants to track
 type Todo struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f201"`
-	Title     string             `json:"title" bson:"title" binding:"required" example:"Buy groceries"`
-	Completed bool               `json:"completed" bson:"completed" example:"false"`
-	CreatedAt time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
-	UserID    primitive.ObjectID `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+	ID               primitive.ObjectID   `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f201"`
+	Title            string               `json:"title" bson:"title" binding:"required" example:"Buy groceries"`
+	Description      string               `json:"description,omitempty" bson:"description,omitempty" example:"Get **oat** milk"`
+	DescriptionHTML  string               `json:"descriptionHtml,omitempty" bson:"-" example:"<p>Get <strong>oat</strong> milk</p>"`
+	Completed        bool                 `json:"completed" bson:"completed" example:"false"`
+	Status           string               `json:"status,omitempty" bson:"status,omitempty" example:"in_progress"`
+	CompletedAt      *time.Time           `json:"completedAt,omitempty" bson:"completedAt,omitempty" example:"2022-01-04T17:30:00Z"`
+	Priority         string               `json:"priority,omitempty" bson:"priority,omitempty" enums:"low,medium,high" example:"high"`
+	Position         string               `json:"position" bson:"position,omitempty" example:"V"`
+	CreatedAt        time.Time            `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	UserID           primitive.ObjectID   `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
+	UpdatedAt        time.Time            `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+	DueAt            *time.Time           `json:"dueAt,omitempty" bson:"dueAt,omitempty" example:"2022-01-05T09:00:00Z"`
+	TimeZone         string               `json:"timeZone,omitempty" bson:"timeZone,omitempty" example:"Europe/London"`
+	Reminders        []Reminder           `json:"reminders,omitempty" bson:"reminders,omitempty"`
+	Recurrence       string               `json:"recurrence,omitempty" bson:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO"`
+	RecurrenceStart  *time.Time           `json:"recurrenceStart,omitempty" bson:"recurrenceStart,omitempty" example:"2022-01-03T09:00:00Z"`
+	NextOccurrenceID *primitive.ObjectID  `json:"nextOccurrenceId,omitempty" bson:"nextOccurrenceId,omitempty" example:"5f8d0614db5c5c7b3a18f203"`
+	ParentID         *primitive.ObjectID  `json:"parentId,omitempty" bson:"parentId,omitempty" example:"5f8d0614db5c5c7b3a18f204"`
+	Progress         *int                 `json:"progress,omitempty" bson:"-" example:"50"`
+	Labels           []primitive.ObjectID `json:"labels,omitempty" bson:"labels,omitempty"`
+	ListID           *primitive.ObjectID  `json:"listId,omitempty" bson:"listId,omitempty" example:"5f8d0614db5c5c7b3a18f220"`
+	DeletedAt        *time.Time           `json:"deletedAt,omitempty" bson:"deletedAt,omitempty" example:"2022-01-06T10:00:00Z"`
+	Revision         int                  `json:"revision,omitempty" bson:"revision,omitempty" example:"3"`
+	Version          int64                `json:"version" bson:"version" example:"4"`
+	// Number of the last change to the todo in its user's change sequence,
+	// which is what sync tokens point into
+	ChangeSeq int64 `json:"-" bson:"changeSeq,omitempty"`
+	// Priority as a number, which is what sorting by priority uses
+	PriorityRank int `json:"-" bson:"priorityRank,omitempty"`
+	// Set for todos created over CalDAV, which keep the UID and resource
+	// name the client gave them
+	ICalUID string `json:"-" bson:"icalUid,omitempty"`
+	DAVName string `json:"-" bson:"davName,omitempty"`
+}
+
+// Reminder is a notification scheduled relative to a todo's due date.
+// Offsets combine weeks, days, hours and minutes, e.g. "-1d", "-2h30m" or "0";
+// weeks and days are calendar units in the todo's time zone so a "-1d"
+// reminder keeps its wall-clock time across DST changes.
+// @Description Reminder fires at RemindAt, which is the todo's due date shifted by Offset in the todo's time zone
+type Reminder struct {
+	ID       primitive.ObjectID `json:"id" bson:"id" example:"5f8d0614db5c5c7b3a18f202"`
+	Offset   string             `json:"offset" bson:"offset" example:"-1d"`
+	RemindAt time.Time          `json:"remindAt" bson:"remindAt" example:"2022-01-04T09:00:00Z"`
+	SentAt   *time.Time         `json:"sentAt,omitempty" bson:"sentAt,omitempty" example:"2022-01-04T09:00:05Z"`
+	// A reminder whose notification failed is retried from NextAttemptAt,
+	// until it has been attempted too often and FailedAt is set
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty" example:"2022-01-04T09:01:00Z"`
+	FailedAt      *time.Time `json:"failedAt,omitempty" bson:"failedAt,omitempty" example:"2022-01-04T11:00:00Z"`
+	Attempts      int        `json:"-" bson:"attempts,omitempty"`
 }
 
 // TodoCreate is used for creating new todos
 // @Description TodoCreate is used when creating a new todo item
 type TodoCreate struct {
-	Title     string `json:"title" bson:"title" binding:"required" example:"Buy groceries"`
-	Completed *bool  `json:"completed" bson:"completed" example:"false"`
+	Title       string               `json:"title" bson:"title" binding:"required" example:"Buy groceries"`
+	Description string               `json:"description" bson:"-" binding:"max=20000" example:"Get **oat** milk"`
+	Completed   *bool                `json:"completed" bson:"completed" example:"false"`
+	Status      string               `json:"status" bson:"-" binding:"omitempty,max=50" example:"in_progress"`
+	Priority    string               `json:"priority" bson:"-" binding:"omitempty,oneof=low medium high" enums:"low,medium,high" example:"high"`
+	DueAt       *time.Time           `json:"dueAt" bson:"dueAt,omitempty" example:"2022-01-05T09:00:00Z"`
+	RemindAt    []string             `json:"remindAt" bson:"-" example:"-1d,-15m"`
+	Recurrence  string               `json:"recurrence" bson:"-" example:"FREQ=MONTHLY;BYDAY=-1FR"`
+	ParentID    *primitive.ObjectID  `json:"parentId" bson:"-" example:"5f8d0614db5c5c7b3a18f204"`
+	Labels      []primitive.ObjectID `json:"labels" bson:"-"`
+	ListID      *primitive.ObjectID  `json:"listId" bson:"-" example:"5f8d0614db5c5c7b3a18f220"`
+
+	// Filled in by the service, never bound from requests
+	ID              primitive.ObjectID `json:"-" bson:"-"`
+	TimeZone        string             `json:"-" bson:"-"`
+	Reminders       []Reminder         `json:"-" bson:"-"`
+	RecurrenceStart *time.Time         `json:"-" bson:"-"`
+	ICalUID         string             `json:"-" bson:"-"`
+	DAVName         string             `json:"-" bson:"-"`
 }
 
-// TodoUpdate is used for updating existing todos
-// @Description TodoUpdate is used when updating an existing todo item
+// TodoUpdate replaces the editable fields of a todo. Fields left out are
+// cleared; parent, list and labels have their own endpoints and are kept.
+// @Description TodoUpdate is the full editable state of a todo, as sent to PUT and produced by applying a PATCH
 type TodoUpdate struct {
-	Title       string    `json:"title" bson:"title,omitempty" example:"Buy more groceries"`
-	Description string    `json:"description" bson:"description,omitempty" example:"Need to get milk and eggs"`
-	Completed   bool      `json:"completed" bson:"completed,omitempty" example:"true"`
-	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt" example:"2022-01-02T12:00:00Z"`
+	Title       string     `json:"title" binding:"required" example:"Buy more groceries"`
+	Description string     `json:"description" binding:"max=20000" example:"Need to get **milk** and eggs"`
+	Completed   bool       `json:"completed" example:"true"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low medium high" enums:"low,medium,high" example:"medium"`
+	DueAt       *time.Time `json:"dueAt" example:"2022-01-06T09:00:00Z"`
+	RemindAt    []string   `json:"remindAt" example:"-1d"`
+	Recurrence  string     `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+
+	// Versions the client expects the todo to be at, from If-Match
+	IfMatch Precondition `json:"-"`
+
+	// Filled in by the service, never bound from requests
+	TimeZone        string     `json:"-"`
+	Reminders       []Reminder `json:"-"`
+	RecurrenceStart *time.Time `json:"-"`
+	CompletedAt     *time.Time `json:"-"`
+	// Workflow state to move to; otherwise it follows Completed
+	Status string `json:"-"`
+}
+
+// TodoPreconditionFailed is returned when a todo was changed since the
+// version a request expected
+// @Description TodoPreconditionFailed holds the todo as it is now, with its current version
+type TodoPreconditionFailed struct {
+	Error string `json:"error" example:"The todo has been changed since the given version"`
+	Todo  *Todo  `json:"todo"`
+}
+
+// Precondition lists the versions a change may be applied to. A nil
+// Precondition allows any version, while an empty one allows none.
+type Precondition []int64
+
+// Allows reports whether a todo at version may be changed
+func (p Precondition) Allows(version int64) bool {
+	if p == nil {
+		return true
+	}
+	for _, v := range p {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ReminderOffsets lists the offsets the todo's reminders were created from
+func (t *Todo) ReminderOffsets() []string {
+	offsets := make([]string, len(t.Reminders))
+	for i, reminder := range t.Reminders {
+		offsets[i] = reminder.Offset
+	}
+	return offsets
+}
+
+// CompletionTime tells when a completed todo was completed. Todos completed
+// before that was recorded fall back to their last change, which is usually
+// completing them.
+func (t *Todo) CompletionTime() *time.Time {
+	if !t.Completed {
+		return nil
+	}
+	if t.CompletedAt != nil {
+		return t.CompletedAt
+	}
+	completedAt := t.UpdatedAt
+	return &completedAt
+}
+
+// Editable returns the current state of the fields a TodoUpdate replaces,
+// which is the document PATCH requests are applied to
+func (t *Todo) Editable() *TodoUpdate {
+	return &TodoUpdate{
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Priority:    t.Priority,
+		DueAt:       t.DueAt,
+		RemindAt:    t.ReminderOffsets(),
+		Recurrence:  t.Recurrence,
+	}
+}
+
+// TodoPatch is a merge patch or JSON Patch document for the fields of a
+// TodoUpdate, in the format named by ContentType
+type TodoPatch struct {
+	ContentType string
+	Document    []byte
+	IfMatch     Precondition
+}
+
+// MaxTodoDepth is the deepest a todo may be nested, counting the root as 1
+const MaxTodoDepth = 5
+
+// TodoMove is used when moving a todo, with its subtree, under a new parent
+// @Description TodoMove names the new parent of a todo; null moves it to the top level
+type TodoMove struct {
+	ParentID *primitive.ObjectID `json:"parentId" example:"5f8d0614db5c5c7b3a18f204"`
+}
+
+// TodoReorder places a todo between two others in the user's manual order.
+// Either may be left out to place it directly before or after the other.
+// @Description TodoReorder names the todos a todo should come after and before; at least one is required
+type TodoReorder struct {
+	After  *primitive.ObjectID `json:"after" example:"5f8d0614db5c5c7b3a18f205"`
+	Before *primitive.ObjectID `json:"before" example:"5f8d0614db5c5c7b3a18f206"`
+}
+
+// RenderQuery selects extra representations of a todo's Markdown
+// description; "html" adds descriptionHtml next to the raw source
+type RenderQuery struct {
+	Render string `form:"render" binding:"omitempty,oneof=html"`
+}
+
+// TodoDeleteQuery chooses what happens to the children of a deleted todo:
+// "orphan" moves them to the top level, "delete" removes the whole subtree
+type TodoDeleteQuery struct {
+	Cascade string `form:"cascade" binding:"omitempty,oneof=orphan delete"`
+}
+
+const (
+	// DefaultTodoPageLimit is the page size used when no limit is requested
+	DefaultTodoPageLimit = 20
+	// MaxTodoPageLimit is the largest page size a client may request
+	MaxTodoPageLimit = 100
+)
+
+// TodoQuery holds the filtering, sorting and pagination options for listing todos
+type TodoQuery struct {
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor        string     `form:"cursor"`
+	Completed     *bool      `form:"completed"`
+	CreatedAfter  *time.Time `form:"createdAfter" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"createdBefore" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort          string     `form:"sort" binding:"omitempty,oneof=createdAt updatedAt title position priority"`
+	Order         string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Labels        []string   `form:"label"`
+	LabelMatch    string     `form:"labelMatch" binding:"omitempty,oneof=all any"`
+
+	// Filled in by the service, never bound from requests
+	LabelIDs []primitive.ObjectID `form:"-"`
+	ListID   *primitive.ObjectID  `form:"-"`
+}
+
+// Normalize fills in the defaults for any option left unset
+func (q *TodoQuery) Normalize() {
+	if q.Limit <= 0 {
+		q.Limit = DefaultTodoPageLimit
+	}
+	if q.Limit > MaxTodoPageLimit {
+		q.Limit = MaxTodoPageLimit
+	}
+	if q.Sort == "" {
+		q.Sort = "createdAt"
+	}
+	if q.Order == "" {
+		// The manual order reads top to bottom, everything else newest or
+		// most urgent first
+		q.Order = "desc"
+		if q.Sort == "position" {
+			q.Order = "asc"
+		}
+	}
+}
+
+// TodoPage is a single page of todos
+// @Description TodoPage wraps a page of todos together with the cursor for the next page
+type TodoPage struct {
+	Items      []*Todo `json:"items"`
+	NextCursor string  `json:"nextCursor,omitempty" example:"eyJzIjoiY3JlYXRlZEF0In0"`
+}
+
+// TodoListVersion identifies the state of all of a user's todos. It changes
+// whenever any of them is created, changed or removed.
+type TodoListVersion struct {
+	// Last number of the user's change sequence
+	Seq          int64     `bson:"seq"`
+	LastModified time.Time `bson:"changedAt"`
+	// Labels the query's label names currently resolve to
+	Labels []primitive.ObjectID `bson:"-"`
+}
+
+// TrashQuery holds the pagination options for listing deleted todos, which
+// are returned most recently deleted first
+type TrashQuery struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// TodoSearchQuery holds the parameters of a full-text search
+type TodoSearchQuery struct {
+	Q     string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TodoSearchResult is a single ranked search hit
+// @Description TodoSearchResult is a todo matching a search together with its relevance and highlighted excerpts
+type TodoSearchResult struct {
+	Todo       *Todo             `json:"todo"`
+	Score      float64           `json:"score" example:"3.5"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// TodoOccurrencesQuery bounds the window of a recurrence preview
+type TodoOccurrencesQuery struct {
+	From  *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To    *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int        `form:"limit" binding:"omitempty,min=1,max=366"`
+}
+
+// TodoOccurrences lists upcoming instances of a recurring todo
+// @Description TodoOccurrences lists the due dates of a recurring todo within a window, in the todo's time zone
+type TodoOccurrences struct {
+	Recurrence  string      `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Occurrences []time.Time `json:"occurrences"`
 }
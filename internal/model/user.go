@@ -13,6 +13,7 @@ type User struct {
 	FullName     string             `json:"fullName" bson:"fullName" binding:"required,min=3,max=50" msg:"Full name is required and must be between 3 and 50 characters"`
 	Password     string             `json:"password,omitempty" bson:"password" binding:"required,min=6" msg:"Password is required and must be at least 6 characters"`
 	PasswordHash string             `json:"-" bson:"passwordHash"`
+	TimeZone     string             `json:"timeZone,omitempty" bson:"timeZone,omitempty"`
 	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
 }
@@ -21,6 +22,13 @@ type UserRegister struct {
 	Email    string `json:"email" bson:"email" binding:"required,email" msg:"Email is required and must be valid"`
 	FullName string `json:"fullName" bson:"fullName" binding:"required,min=3,max=50" msg:"Full name is required and must be between 3 and 50 characters"`
 	Password string `json:"password,omitempty" bson:"password" binding:"required,min=6" msg:"Password is required and must be at least 6 characters"`
+	TimeZone string `json:"timeZone,omitempty" bson:"timeZone,omitempty" binding:"omitempty,timezone" msg:"Time zone must be an IANA name such as Europe/London"`
+}
+
+// UserUpdate changes the settings of the signed-in user. Fields left out
+// are kept.
+type UserUpdate struct {
+	TimeZone *string `json:"timeZone" binding:"omitempty,timezone" example:"Europe/London" msg:"Time zone must be an IANA name such as Europe/London"`
 }
 
 type AuthUser struct {
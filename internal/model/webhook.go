@@ -0,0 +1,111 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Events webhooks can subscribe to
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+// EventTypes lists every event, which is what a webhook without a filter
+// subscribes to
+var EventTypes = []string{EventTodoCreated, EventTodoUpdated, EventTodoCompleted, EventTodoDeleted}
+
+// TodoEvent is a change to a todo that webhooks are told about. Todo is the
+// todo after the change, or before it for a deletion.
+type TodoEvent struct {
+	Type       string
+	UserID     primitive.ObjectID
+	Todo       *Todo
+	OccurredAt time.Time
+}
+
+// Webhook is an endpoint the user's todo events are POSTed to. The secret
+// signs each delivery and is never returned.
+// @Description Webhook is an endpoint that receives the events it subscribes to, signed with its secret
+type Webhook struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f240"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
+	URL       string             `json:"url" bson:"url" example:"https://hooks.example.com/todos"`
+	Secret    string             `json:"-" bson:"secret"`
+	Events    []string           `json:"events" bson:"events" example:"todo.created,todo.completed"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+}
+
+// WebhookCreate is used for registering a webhook
+// @Description WebhookCreate registers an endpoint; without events it receives every event
+type WebhookCreate struct {
+	URL    string   `json:"url" binding:"required,url,startswith=http,max=2048" example:"https://hooks.example.com/todos"`
+	Secret string   `json:"secret" binding:"required,min=16,max=256" example:"whsec_3f9a1c7e5b2d4068"`
+	Events []string `json:"events" binding:"omitempty,unique,dive,oneof=todo.created todo.updated todo.completed todo.deleted" example:"todo.created,todo.completed"`
+}
+
+// WebhookUpdate is used for changing a webhook
+// @Description WebhookUpdate changes a webhook; empty fields are left unchanged
+type WebhookUpdate struct {
+	URL    string   `json:"url" binding:"omitempty,url,startswith=http,max=2048" example:"https://hooks.example.com/v2/todos"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=256" example:"whsec_8c2e6a0d4f1b3957"`
+	Events []string `json:"events" binding:"omitempty,unique,dive,oneof=todo.created todo.updated todo.completed todo.deleted" example:"todo.deleted"`
+}
+
+// States of a webhook delivery
+const (
+	// DeliveryPending is waiting for its first attempt or a retry
+	DeliveryPending = "pending"
+	// DeliveryDelivered was accepted by the endpoint
+	DeliveryDelivered = "delivered"
+	// DeliveryDead ran out of attempts and is only retried on request
+	DeliveryDead = "dead"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook, with a
+// log of every attempt
+// @Description WebhookDelivery is an event sent to a webhook with its attempts; status is pending, delivered or dead
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f250"`
+	WebhookID     primitive.ObjectID `json:"webhookId" bson:"webhookId" example:"5f8d0614db5c5c7b3a18f240"`
+	UserID        primitive.ObjectID `json:"-" bson:"userId"`
+	Event         string             `json:"event" bson:"event" example:"todo.created"`
+	Payload       json.RawMessage    `json:"payload" bson:"payload" swaggertype:"object"`
+	Status        string             `json:"status" bson:"status" example:"pending"`
+	Attempts      []WebhookAttempt   `json:"attempts" bson:"attempts"`
+	NextAttemptAt *time.Time         `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty" example:"2022-01-01T12:00:30Z"`
+	DeliveredAt   *time.Time         `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty" example:"2022-01-01T12:00:01Z"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	// Tries counts the attempts since the delivery was last queued, which
+	// is what retries back off by
+	Tries int `json:"-" bson:"tries"`
+}
+
+// WebhookAttempt is the outcome of POSTing a delivery once
+// @Description WebhookAttempt is one try at a delivery; error is set when it failed
+type WebhookAttempt struct {
+	At         time.Time `json:"at" bson:"at" example:"2022-01-01T12:00:01Z"`
+	StatusCode int       `json:"statusCode,omitempty" bson:"statusCode,omitempty" example:"200"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty" example:"webhook responded with status 503"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs" example:"84"`
+}
+
+// WebhookDeliveryQuery filters the delivery log of a webhook
+type WebhookDeliveryQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// WebhookPayload is the body POSTed to a webhook
+// @Description WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	ID         string    `json:"id" example:"5f8d0614db5c5c7b3a18f250"`
+	Event      string    `json:"event" example:"todo.completed"`
+	OccurredAt time.Time `json:"occurredAt" example:"2022-01-01T12:00:00Z"`
+	Todo       *Todo     `json:"todo"`
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Keys of the states of the workflow todos follow when their user has none
+const (
+	WorkflowStateOpen = "todo"
+	WorkflowStateDone = "done"
+)
+
+// Workflow is the ordered set of states a user's todos move through. It
+// applies to the todos of the lists it names, and to those of all other
+// lists when it is the user's default.
+// @Description Workflow lists the states of todos in order and the transitions allowed between them; with no transitions any state can follow any other. A todo counts as completed while it is in a done state.
+type Workflow struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty" example:"5f8d0614db5c5c7b3a18f250"`
+	UserID      primitive.ObjectID   `json:"userId" bson:"userId" example:"5f8d0614db5c5c7b3a18f200"`
+	Name        string               `json:"name" bson:"name" example:"Team board"`
+	Default     bool                 `json:"default" bson:"default" example:"true"`
+	ListIDs     []primitive.ObjectID `json:"listIds,omitempty" bson:"listIds,omitempty"`
+	States      []WorkflowState      `json:"states" bson:"states"`
+	Transitions []WorkflowTransition `json:"transitions,omitempty" bson:"transitions,omitempty"`
+	CreatedAt   time.Time            `json:"createdAt" bson:"createdAt" example:"2022-01-01T12:00:00Z"`
+	UpdatedAt   time.Time            `json:"updatedAt" bson:"updatedAt" example:"2022-01-01T12:00:00Z"`
+}
+
+// WorkflowState is one of the states of a workflow
+// @Description WorkflowState is a state todos can be in; key is what todos store and transitions refer to
+type WorkflowState struct {
+	Key  string `json:"key" bson:"key" binding:"required,max=50" example:"review"`
+	Name string `json:"name" bson:"name" binding:"required,max=100" example:"In review"`
+	Done bool   `json:"done" bson:"done" example:"false"`
+}
+
+// WorkflowTransition allows todos to move from one state to another
+// @Description WorkflowTransition allows moving a todo from the state keyed from to the state keyed to
+type WorkflowTransition struct {
+	From string `json:"from" bson:"from" binding:"required" example:"in_progress"`
+	To   string `json:"to" bson:"to" binding:"required" example:"review"`
+}
+
+// WorkflowCreate is used for creating new workflows
+// @Description WorkflowCreate defines a workflow. It needs at least one done state and one state that is not.
+type WorkflowCreate struct {
+	Name        string               `json:"name" binding:"required,min=1,max=100" example:"Team board"`
+	Default     bool                 `json:"default" example:"true"`
+	ListIDs     []primitive.ObjectID `json:"listIds" binding:"max=100"`
+	States      []WorkflowState      `json:"states" binding:"required,min=2,max=20,dive"`
+	Transitions []WorkflowTransition `json:"transitions" binding:"max=400,dive"`
+}
+
+// WorkflowUpdate replaces the definition of a workflow
+// @Description WorkflowUpdate is the whole new definition of a workflow; todos in states it drops count as being in its first done or first open state
+type WorkflowUpdate struct {
+	Name        string               `json:"name" binding:"required,min=1,max=100" example:"Team board"`
+	Default     bool                 `json:"default" example:"true"`
+	ListIDs     []primitive.ObjectID `json:"listIds" binding:"max=100"`
+	States      []WorkflowState      `json:"states" binding:"required,min=2,max=20,dive"`
+	Transitions []WorkflowTransition `json:"transitions" binding:"max=400,dive"`
+}
+
+// TodoTransition moves a todo to another state of its workflow
+// @Description TodoTransition names the state of its workflow to move a todo to
+type TodoTransition struct {
+	Status string `json:"status" binding:"required" example:"review"`
+
+	// Versions the client expects the todo to be at, from If-Match
+	IfMatch Precondition `json:"-"`
+}
+
+// DefaultWorkflow is the workflow of todos whose user has not defined one,
+// which is just the completed flag
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Name: "Default",
+		States: []WorkflowState{
+			{Key: WorkflowStateOpen, Name: "To do"},
+			{Key: WorkflowStateDone, Name: "Done", Done: true},
+		},
+	}
+}
+
+// ValidateWorkflow checks that the states of a workflow have distinct keys,
+// include done and open ones, and that transitions join two of them
+func ValidateWorkflow(states []WorkflowState, transitions []WorkflowTransition) error {
+	keys := make(map[string]bool, len(states))
+	var done, open bool
+	for _, state := range states {
+		if keys[state.Key] {
+			return fmt.Errorf("state %q is defined twice", state.Key)
+		}
+		keys[state.Key] = true
+		done = done || state.Done
+		open = open || !state.Done
+	}
+	if !done || !open {
+		return fmt.Errorf("a workflow needs at least one done state and one that is not")
+	}
+
+	seen := make(map[WorkflowTransition]bool, len(transitions))
+	for _, transition := range transitions {
+		for _, key := range []string{transition.From, transition.To} {
+			if !keys[key] {
+				return fmt.Errorf("transition from %q to %q refers to unknown state %q", transition.From, transition.To, key)
+			}
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("state %q cannot transition to itself", transition.From)
+		}
+		if seen[transition] {
+			return fmt.Errorf("transition from %q to %q is defined twice", transition.From, transition.To)
+		}
+		seen[transition] = true
+	}
+	return nil
+}
+
+// State returns the state with the given key, or nil
+func (w *Workflow) State(key string) *WorkflowState {
+	for i := range w.States {
+		if w.States[i].Key == key {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// FirstState is the key of the first done state, or of the first open one
+func (w *Workflow) FirstState(done bool) string {
+	for _, state := range w.States {
+		if state.Done == done {
+			return state.Key
+		}
+	}
+	return ""
+}
+
+// StateOf tells which state of the workflow a todo is in. Todos without a
+// status, or with one the workflow does not have, are in its first done or
+// first open state according to whether they are completed.
+func (w *Workflow) StateOf(todo *Todo) string {
+	if w.State(todo.Status) != nil {
+		return todo.Status
+	}
+	return w.FirstState(todo.Completed)
+}
+
+// Allows reports whether a todo may move from one state to another
+func (w *Workflow) Allows(from, to string) bool {
+	if len(w.Transitions) == 0 {
+		return true
+	}
+	for _, transition := range w.Transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+	return false
+}
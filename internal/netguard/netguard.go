@@ -0,0 +1,103 @@
+// Package netguard keeps outgoing requests made on behalf of users, such as
+// webhook deliveries, away from the server's own network.
+package netguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Guard refuses connections to loopback, private, link-local and
+// unspecified addresses, except those in the networks it allows
+type Guard struct {
+	allowed []netip.Prefix
+}
+
+// New returns a guard allowing the given networks in CIDR notation, such
+// as "10.1.0.0/16". A bare address allows just that address.
+func New(allowed []string) (*Guard, error) {
+	g := &Guard{}
+	for _, network := range allowed {
+		network = strings.TrimSpace(network)
+		if network == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(network)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", network, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		g.allowed = append(g.allowed, prefix.Masked())
+	}
+	return g, nil
+}
+
+// Allows reports whether connecting to an address is allowed
+func (g *Guard) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast())
+}
+
+// Control is a net.Dialer Control function. It checks the address a
+// connection is actually made to, after name resolution, so a name that
+// resolves elsewhere by the time of the request is still caught.
+func (g *Guard) Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("netguard: unexpected address %q: %w", address, err)
+	}
+	if !g.Allows(addrPort.Addr()) {
+		return fmt.Errorf("netguard: connecting to %s is not allowed", addrPort.Addr())
+	}
+	return nil
+}
+
+// Client returns an HTTP client whose connections the guard checks. It
+// ignores proxy settings, since a proxy would connect on its behalf.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: g.Control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// AllowsURL reports whether the host of a URL is one the guard allows.
+// Every address a name resolves to must be allowed. A name that does not
+// resolve passes, as Control checks it again when a connection is made.
+func (g *Guard) AllowsURL(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return g.Allows(addr)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return true
+	}
+	for _, addr := range addrs {
+		if !g.Allows(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// Package notifier delivers reminder notifications to users.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification describes a reminder that has come due
+type Notification struct {
+	TodoID    string    `json:"todoId"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email,omitempty"`
+	Title     string    `json:"title"`
+	DueAt     time.Time `json:"dueAt"`
+	RemindAt  time.Time `json:"remindAt"`
+	Offset    string    `json:"offset"`
+	TimeZone  string    `json:"timeZone,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Notifier sends a notification. An error means it was not delivered.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New builds the notifier selected by kind: "log", "webhook" or "email"
+func New(kind, webhookURL string) (Notifier, error) {
+	switch kind {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "webhook":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("webhook notifier requires a URL")
+		}
+		return NewWebhookNotifier(webhookURL), nil
+	case "email":
+		return NewEmailNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a notifier that writes reminders to the log
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("Reminder - User: %s | Todo: %s (%s) | Due: %s",
+		notification.UserID, notification.Title, notification.TodoID, notification.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier returns a notifier that POSTs reminders as JSON to url
+func NewWebhookNotifier(url string) Notifier {
+	return &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emailNotifier stands in for a real mail provider: it renders the message
+// a user would receive and logs it.
+type emailNotifier struct{}
+
+// NewEmailNotifier returns the stand-in email notifier
+func NewEmailNotifier() Notifier {
+	return &emailNotifier{}
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.Email == "" {
+		return fmt.Errorf("no email address for user %s", notification.UserID)
+	}
+
+	due := notification.DueAt
+	if loc, err := time.LoadLocation(notification.TimeZone); err == nil {
+		due = due.In(loc)
+	}
+
+	log.Printf("Email - To: %s | Subject: Reminder: %s | Body: %q is due %s",
+		notification.Email, notification.Title, notification.Title, due.Format("Mon 2 Jan 2006 15:04 MST"))
+	return nil
+}
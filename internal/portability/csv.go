@@ -0,0 +1,134 @@
+package portability
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/internal/model"
+)
+
+// csvColumns are the columns exports have. Imports may leave out or reorder
+// any of them except title.
+var csvColumns = []string{"id", "title", "description", "completed", "dueAt", "recurrence", "list", "labels", "createdAt", "updatedAt"}
+
+// csvLabelSeparator joins the label names of a todo in one cell
+const csvLabelSeparator = ","
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w)}
+	if err := writer.w.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *csvWriter) Write(record *model.TodoRecord) error {
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return formatTime(*t)
+	}
+	return w.w.Write([]string{
+		record.ID,
+		record.Title,
+		record.Description,
+		strconv.FormatBool(record.Completed),
+		optionalTime(record.DueAt),
+		record.Recurrence,
+		record.List,
+		strings.Join(record.Labels, csvLabelSeparator),
+		optionalTime(record.CreatedAt),
+		optionalTime(record.UpdatedAt),
+	})
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func readCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("the CSV file is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets like to prepend a byte order mark
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		columns[strings.ToLower(name)] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("the CSV header has no title column")
+	}
+
+	var entries []Entry
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				// The reader cannot recover from a broken quote
+				entries = append(entries, Entry{Line: parseErr.StartLine, Err: parseErr.Err})
+				return entries, nil
+			}
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		record, err := csvRecord(row, columns)
+		entries = append(entries, Entry{Line: line, Record: record, Err: err})
+	}
+}
+
+func csvRecord(row []string, columns map[string]int) (*model.TodoRecord, error) {
+	cell := func(name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	record := &model.TodoRecord{
+		ID:          cell("id"),
+		Title:       cell("title"),
+		Description: cell("description"),
+		Recurrence:  cell("recurrence"),
+		List:        cell("list"),
+	}
+	if completed := cell("completed"); completed != "" {
+		value, err := strconv.ParseBool(completed)
+		if err != nil {
+			return record, fmt.Errorf("invalid completed value %q", completed)
+		}
+		record.Completed = value
+	}
+	var err error
+	if record.DueAt, err = parseTime(cell("dueAt")); err != nil {
+		return record, err
+	}
+	for _, name := range strings.Split(cell("labels"), csvLabelSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			record.Labels = append(record.Labels, name)
+		}
+	}
+	return record, checkRecord(record)
+}
@@ -0,0 +1,80 @@
+package portability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"todo-app/internal/model"
+)
+
+// jsonWriter writes an array with one record per line
+type jsonWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (w *jsonWriter) Write(record *model.TodoRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if !w.written {
+		separator = "[\n"
+		w.written = true
+	}
+	_, err = w.w.Write(append([]byte(separator), data...))
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if !w.written {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return err
+}
+
+func readJSON(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errors.New("a JSON import must be an array of todos")
+	}
+
+	var entries []Entry
+	for decoder.More() {
+		line := lineAt(data, decoder.InputOffset())
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			// Malformed JSON leaves the decoder lost, so stop here
+			entries = append(entries, Entry{Line: line, Err: err})
+			return entries, nil
+		}
+
+		var record model.TodoRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			entries = append(entries, Entry{Line: line, Err: fmt.Errorf("invalid todo: %v", err)})
+			continue
+		}
+		entries = append(entries, Entry{Line: line, Record: &record, Err: checkRecord(&record)})
+	}
+	return entries, nil
+}
+
+// lineAt is the line of the first value at or after offset, skipping the
+// separators between array elements
+func lineAt(data []byte, offset int64) int {
+	for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
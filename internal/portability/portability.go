@@ -0,0 +1,119 @@
+// Package portability reads and writes todos in the formats users move
+// them in and out with: CSV, JSON and todo.txt. Writers stream one record at
+// a time; readers keep the line every record starts on so problems can be
+// reported against the file.
+package portability
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"todo-app/internal/model"
+)
+
+// Formats
+const (
+	CSV     = "csv"
+	JSON    = "json"
+	TodoTxt = "todotxt"
+)
+
+// Writer writes records in one format. Close finishes the document but
+// does not close the underlying writer.
+type Writer interface {
+	Write(record *model.TodoRecord) error
+	Close() error
+}
+
+// Entry is one record read from an import, or why it could not be read
+type Entry struct {
+	Line   int
+	Record *model.TodoRecord
+	Err    error
+}
+
+// NewWriter returns a writer for format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w)
+	case JSON:
+		return &jsonWriter{w: w}, nil
+	case TodoTxt:
+		return &todoTxtWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// Read reads every record of an import. A record that cannot be read is
+// returned as an entry with Err set; only a file that cannot be read at all
+// is an error.
+func Read(format string, r io.Reader) ([]Entry, error) {
+	switch format {
+	case CSV:
+		return readCSV(r)
+	case JSON:
+		return readJSON(r)
+	case TodoTxt:
+		return readTodoTxt(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// ContentType is the media type of a file in format
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case TodoTxt:
+		return "text/plain; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// FileName is the name exports in format are downloaded as
+func FileName(format string) string {
+	switch format {
+	case CSV:
+		return "todos.csv"
+	case TodoTxt:
+		return "todo.txt"
+	}
+	return "todos.json"
+}
+
+// parseTime accepts RFC 3339 times and plain dates, which are taken as
+// midnight UTC
+func parseTime(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, use RFC 3339 or YYYY-MM-DD", value)
+	}
+	return &t, nil
+}
+
+// formatTime writes midnight UTC as a plain date and anything else in
+// RFC 3339
+func formatTime(t time.Time) string {
+	t = t.UTC()
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// checkRecord rejects records every format needs to get right
+func checkRecord(record *model.TodoRecord) error {
+	if strings.TrimSpace(record.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package portability
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"todo-app/internal/model"
+)
+
+// todo.txt keeps everything on one line: "x" for done, the completion and
+// creation dates, then the title with +list, @label and key:value tags.
+// Descriptions have no place in it and are left out. The date a completed
+// todo last changed stands in for its completion date.
+type todoTxtWriter struct {
+	w io.Writer
+}
+
+func (w *todoTxtWriter) Write(record *model.TodoRecord) error {
+	var parts []string
+	if record.Completed {
+		parts = append(parts, "x")
+		if record.UpdatedAt != nil {
+			parts = append(parts, record.UpdatedAt.UTC().Format(time.DateOnly))
+		}
+	}
+	if record.CreatedAt != nil {
+		parts = append(parts, record.CreatedAt.UTC().Format(time.DateOnly))
+	}
+	parts = append(parts, strings.Join(strings.Fields(record.Title), " "))
+	if record.List != "" {
+		parts = append(parts, "+"+todoTxtName(record.List))
+	}
+	for _, label := range record.Labels {
+		parts = append(parts, "@"+todoTxtName(label))
+	}
+	if record.DueAt != nil {
+		parts = append(parts, "due:"+formatTime(*record.DueAt))
+	}
+	if record.Recurrence != "" {
+		parts = append(parts, "rrule:"+record.Recurrence)
+	}
+	if record.ID != "" {
+		parts = append(parts, "id:"+record.ID)
+	}
+
+	_, err := io.WriteString(w.w, strings.Join(parts, " ")+"\n")
+	return err
+}
+
+func (w *todoTxtWriter) Close() error {
+	return nil
+}
+
+// todoTxtName makes a list or label name a single word. Reading turns the
+// underscores back into spaces.
+func todoTxtName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+func readTodoTxt(r io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), model.MaxImportBytes)
+
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		record, err := todoTxtRecord(text)
+		entries = append(entries, Entry{Line: line, Record: record, Err: err})
+	}
+	return entries, scanner.Err()
+}
+
+func todoTxtRecord(text string) (*model.TodoRecord, error) {
+	record := &model.TodoRecord{}
+	words := strings.Fields(text)
+
+	if len(words) > 0 && words[0] == "x" {
+		record.Completed = true
+		words = words[1:]
+		// The completion date comes first and is not kept; a creation
+		// date may only follow it
+		if len(words) == 0 || !isTodoTxtDate(words[0]) {
+			return record, todoTxtTitle(record, words)
+		}
+		words = words[1:]
+	} else if len(words) > 0 && len(words[0]) == 3 && words[0][0] == '(' && words[0][2] == ')' {
+		// Priorities are not supported yet and are dropped
+		words = words[1:]
+	}
+	if len(words) > 0 && isTodoTxtDate(words[0]) {
+		created, _ := time.Parse(time.DateOnly, words[0])
+		record.CreatedAt = &created
+		words = words[1:]
+	}
+
+	return record, todoTxtTitle(record, words)
+}
+
+// todoTxtTitle fills in the title of a record and the tags found in it
+func todoTxtTitle(record *model.TodoRecord, words []string) error {
+	var title []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+' && record.List == "":
+			record.List = strings.ReplaceAll(word[1:], "_", " ")
+		case len(word) > 1 && word[0] == '@':
+			record.Labels = append(record.Labels, strings.ReplaceAll(word[1:], "_", " "))
+		case strings.HasPrefix(word, "due:"):
+			due, err := parseTime(strings.TrimPrefix(word, "due:"))
+			if err != nil {
+				return fmt.Errorf("invalid due date: %v", err)
+			}
+			record.DueAt = due
+		case strings.HasPrefix(word, "rrule:"):
+			record.Recurrence = strings.TrimPrefix(word, "rrule:")
+		case strings.HasPrefix(word, "id:"):
+			record.ID = strings.TrimPrefix(word, "id:")
+		default:
+			title = append(title, word)
+		}
+	}
+	record.Title = strings.Join(title, " ")
+	return checkRecord(record)
+}
+
+func isTodoTxtDate(word string) bool {
+	_, err := time.Parse(time.DateOnly, word)
+	return err == nil
+}
@@ -0,0 +1,522 @@
+// Package quickadd reads a todo from a line of free text such as
+// "Pay rent tomorrow 9am #finance !high every month". Phrases it recognises
+// become fields and the remaining words form the title. The result depends
+// only on the text, the clock and the time zone passed in, so the same line
+// always reads the same way.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/rrule"
+	"unicode"
+	"unicode/utf8"
+)
+
+var priorities = map[string]string{
+	"!high":   model.PriorityHigh,
+	"!medium": model.PriorityMedium,
+	"!med":    model.PriorityMedium,
+	"!low":    model.PriorityLow,
+	"!1":      model.PriorityHigh,
+	"!2":      model.PriorityMedium,
+	"!3":      model.PriorityLow,
+}
+
+var weekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// units are the periods of "every 2 weeks" and "in 3 days"
+var units = map[string]rrule.Frequency{
+	"day": rrule.Daily, "days": rrule.Daily,
+	"week": rrule.Weekly, "weeks": rrule.Weekly,
+	"month": rrule.Monthly, "months": rrule.Monthly,
+	"year": rrule.Yearly, "years": rrule.Yearly,
+}
+
+// adverbs repeat on their own, as in "water plants daily"
+var adverbs = map[string]rrule.Frequency{
+	"daily":    rrule.Daily,
+	"weekly":   rrule.Weekly,
+	"monthly":  rrule.Monthly,
+	"yearly":   rrule.Yearly,
+	"annually": rrule.Yearly,
+}
+
+var (
+	twelveHour     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+	twentyFourHour = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	ordinal        = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+// word is a whitespace-separated part of the text. Literal words came from
+// double quotes and always stay in the title.
+type word struct {
+	text    string
+	literal bool
+}
+
+type parser struct {
+	words  []word
+	now    time.Time
+	loc    *time.Location
+	result *model.QuickAddParse
+
+	date         time.Time
+	hasDate      bool
+	hour, minute int
+	hasTime      bool
+	rule         *rrule.Rule
+}
+
+// Parse reads a line of text as of now in loc. Only the first due date,
+// time, priority and recurrence are taken; repeats of them stay in the
+// title.
+func Parse(text string, now time.Time, loc *time.Location) *model.QuickAddParse {
+	p := &parser{
+		words: split(text),
+		now:   now.In(loc),
+		loc:   loc,
+		result: &model.QuickAddParse{
+			TimeZone: loc.String(),
+			Labels:   []string{},
+			Matches:  []model.QuickAddMatch{},
+		},
+	}
+
+	var title []string
+	for i := 0; i < len(p.words); {
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+		title = append(title, p.words[i].text)
+		i++
+	}
+	p.result.Title = strings.Join(title, " ")
+	p.resolveDue()
+	return p.result
+}
+
+// split breaks text into words, keeping double-quoted runs together
+func split(text string) []word {
+	var words []word
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], `"`) {
+			words = append(words, word{text: fields[i]})
+			continue
+		}
+		end := i
+		for end < len(fields)-1 && !closesQuote(fields[end], end == i) {
+			end++
+		}
+		if quoted := strings.Trim(strings.Join(fields[i:end+1], " "), `"`); quoted != "" {
+			words = append(words, word{text: quoted, literal: true})
+		}
+		i = end
+	}
+	return words
+}
+
+func closesQuote(field string, opening bool) bool {
+	if opening {
+		return len(field) > 1 && strings.HasSuffix(field, `"`)
+	}
+	return strings.HasSuffix(field, `"`)
+}
+
+// key is the word at i as phrases are matched: lower case, without
+// trailing punctuation. Literal words and words past the end have none.
+func (p *parser) key(i int) string {
+	if i >= len(p.words) || p.words[i].literal {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(p.words[i].text, ",.;:!?"))
+}
+
+// match reads a phrase starting at word i and returns how many words it took
+func (p *parser) match(i int) int {
+	matchers := []struct {
+		kind string
+		read func(int) int
+	}{
+		{model.QuickAddLabel, p.label},
+		{model.QuickAddPriority, p.priority},
+		{model.QuickAddRecurrence, p.recurrence},
+		{model.QuickAddDue, p.day},
+		{model.QuickAddDue, p.clock},
+	}
+	if p.key(i) == "" {
+		return 0
+	}
+	for _, matcher := range matchers {
+		if n := matcher.read(i); n > 0 {
+			texts := make([]string, n)
+			for j := range texts {
+				texts[j] = p.words[i+j].text
+			}
+			p.result.Matches = append(p.result.Matches, model.QuickAddMatch{Kind: matcher.kind, Text: strings.Join(texts, " ")})
+			return n
+		}
+	}
+	return 0
+}
+
+// label reads "#name", with underscores standing for spaces. Names start
+// with a letter so that "issue #12" stays in the title.
+func (p *parser) label(i int) int {
+	text := strings.TrimRight(p.words[i].text, ",.;:!?")
+	if !strings.HasPrefix(text, "#") {
+		return 0
+	}
+	name := strings.ReplaceAll(text[1:], "_", " ")
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(first) {
+		return 0
+	}
+	for _, existing := range p.result.Labels {
+		if strings.EqualFold(existing, name) {
+			return 1
+		}
+	}
+	p.result.Labels = append(p.result.Labels, name)
+	return 1
+}
+
+// priority reads "!high", "!medium" or "!low", or "!1" to "!3"
+func (p *parser) priority(i int) int {
+	priority, ok := priorities[p.key(i)]
+	if !ok || p.result.Priority != "" {
+		return 0
+	}
+	p.result.Priority = priority
+	return 1
+}
+
+// recurrence reads "daily" and the like, "every day", "every other week",
+// "every 3 months", "every weekday" and lists of weekdays such as
+// "every monday and thursday"
+func (p *parser) recurrence(i int) int {
+	if p.rule != nil {
+		return 0
+	}
+	if freq, ok := adverbs[p.key(i)]; ok {
+		return p.repeat(&rrule.Rule{Freq: freq, Interval: 1}, 1)
+	}
+	if p.key(i) != "every" {
+		return 0
+	}
+
+	next := p.key(i + 1)
+	if freq, ok := units[next]; ok {
+		return p.repeat(&rrule.Rule{Freq: freq, Interval: 1}, 2)
+	}
+	if freq, ok := units[p.key(i+2)]; ok {
+		if next == "other" {
+			return p.repeat(&rrule.Rule{Freq: freq, Interval: 2}, 3)
+		}
+		if n, err := strconv.Atoi(next); err == nil && n >= 1 && n <= 999 {
+			return p.repeat(&rrule.Rule{Freq: freq, Interval: n}, 3)
+		}
+	}
+	switch next {
+	case "weekday":
+		return p.repeat(weeklyOn(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday), 2)
+	case "weekend":
+		return p.repeat(weeklyOn(time.Saturday, time.Sunday), 2)
+	}
+
+	var days []time.Weekday
+	j := i + 1
+	for {
+		weekday, ok := weekdays[p.key(j)]
+		if !ok {
+			break
+		}
+		days = append(days, weekday)
+		j++
+		if _, ok := weekdays[p.key(j+1)]; ok && p.key(j) == "and" {
+			j++
+		}
+	}
+	if len(days) == 0 {
+		return 0
+	}
+	return p.repeat(weeklyOn(days...), j-i)
+}
+
+func (p *parser) repeat(rule *rrule.Rule, n int) int {
+	p.rule = rule
+	p.result.Recurrence = rule.String()
+	return n
+}
+
+func weeklyOn(days ...time.Weekday) *rrule.Rule {
+	rule := &rrule.Rule{Freq: rrule.Weekly, Interval: 1}
+	seen := map[time.Weekday]bool{}
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			rule.ByDay = append(rule.ByDay, rrule.WeekdayNum{Weekday: day})
+		}
+	}
+	return rule
+}
+
+// day reads a due date, optionally after "on", "due" or "by"
+func (p *parser) day(i int) int {
+	if p.hasDate {
+		return 0
+	}
+	start := i
+	switch p.key(i) {
+	case "on", "due", "by":
+		i++
+	}
+	n := p.dayPhrase(i)
+	if n == 0 {
+		return 0
+	}
+	return i + n - start
+}
+
+// dayPhrase reads "today", "tomorrow", a weekday, "next week", "in 3 days",
+// "2024-03-15", "march 15" or "15 march", the last two with an optional
+// year. A weekday is the next one after today.
+func (p *parser) dayPhrase(i int) int {
+	today := p.today()
+	key := p.key(i)
+	switch key {
+	case "today":
+		return p.setDate(today, 1)
+	case "tomorrow":
+		return p.setDate(today.AddDate(0, 0, 1), 1)
+	case "next":
+		next := p.key(i + 1)
+		if weekday, ok := weekdays[next]; ok {
+			return p.setDate(nextWeekday(today, weekday), 2)
+		}
+		switch next {
+		case "week":
+			return p.setDate(nextWeekday(today, time.Monday), 2)
+		case "month":
+			return p.setDate(time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, p.loc), 2)
+		case "year":
+			return p.setDate(time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, p.loc), 2)
+		}
+		return 0
+	case "in":
+		amount := 0
+		switch next := p.key(i + 1); next {
+		case "a", "an":
+			amount = 1
+		default:
+			n, err := strconv.Atoi(next)
+			if err != nil || n < 1 || n > 999 {
+				return 0
+			}
+			amount = n
+		}
+		switch units[p.key(i+2)] {
+		case rrule.Daily:
+			return p.setDate(today.AddDate(0, 0, amount), 3)
+		case rrule.Weekly:
+			return p.setDate(today.AddDate(0, 0, 7*amount), 3)
+		case rrule.Monthly:
+			return p.setDate(today.AddDate(0, amount, 0), 3)
+		case rrule.Yearly:
+			return p.setDate(today.AddDate(amount, 0, 0), 3)
+		}
+		return 0
+	}
+	if weekday, ok := weekdays[key]; ok {
+		return p.setDate(nextWeekday(today, weekday), 1)
+	}
+	if date, err := time.ParseInLocation("2006-01-02", key, p.loc); err == nil {
+		return p.setDate(date, 1)
+	}
+
+	// "march 15" or "15 march", then perhaps a year
+	month, monthOK := months[key]
+	day, dayOK := dayOfMonth(p.key(i + 1))
+	if !monthOK || !dayOK {
+		day, dayOK = dayOfMonth(key)
+		month, monthOK = months[p.key(i+1)]
+	}
+	if !monthOK || !dayOK {
+		return 0
+	}
+	n, year := 2, today.Year()
+	if y, err := strconv.Atoi(p.key(i + 2)); err == nil && y >= 1970 && y <= 9999 {
+		n, year = 3, y
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.loc)
+	if date.Day() != day {
+		return 0
+	}
+	// Without a year the date is the next one to come
+	if n == 2 && date.Before(today) {
+		date = time.Date(year+1, month, day, 0, 0, 0, 0, p.loc)
+		if date.Day() != day {
+			return 0
+		}
+	}
+	return p.setDate(date, n)
+}
+
+func (p *parser) setDate(date time.Time, n int) int {
+	p.date, p.hasDate = date, true
+	return n
+}
+
+// clock reads a time of day, optionally after "at": "9am", "9:30pm",
+// "9 pm", "21:00", "noon" or "midnight"
+func (p *parser) clock(i int) int {
+	if p.hasTime {
+		return 0
+	}
+	start := i
+	if p.key(i) == "at" {
+		i++
+	}
+	n := p.clockPhrase(i)
+	if n == 0 {
+		return 0
+	}
+	return i + n - start
+}
+
+func (p *parser) clockPhrase(i int) int {
+	key := p.key(i)
+	switch key {
+	case "noon":
+		return p.setTime(12, 0, 1)
+	case "midnight":
+		return p.setTime(0, 0, 1)
+	}
+
+	n := 1
+	if next := p.key(i + 1); (next == "am" || next == "pm") && !strings.ContainsAny(key, "apm") {
+		key, n = key+next, 2
+	}
+	if match := twelveHour.FindStringSubmatch(key); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute := 0
+		if match[2] != "" {
+			minute, _ = strconv.Atoi(match[2])
+		}
+		if hour < 1 || hour > 12 || minute > 59 {
+			return 0
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+		return p.setTime(hour, minute, n)
+	}
+	if match := twentyFourHour.FindStringSubmatch(key); match != nil && n == 1 {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		if hour > 23 || minute > 59 {
+			return 0
+		}
+		return p.setTime(hour, minute, 1)
+	}
+	return 0
+}
+
+func (p *parser) setTime(hour, minute, n int) int {
+	p.hour, p.minute, p.hasTime = hour, minute, true
+	return n
+}
+
+// resolveDue combines the date, time and recurrence read into a due date.
+// A time alone is due today, or tomorrow once it has passed. A date alone
+// is due at midnight, which shows as all-day. A recurrence alone starts
+// today, or on its first weekday to come.
+func (p *parser) resolveDue() {
+	if !p.hasDate && !p.hasTime && p.rule == nil {
+		return
+	}
+
+	day := p.today()
+	switch {
+	case p.hasDate:
+		day = p.date
+	case p.rule != nil && len(p.rule.ByDay) > 0:
+		for offset := 0; offset <= 7; offset++ {
+			candidate := day.AddDate(0, 0, offset)
+			if p.onRuleDay(candidate.Weekday()) && (!p.hasTime || p.at(candidate).After(p.now)) {
+				day = candidate
+				break
+			}
+		}
+	case p.hasTime && !p.at(day).After(p.now):
+		day = day.AddDate(0, 0, 1)
+	}
+
+	due := p.at(day)
+	p.result.DueAt = &due
+}
+
+func (p *parser) onRuleDay(weekday time.Weekday) bool {
+	for _, day := range p.rule.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// at is the time read on the given day, or its midnight without one
+func (p *parser) at(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), p.hour, p.minute, 0, 0, p.loc)
+}
+
+func (p *parser) today() time.Time {
+	return time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.loc)
+}
+
+// nextWeekday is the first such weekday after today
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(today.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// dayOfMonth reads "15" or "15th"
+func dayOfMonth(key string) (int, bool) {
+	match := ordinal.FindStringSubmatch(key)
+	if match == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(match[1])
+	return day, day >= 1 && day <= 31
+}
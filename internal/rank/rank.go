@@ -0,0 +1,128 @@
+// Package rank generates lexicographic ranks for ordering todos by hand.
+// A rank is read as a base-62 fraction, so a new one always fits between
+// any two others and moving an item only rewrites that item. Ranks grow
+// longer as items are squeezed into the same gap, until Spread hands out
+// short evenly spaced ones again.
+package rank
+
+import (
+	"fmt"
+	"strings"
+)
+
+// digits are in ASCII order, so comparing ranks as strings compares them as
+// fractions. No rank ends in the lowest digit, as nothing would fit between
+// "A" and "A0".
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// Valid reports whether s is a rank
+func Valid(s string) bool {
+	if s == "" || s[len(s)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(digits, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a rank after before and ahead of after. An empty before
+// stands for the start and an empty after for the end, so Between("", "")
+// is a first rank. It fails unless before sorts ahead of after.
+func Between(before, after string) (string, error) {
+	if (before != "" && !Valid(before)) || (after != "" && !Valid(after)) {
+		return "", fmt.Errorf("invalid rank")
+	}
+	if after != "" && before >= after {
+		return "", fmt.Errorf("rank %q does not sort ahead of %q", before, after)
+	}
+	return midpoint(before, after), nil
+}
+
+// After returns a short rank following last, or a first rank when last is
+// empty. Unlike Between(last, "") it leaves little room behind, but ranks
+// grow a digit only every 61 calls, which suits always appending.
+func After(last string) string {
+	for i := 0; i < len(last); i++ {
+		if next := strings.IndexByte(digits, last[i]) + 1; next < base {
+			return last[:i] + string(digits[next])
+		}
+	}
+	if last == "" {
+		return midpoint("", "")
+	}
+	return last + digits[1:2]
+}
+
+// midpoint finds a rank between a and b, where b is empty for the end
+func midpoint(a, b string) string {
+	// Keep the digits the two have in common
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(rest(a, n), b[n:])
+		}
+	}
+
+	low, high := 0, base
+	if a != "" {
+		low = strings.IndexByte(digits, a[0])
+	}
+	if b != "" {
+		high = strings.IndexByte(digits, b[0])
+	}
+	if high-low > 1 {
+		return string(digits[(low+high+1)/2])
+	}
+	// The first digits are adjacent: b's first digit alone sorts between
+	// when b goes on, otherwise look further along a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[low]) + midpoint(rest(a, 1), "")
+}
+
+// digitAt is the nth digit of s, which is the lowest past its end
+func digitAt(s string, n int) byte {
+	if n < len(s) {
+		return s[n]
+	}
+	return digits[0]
+}
+
+func rest(s string, n int) string {
+	if n < len(s) {
+		return s[n:]
+	}
+	return ""
+}
+
+// Spread returns n ranks in ascending order, evenly spaced and as short as
+// leaves room for many moves between each pair
+func Spread(n int) []string {
+	width, slots := 1, base
+	// Leave at least a whole digit's worth of room on either side of each
+	for slots < (n+1)*base {
+		width++
+		slots *= base
+	}
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * slots / (n + 1)
+		rank := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			rank[j] = digits[value%base]
+			value /= base
+		}
+		ranks[i] = strings.TrimRight(string(rank), digits[:1])
+	}
+	return ranks
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CalendarFeedRepository interface {
+	Replace(ctx context.Context, userId string, tokenHash string) (*model.CalendarFeed, error)
+	FindByUser(ctx context.Context, userId string) (*model.CalendarFeed, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error)
+	Delete(ctx context.Context, userId string) error
+}
+
+type calendarFeedRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCalendarFeedRepository(db *mongo.Database, collectionName string) CalendarFeedRepository {
+	repo := &calendarFeedRepository{
+		collection: db.Collection(collectionName),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *calendarFeedRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create calendar feed indexes: %v", err)
+	}
+}
+
+// Replace gives the user a feed with a new token, so the old address stops
+// working
+func (r *calendarFeedRepository) Replace(ctx context.Context, userId string, tokenHash string) (*model.CalendarFeed, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	feed := &model.CalendarFeed{UserID: userObjectID, TokenHash: tokenHash, CreatedAt: time.Now()}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	if err := r.collection.FindOneAndReplace(ctx, bson.M{"userId": userObjectID}, feed, opts).Decode(feed); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+func (r *calendarFeedRepository) FindByUser(ctx context.Context, userId string) (*model.CalendarFeed, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+	return r.findOne(ctx, bson.M{"userId": userObjectID})
+}
+
+func (r *calendarFeedRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error) {
+	return r.findOne(ctx, bson.M{"tokenHash": tokenHash})
+}
+
+func (r *calendarFeedRepository) findOne(ctx context.Context, filter bson.M) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := r.collection.FindOne(ctx, filter).Decode(&feed); err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Delete revokes the user's feed
+func (r *calendarFeedRepository) Delete(ctx context.Context, userId string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.ErrInvalidID
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"userId": userObjectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.ErrCalendarFeedNotFound
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+	"todo-app/internal/errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pageCursor is the decoded form of the opaque cursor handed to clients.
+// It remembers the sort key and the position of the last item returned so
+// the next page can resume strictly after it.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+	// Set when the last item had no value for the sort key
+	Null bool `json:"n,omitempty"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token, sort, order string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	// A cursor is only meaningful for the ordering it was produced with
+	if c.Sort != sort || c.Order != order {
+		return nil, errors.ErrInvalidCursor
+	}
+	if _, err := primitive.ObjectIDFromHex(c.ID); err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// sortField is the field a sort option orders by
+func sortField(sort string) string {
+	if sort == "priority" {
+		return "priorityRank"
+	}
+	return sort
+}
+
+// value turns the cursor's sort key back into the type stored in the field
+func (c *pageCursor) value() (interface{}, error) {
+	switch c.Sort {
+	case "title", "position":
+		return c.Value, nil
+	case "priority":
+		n, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, errors.ErrInvalidCursor
+		}
+		return n, nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, errors.ErrInvalidCursor
+		}
+		return t, nil
+	}
+}
+
+// seekFilter builds the condition selecting documents that sort strictly
+// after the cursor position, using _id as the tie breaker. Documents
+// without the sort key sort ahead of all others, as MongoDB orders them.
+func (c *pageCursor) seekFilter() (bson.M, error) {
+	id, _ := primitive.ObjectIDFromHex(c.ID)
+	field := sortField(c.Sort)
+
+	op := "$gt"
+	if c.Order == "desc" {
+		op = "$lt"
+	}
+
+	if c.Null {
+		tied := bson.M{field: nil, "_id": bson.M{op: id}}
+		if c.Order == "desc" {
+			return tied, nil
+		}
+		return bson.M{"$or": bson.A{bson.M{field: bson.M{"$ne": nil}}, tied}}, nil
+	}
+
+	value, err := c.value()
+	if err != nil {
+		return nil, err
+	}
+	seek := bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: id}},
+	}
+	// Only some sort keys may be missing, and those sort last when descending
+	if c.Order == "desc" && (c.Sort == "position" || c.Sort == "priority") {
+		seek = append(seek, bson.M{field: nil})
+	}
+	return bson.M{"$or": seek}, nil
+}
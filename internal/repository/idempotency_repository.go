@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *model.IdempotencyRecord) error
+	Release(ctx context.Context, record *model.IdempotencyRecord) error
+}
+
+type idempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database, collectionName string) IdempotencyRepository {
+	repo := &idempotencyRepository{
+		collection: db.Collection(collectionName),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *idempotencyRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create idempotency indexes: %v", err)
+	}
+}
+
+// Reserve claims the user's key for a new request and returns nil. When the
+// key is already taken it returns the record holding it instead.
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	owner := bson.M{"userId": record.UserID, "key": record.Key}
+
+	// MongoDB only removes expired records once a minute, so clear the
+	// key if its time is up
+	expired := bson.M{"userId": record.UserID, "key": record.Key, "expiresAt": bson.M{"$lte": record.CreatedAt}}
+	if _, err := r.collection.DeleteOne(ctx, expired); err != nil {
+		return nil, err
+	}
+
+	result, err := r.collection.InsertOne(ctx, record)
+	if err == nil {
+		record.ID = result.InsertedID.(primitive.ObjectID)
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing model.IdempotencyRecord
+	if err := r.collection.FindOne(ctx, owner).Decode(&existing); err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Released by a failed request in the meantime
+			return nil, errors.ErrIdempotencyInProgress
+		}
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Complete stores the response to a reserved request
+func (r *idempotencyRepository) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": bson.M{
+		"completed": true,
+		"status":    record.Status,
+		"header":    record.Header,
+		"body":      record.Body,
+	}})
+	return err
+}
+
+// Release frees the key of a request that did not complete, so it can be
+// retried
+func (r *idempotencyRepository) Release(ctx context.Context, record *model.IdempotencyRecord) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": record.ID, "completed": false})
+	return err
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LabelRepository interface {
+	Create(ctx context.Context, userId string, label *model.LabelCreate) (*model.Label, error)
+	FindAll(ctx context.Context, userId string) ([]*model.Label, error)
+	FindByID(ctx context.Context, id string, userId string) (*model.Label, error)
+	FindByNames(ctx context.Context, userId string, names []string) ([]*model.Label, error)
+	Update(ctx context.Context, id string, userId string, update *model.LabelUpdate) (*model.Label, error)
+	Delete(ctx context.Context, id string, userId string) error
+	Merge(ctx context.Context, id string, userId string, targetID primitive.ObjectID) (*model.Label, error)
+}
+
+type labelRepository struct {
+	collection *mongo.Collection
+	todos      *mongo.Collection
+	changes    *changeSequence
+}
+
+// NewLabelRepository needs the todo collection as well, because deleting or
+// merging a label rewrites the todos that carry it.
+func NewLabelRepository(db *mongo.Database, collectionName, todoCollectionName string) LabelRepository {
+	repo := &labelRepository{
+		collection: db.Collection(collectionName),
+		todos:      db.Collection(todoCollectionName),
+		changes:    newChangeSequence(db.Collection(todoCollectionName)),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *labelRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "nameKey", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create label indexes: %v", err)
+	}
+}
+
+// labelKey normalizes a name for case-insensitive uniqueness and lookup
+func labelKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (r *labelRepository) Create(ctx context.Context, userId string, labelCreate *model.LabelCreate) (*model.Label, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	now := time.Now()
+	label := &model.Label{
+		UserID:    userObjectID,
+		Name:      strings.TrimSpace(labelCreate.Name),
+		NameKey:   labelKey(labelCreate.Name),
+		Color:     labelCreate.Color,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	result, err := r.collection.InsertOne(ctx, label)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrDuplicateLabel
+		}
+		return nil, err
+	}
+
+	label.ID = result.InsertedID.(primitive.ObjectID)
+	return label, nil
+}
+
+func (r *labelRepository) FindAll(ctx context.Context, userId string) ([]*model.Label, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	return r.find(ctx, bson.M{"userId": userObjectID})
+}
+
+func (r *labelRepository) FindByNames(ctx context.Context, userId string, names []string) ([]*model.Label, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = labelKey(name)
+	}
+	return r.find(ctx, bson.M{"userId": userObjectID, "nameKey": bson.M{"$in": keys}})
+}
+
+func (r *labelRepository) find(ctx context.Context, filter bson.M) ([]*model.Label, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"nameKey": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	labels := []*model.Label{}
+	if err := cursor.All(ctx, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r *labelRepository) FindByID(ctx context.Context, id string, userId string) (*model.Label, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var label model.Label
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}).Decode(&label)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrLabelNotFound
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+// Update renames or recolours a label. Todos reference labels by ID, so a
+// rename takes effect on every todo at once.
+func (r *labelRepository) Update(ctx context.Context, id string, userId string, labelUpdate *model.LabelUpdate) (*model.Label, error) {
+	label, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if labelUpdate.Name != "" {
+		set["name"] = strings.TrimSpace(labelUpdate.Name)
+		set["nameKey"] = labelKey(labelUpdate.Name)
+	}
+	if labelUpdate.Color != "" {
+		set["color"] = labelUpdate.Color
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": label.ID, "userId": label.UserID}, bson.M{"$set": set})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrDuplicateLabel
+		}
+		return nil, err
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// Delete removes a label and detaches it from every todo
+func (r *labelRepository) Delete(ctx context.Context, id string, userId string) error {
+	label, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+
+	return database.RunInTransaction(ctx, r.collection.Database(), func(ctx context.Context) error {
+		update, err := r.changes.stamp(ctx, label.UserID,
+			bson.M{"$pull": bson.M{"labels": label.ID}, "$set": bson.M{"updatedAt": time.Now()}, "$inc": incVersion})
+		if err != nil {
+			return err
+		}
+		_, err = r.todos.UpdateMany(ctx, bson.M{"userId": label.UserID, "labels": label.ID}, update)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.collection.DeleteOne(ctx, bson.M{"_id": label.ID, "userId": label.UserID})
+		return err
+	})
+}
+
+// Merge folds a label into targetID: every todo carrying it gets the target
+// instead, and the merged label is removed. Both steps share a transaction
+// when the deployment supports one; each todo is rewritten atomically either
+// way, so no todo ever holds both labels or neither.
+func (r *labelRepository) Merge(ctx context.Context, id string, userId string, targetID primitive.ObjectID) (*model.Label, error) {
+	source, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.FindByID(ctx, targetID.Hex(), userId)
+	if err != nil {
+		return nil, err
+	}
+	if source.ID == target.ID {
+		return nil, errors.ErrInvalidLabelMerge
+	}
+
+	err = database.RunInTransaction(ctx, r.collection.Database(), func(ctx context.Context) error {
+		changeSeq, err := r.changes.next(ctx, source.UserID)
+		if err != nil {
+			return err
+		}
+		swap := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"labels": bson.M{"$setUnion": bson.A{
+					bson.M{"$setDifference": bson.A{"$labels", bson.A{source.ID}}},
+					bson.A{target.ID},
+				}},
+				"updatedAt": "$$NOW",
+				"version":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+				"changeSeq": changeSeq,
+			}}},
+		}
+		_, err = r.todos.UpdateMany(ctx, bson.M{"userId": source.UserID, "labels": source.ID}, swap)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.collection.DeleteOne(ctx, bson.M{"_id": source.ID, "userId": source.UserID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
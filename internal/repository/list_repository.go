@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ListRepository interface {
+	Create(ctx context.Context, userId string, list *model.ListCreate) (*model.List, error)
+	EnsureInbox(ctx context.Context, userId string) (*model.List, error)
+	FindAll(ctx context.Context, userId string, includeArchived bool) ([]*model.List, error)
+	FindByID(ctx context.Context, id string, userId string) (*model.List, error)
+	Update(ctx context.Context, id string, userId string, update *model.ListUpdate) (*model.List, error)
+	Delete(ctx context.Context, id string, userId string, deleteTodos bool) error
+}
+
+type listRepository struct {
+	collection *mongo.Collection
+	todos      *mongo.Collection
+	changes    *changeSequence
+}
+
+// NewListRepository needs the todo collection as well, because deleting a
+// list moves or removes its todos.
+func NewListRepository(db *mongo.Database, collectionName, todoCollectionName string) ListRepository {
+	repo := &listRepository{
+		collection: db.Collection(collectionName),
+		todos:      db.Collection(todoCollectionName),
+		changes:    newChangeSequence(db.Collection(todoCollectionName)),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *listRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "sortOrder", Value: 1}}},
+		{
+			// At most one Inbox per user
+			Keys: bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().
+				SetName("unique_inbox").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"inbox": true}),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create list indexes: %v", err)
+	}
+}
+
+func (r *listRepository) Create(ctx context.Context, userId string, listCreate *model.ListCreate) (*model.List, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	now := time.Now()
+	list := &model.List{
+		UserID:    userObjectID,
+		Name:      listCreate.Name,
+		Icon:      listCreate.Icon,
+		SortOrder: listCreate.SortOrder,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	result, err := r.collection.InsertOne(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+
+	list.ID = result.InsertedID.(primitive.ObjectID)
+	return list, nil
+}
+
+// EnsureInbox returns the user's Inbox, creating it if needed. Users created
+// before lists existed get theirs on first use, and their todos move into it.
+func (r *listRepository) EnsureInbox(ctx context.Context, userId string) (*model.List, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var inbox model.List
+	filter := bson.M{"userId": userObjectID, "inbox": true}
+	err = r.collection.FindOne(ctx, filter).Decode(&inbox)
+	if err == nil {
+		return &inbox, nil
+	}
+	if !stderror.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": bson.M{
+		"name":      model.InboxListName,
+		"archived":  false,
+		"sortOrder": 0,
+		"createdAt": now,
+		"updatedAt": now,
+	}}, options.Update().SetUpsert(true))
+	// A concurrent request may have created it first
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	if err := r.collection.FindOne(ctx, filter).Decode(&inbox); err != nil {
+		return nil, err
+	}
+
+	if result != nil && result.UpsertedCount > 0 {
+		update, err := r.changes.stamp(ctx, userObjectID, bson.M{"$set": bson.M{"listId": inbox.ID}, "$inc": incVersion})
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.todos.UpdateMany(ctx, bson.M{"userId": userObjectID, "listId": bson.M{"$exists": false}}, update)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &inbox, nil
+}
+
+// FindAll returns the user's lists in their sort order, the Inbox first
+func (r *listRepository) FindAll(ctx context.Context, userId string, includeArchived bool) ([]*model.List, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	filter := bson.M{"userId": userObjectID}
+	if !includeArchived {
+		filter["archived"] = false
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "inbox", Value: -1},
+		{Key: "sortOrder", Value: 1},
+		{Key: "createdAt", Value: 1},
+	})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	lists := []*model.List{}
+	if err := cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *listRepository) FindByID(ctx context.Context, id string, userId string) (*model.List, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var list model.List
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}).Decode(&list)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrListNotFound
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *listRepository) Update(ctx context.Context, id string, userId string, listUpdate *model.ListUpdate) (*model.List, error) {
+	list, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if listUpdate.Name != "" {
+		set["name"] = listUpdate.Name
+	}
+	if listUpdate.Icon != "" {
+		set["icon"] = listUpdate.Icon
+	}
+	if listUpdate.Archived != nil {
+		if list.Inbox && *listUpdate.Archived {
+			return nil, errors.ErrInboxProtected
+		}
+		set["archived"] = *listUpdate.Archived
+	}
+	if listUpdate.SortOrder != nil {
+		set["sortOrder"] = *listUpdate.SortOrder
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": list.ID, "userId": list.UserID}, bson.M{"$set": set})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// Delete removes a list. Its todos move to the Inbox, and are also put in
+// the trash when deleteTodos is set, so restoring one later lands it in the
+// Inbox.
+func (r *listRepository) Delete(ctx context.Context, id string, userId string, deleteTodos bool) error {
+	list, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+	if list.Inbox {
+		return errors.ErrInboxProtected
+	}
+
+	inbox, err := r.EnsureInbox(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return database.RunInTransaction(ctx, r.collection.Database(), func(ctx context.Context) error {
+		now := time.Now()
+		changeSeq, err := r.changes.next(ctx, list.UserID)
+		if err != nil {
+			return err
+		}
+		filter := bson.M{"userId": list.UserID, "listId": list.ID}
+		if deleteTodos {
+			trashed := bson.M{"userId": list.UserID, "listId": list.ID, "deletedAt": nil}
+			update := bson.M{"$set": bson.M{"deletedAt": now, "changeSeq": changeSeq}, "$inc": incVersion}
+			if _, err := r.todos.UpdateMany(ctx, trashed, update); err != nil {
+				return err
+			}
+		}
+		update := bson.M{"$set": bson.M{"listId": inbox.ID, "updatedAt": now, "changeSeq": changeSeq}, "$inc": incVersion}
+		if _, err := r.todos.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+
+		_, err = r.collection.DeleteOne(ctx, bson.M{"_id": list.ID, "userId": list.UserID})
+		return err
+	})
+}
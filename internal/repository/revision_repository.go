@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevisionRepository interface {
+	Create(ctx context.Context, revision *model.Revision) (*model.Revision, error)
+	FindByTodo(ctx context.Context, todoId string, userId string, query *model.RevisionQuery) ([]*model.Revision, error)
+	FindOne(ctx context.Context, todoId string, userId string, number int) (*model.Revision, error)
+}
+
+type revisionRepository struct {
+	collection *mongo.Collection
+	todos      *mongo.Collection
+}
+
+// NewRevisionRepository needs the todo collection as well, because each todo
+// holds the counter its revisions are numbered from.
+func NewRevisionRepository(db *mongo.Database, collectionName, todoCollectionName string) RevisionRepository {
+	repo := &revisionRepository{
+		collection: db.Collection(collectionName),
+		todos:      db.Collection(todoCollectionName),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *revisionRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "todoId", Value: 1}, {Key: "revision", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create revision indexes: %v", err)
+	}
+}
+
+// Create stores a revision under the next number of its todo. The counter
+// is bumped on the todo itself, so concurrent changes never share a number.
+func (r *revisionRepository) Create(ctx context.Context, revision *model.Revision) (*model.Revision, error) {
+	var counter struct {
+		Revision int `bson:"revision"`
+	}
+	err := r.todos.FindOneAndUpdate(ctx,
+		bson.M{"_id": revision.TodoID, "userId": revision.UserID},
+		bson.M{"$inc": bson.M{"revision": 1}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"revision": 1}),
+	).Decode(&counter)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, stderror.New("todo not found")
+		}
+		return nil, err
+	}
+
+	revision.Revision = counter.Revision
+	revision.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(ctx, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.ID = result.InsertedID.(primitive.ObjectID)
+	return revision, nil
+}
+
+// FindByTodo returns the revisions of a todo, newest first. Revisions
+// outlive the todo, so the history of a deleted todo can still be read.
+func (r *revisionRepository) FindByTodo(ctx context.Context, todoId string, userId string, query *model.RevisionQuery) ([]*model.Revision, error) {
+	todoObjectID, userObjectID, err := revisionOwner(todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = model.DefaultTodoPageLimit
+	}
+
+	filter := bson.M{"todoId": todoObjectID, "userId": userObjectID}
+	if query.Before > 0 {
+		filter["revision"] = bson.M{"$lt": query.Before}
+	}
+	opts := options.Find().SetSort(bson.M{"revision": -1}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*model.Revision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepository) FindOne(ctx context.Context, todoId string, userId string, number int) (*model.Revision, error) {
+	todoObjectID, userObjectID, err := revisionOwner(todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision model.Revision
+	filter := bson.M{"todoId": todoObjectID, "userId": userObjectID, "revision": number}
+	if err := r.collection.FindOne(ctx, filter).Decode(&revision); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func revisionOwner(todoId string, userId string) (primitive.ObjectID, primitive.ObjectID, error) {
+	todoObjectID, err := primitive.ObjectIDFromHex(todoId)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, stderror.New("invalid user id format")
+	}
+	return todoObjectID, userObjectID, nil
+}
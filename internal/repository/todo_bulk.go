@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindIDs returns the IDs of up to limit todos matching filter, oldest first
+func (r *todoRepository) FindIDs(ctx context.Context, userId string, filter *model.TodoBulkFilter, limit int) ([]primitive.ObjectID, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.New("invalid user id format")
+	}
+
+	query := live(bson.M{"userId": userObjectID})
+	if filter.Completed != nil {
+		query["completed"] = *filter.Completed
+	}
+	createdAt := bson.M{}
+	if filter.CreatedAfter != nil {
+		createdAt["$gt"] = *filter.CreatedAfter
+	}
+	if filter.CreatedBefore != nil {
+		createdAt["$lt"] = *filter.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+	if filter.UpdatedBefore != nil {
+		query["updatedAt"] = bson.M{"$lt": *filter.UpdatedBefore}
+	}
+	if filter.ListID != nil {
+		query["listId"] = *filter.ListID
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+// SupportsTransactions reports whether RunInTransaction makes its work atomic
+func (r *todoRepository) SupportsTransactions(ctx context.Context) bool {
+	return database.SupportsTransactions(ctx, r.collection.Database())
+}
+
+// RunInTransaction runs fn in one transaction when the deployment supports
+// it, and directly otherwise
+func (r *todoRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTransaction(ctx, r.collection.Database(), fn)
+}
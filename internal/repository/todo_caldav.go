@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ForEachInList is ForEach for the todos of one list, subtasks included
+func (r *todoRepository) ForEachInList(ctx context.Context, userId string, listId string, fn func(todo *model.Todo) error) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user id format")
+	}
+	listObjectID, err := primitive.ObjectIDFromHex(listId)
+	if err != nil {
+		return errors.New("invalid list id format")
+	}
+	return r.forEach(ctx, bson.M{"userId": userObjectID, "listId": listObjectID}, fn)
+}
+
+// FindByDAVName finds the live todo a CalDAV client created under name
+func (r *todoRepository) FindByDAVName(ctx context.Context, userId string, name string) (*model.Todo, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.New("invalid user id format")
+	}
+
+	var todo model.Todo
+	err = r.collection.FindOne(ctx, live(bson.M{"userId": userObjectID, "davName": name})).Decode(&todo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("todo not found")
+		}
+		return nil, err
+	}
+	return &todo, nil
+}
@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lineage describes where a todo sits in its tree
+type lineage struct {
+	ID          primitive.ObjectID  `bson:"_id"`
+	ListID      *primitive.ObjectID `bson:"listId"`
+	Ancestors   []treeNode          `bson:"ancestors"`
+	Descendants []treeNode          `bson:"descendants"`
+}
+
+type treeNode struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Depth int                `bson:"depth"`
+}
+
+// depth is the level of the todo, 1 for a top-level todo
+func (l *lineage) depth() int {
+	return len(l.Ancestors) + 1
+}
+
+// height is the number of levels in the todo's subtree, including itself
+func (l *lineage) height() int {
+	height := 1
+	for _, node := range l.Descendants {
+		// depth 0 marks direct children
+		if node.Depth+2 > height {
+			height = node.Depth + 2
+		}
+	}
+	return height
+}
+
+// subtree returns the IDs of the todo and all of its descendants
+func (l *lineage) subtree() []primitive.ObjectID {
+	ids := []primitive.ObjectID{l.ID}
+	for _, node := range l.Descendants {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func (l *lineage) hasAncestor(id primitive.ObjectID) bool {
+	for _, node := range l.Ancestors {
+		if node.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// findLineage loads the ancestors and descendants of a todo in one query.
+// It returns nil when the todo does not exist for the user.
+func (r *todoRepository) findLineage(ctx context.Context, id, userID primitive.ObjectID) (*lineage, error) {
+	return r.findLineageAt(ctx, id, userID, nil)
+}
+
+// findLineageAt is findLineage among the todos moved to the trash at
+// deletedAt, or among live todos when deletedAt is nil
+func (r *todoRepository) findLineageAt(ctx context.Context, id, userID primitive.ObjectID, deletedAt *time.Time) (*lineage, error) {
+	owned := bson.M{"userId": userID, "deletedAt": deletedAt}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": id, "userId": userID, "deletedAt": deletedAt}}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    r.collection.Name(),
+			"startWith":               "$parentId",
+			"connectFromField":        "parentId",
+			"connectToField":          "_id",
+			"as":                      "ancestors",
+			"maxDepth":                model.MaxTodoDepth,
+			"depthField":              "depth",
+			"restrictSearchWithMatch": owned,
+		}}},
+		{{Key: "$graphLookup", Value: bson.M{
+			"from":                    r.collection.Name(),
+			"startWith":               "$_id",
+			"connectFromField":        "_id",
+			"connectToField":          "parentId",
+			"as":                      "descendants",
+			"depthField":              "depth",
+			"restrictSearchWithMatch": owned,
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"listId":            1,
+			"ancestors._id":     1,
+			"ancestors.depth":   1,
+			"descendants._id":   1,
+			"descendants.depth": 1,
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		return nil, cursor.Err()
+	}
+	var result lineage
+	if err := cursor.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// checkParent verifies that a subtree of the given height may be placed
+// under parentID without exceeding the nesting cap.
+func (r *todoRepository) checkParent(ctx context.Context, parentID, userID primitive.ObjectID, height int) (*lineage, error) {
+	parent, err := r.findLineage(ctx, parentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, errors.ErrParentNotFound
+	}
+	if parent.depth()+height > model.MaxTodoDepth {
+		return nil, errors.ErrMaxDepthExceeded
+	}
+	return parent, nil
+}
+
+func (r *todoRepository) FindChildren(ctx context.Context, id string, userId string) ([]*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	if _, err := r.FindByID(ctx, id, userId); err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, live(bson.M{"parentId": objectID, "userId": userObjectID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	todos := []*model.Todo{}
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// FindSubtree returns a todo followed by all of its descendants
+func (r *todoRepository) FindSubtree(ctx context.Context, id string, userId string) ([]*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	todo, err := r.findLineage(ctx, objectID, userObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil {
+		return nil, stderror.New("todo not found")
+	}
+
+	cursor, err := r.collection.Find(ctx, live(bson.M{"_id": bson.M{"$in": todo.subtree()}, "userId": userObjectID}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	todos := []*model.Todo{}
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	// Put the root first
+	for i, found := range todos {
+		if found.ID == objectID {
+			todos[0], todos[i] = todos[i], todos[0]
+		}
+	}
+	return todos, nil
+}
+
+// Move re-parents a todo together with its subtree. A nil parent makes it a
+// top-level todo. Moves that would create a cycle or nest deeper than
+// model.MaxTodoDepth are rejected.
+func (r *todoRepository) Move(ctx context.Context, id string, userId string, parentID *primitive.ObjectID) (*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	todo, err := r.findLineage(ctx, objectID, userObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil {
+		return nil, stderror.New("todo not found")
+	}
+
+	update := bson.M{"$set": bson.M{"updatedAt": time.Now()}, "$inc": incVersion}
+	if parentID == nil {
+		update["$unset"] = bson.M{"parentId": ""}
+	} else {
+		if *parentID == objectID {
+			return nil, errors.ErrTodoCycle
+		}
+		parent, err := r.checkParent(ctx, *parentID, userObjectID, todo.height())
+		if err != nil {
+			return nil, err
+		}
+		if parent.hasAncestor(objectID) {
+			return nil, errors.ErrTodoCycle
+		}
+		update["$set"].(bson.M)["parentId"] = *parentID
+
+		// The subtree follows its new parent into the parent's list
+		if err := r.setSubtreeList(ctx, todo, userObjectID, parent.ListID); err != nil {
+			return nil, err
+		}
+	}
+	if update, err = r.changes.stamp(ctx, userObjectID, update); err != nil {
+		return nil, err
+	}
+
+	result, err := r.collection.UpdateOne(ctx, live(bson.M{"_id": objectID, "userId": userObjectID}), update)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, stderror.New("todo not found")
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// MoveToList moves a todo and its subtasks to another list. A subtask leaves
+// its parent, which stays behind, and becomes a top-level todo of the list.
+func (r *todoRepository) MoveToList(ctx context.Context, id string, userId string, listID primitive.ObjectID) (*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	todo, err := r.findLineage(ctx, objectID, userObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil {
+		return nil, stderror.New("todo not found")
+	}
+
+	if err := r.setSubtreeList(ctx, todo, userObjectID, &listID); err != nil {
+		return nil, err
+	}
+	if len(todo.Ancestors) > 0 {
+		update, err := r.changes.stamp(ctx, userObjectID, bson.M{"$unset": bson.M{"parentId": ""}, "$inc": incVersion})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}, update); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// setSubtreeList assigns a todo and all of its descendants to listID
+func (r *todoRepository) setSubtreeList(ctx context.Context, todo *lineage, userID primitive.ObjectID, listID *primitive.ObjectID) error {
+
+	update := bson.M{"$set": bson.M{"updatedAt": time.Now()}, "$inc": incVersion}
+	if listID == nil {
+		update["$unset"] = bson.M{"listId": ""}
+	} else {
+		update["$set"].(bson.M)["listId"] = *listID
+	}
+	update, err := r.changes.stamp(ctx, userID, update)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": todo.subtree()}, "userId": userID}, update)
+	return err
+}
+
+// ChildProgress returns, for each of the given todos that has children, the
+// percentage of its direct children that are completed.
+func (r *todoRepository) ChildProgress(ctx context.Context, userId string, ids []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	progress := make(map[primitive.ObjectID]int)
+	if len(ids) == 0 {
+		return progress, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: live(bson.M{"userId": userObjectID, "parentId": bson.M{"$in": ids}})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$parentId",
+			"total":     bson.M{"$sum": 1},
+			"completed": bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 1, 0}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		Total     int                `bson:"total"`
+		Completed int                `bson:"completed"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		progress[group.ID] = group.Completed * 100 / group.Total
+	}
+	return progress, nil
+}
+
+// deleteSubtree moves a todo to the trash and, depending on deleteChildren,
+// either its whole subtree with it or just the todo itself with its children
+// moved to the top level. A trashed subtree shares one deletedAt, which is
+// how it is restored or purged together.
+func (r *todoRepository) deleteSubtree(ctx context.Context, id, userID primitive.ObjectID, deleteChildren bool, expected model.Precondition) error {
+	var todo *lineage
+	if deleteChildren {
+		var err error
+		if todo, err = r.findLineage(ctx, id, userID); err != nil {
+			return err
+		}
+		if todo == nil {
+			return stderror.New("todo not found")
+		}
+	}
+
+	// The todo itself goes first, so a failed precondition changes nothing
+	now := time.Now()
+	changeSeq, err := r.changes.next(ctx, userID)
+	if err != nil {
+		return err
+	}
+	trash := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now, "changeSeq": changeSeq}, "$inc": incVersion}
+	result, err := r.collection.UpdateOne(ctx, expectVersion(live(bson.M{"_id": id, "userId": userID}), expected), trash)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return r.missed(ctx, id, userID, expected)
+	}
+
+	if deleteChildren {
+		_, err = r.collection.UpdateMany(ctx, live(bson.M{"_id": bson.M{"$in": todo.subtree()}, "userId": userID}), trash)
+		return err
+	}
+	_, err = r.collection.UpdateMany(ctx,
+		live(bson.M{"parentId": id, "userId": userID}),
+		bson.M{"$unset": bson.M{"parentId": ""}, "$set": bson.M{"updatedAt": now, "changeSeq": changeSeq}, "$inc": incVersion})
+	return err
+}
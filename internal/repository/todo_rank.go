@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"fmt"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/rank"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errNoRoom means two neighbouring positions leave nothing between them,
+// because they are equal or one of the todos has none yet
+var errNoRoom = stderror.New("no room between positions")
+
+// positioned is the part of a todo its place in the manual order needs
+type positioned struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Position string             `bson:"position"`
+}
+
+// lastPosition is the highest position among a user's todos. Trashed todos
+// count too, so they find their place again when restored.
+func (r *todoRepository) lastPosition(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	var last positioned
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "position", Value: -1}}).
+		SetProjection(bson.M{"position": 1})
+	err := r.collection.FindOne(ctx, bson.M{"userId": userID, "position": bson.M{"$ne": nil}}, opts).Decode(&last)
+	if stderror.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return last.Position, err
+}
+
+// Reorder moves a todo in its user's manual order, writing a new position
+// to that todo alone. When its new neighbours leave no room between them
+// the user's positions are rebalanced first.
+func (r *todoRepository) Reorder(ctx context.Context, id string, userId string, reorder *model.TodoReorder) (*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	position, err := r.positionFor(ctx, objectID, userObjectID, reorder)
+	if stderror.Is(err, errNoRoom) {
+		if _, err = r.rebalance(ctx, userObjectID); err != nil {
+			return nil, err
+		}
+		position, err = r.positionFor(ctx, objectID, userObjectID, reorder)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"position": position, "updatedAt": time.Now()}, "$inc": incVersion}
+	if update, err = r.changes.stamp(ctx, userObjectID, update); err != nil {
+		return nil, err
+	}
+	result, err := r.collection.UpdateOne(ctx, live(bson.M{"_id": objectID, "userId": userObjectID}), update)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil, stderror.New("todo not found")
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// positionFor finds a position for a todo between the todos a reorder
+// names. A side left out is taken from the todo next to the other one,
+// leaving out the todo being moved.
+func (r *todoRepository) positionFor(ctx context.Context, id, userID primitive.ObjectID, reorder *model.TodoReorder) (string, error) {
+	var after, before string
+	if reorder.After != nil {
+		anchor, err := r.findPositioned(ctx, *reorder.After, userID)
+		if err != nil {
+			return "", err
+		}
+		after = anchor.Position
+	}
+	if reorder.Before != nil {
+		anchor, err := r.findPositioned(ctx, *reorder.Before, userID)
+		if err != nil {
+			return "", err
+		}
+		before = anchor.Position
+	}
+	if (reorder.After != nil && after == "") || (reorder.Before != nil && before == "") {
+		return "", errNoRoom
+	}
+
+	var err error
+	if reorder.After == nil {
+		after, err = r.neighbour(ctx, id, userID, before, "$lt")
+	} else if reorder.Before == nil {
+		before, err = r.neighbour(ctx, id, userID, after, "$gt")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if before != "" && after >= before {
+		if after == before {
+			return "", errNoRoom
+		}
+		return "", errors.ErrInvalidReorder
+	}
+	return rank.Between(after, before)
+}
+
+// findPositioned loads the position of a live todo of a user
+func (r *todoRepository) findPositioned(ctx context.Context, id, userID primitive.ObjectID) (*positioned, error) {
+	var todo positioned
+	opts := options.FindOne().SetProjection(bson.M{"position": 1})
+	err := r.collection.FindOne(ctx, live(bson.M{"_id": id, "userId": userID}), opts).Decode(&todo)
+	if stderror.Is(err, mongo.ErrNoDocuments) {
+		return nil, stderror.New("todo not found")
+	}
+	return &todo, err
+}
+
+// neighbour is the closest position to from on the side op selects, among
+// all of a user's todos but the one being moved, or empty when there is
+// none. Trashed todos keep their place, so they are not skipped.
+func (r *todoRepository) neighbour(ctx context.Context, id, userID primitive.ObjectID, from string, op string) (string, error) {
+	direction := 1
+	if op == "$lt" {
+		direction = -1
+	}
+
+	var next positioned
+	filter := bson.M{"userId": userID, "_id": bson.M{"$ne": id}, "position": bson.M{op: from}}
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "position", Value: direction}}).
+		SetProjection(bson.M{"position": 1})
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&next)
+	if stderror.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return next.Position, err
+}
+
+// Rebalance hands out short, evenly spaced positions to all of a user's
+// todos, keeping their order, and reports how many it changed
+func (r *todoRepository) Rebalance(ctx context.Context, userId string) (int, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return 0, stderror.New("invalid user id format")
+	}
+	return r.rebalance(ctx, userObjectID)
+}
+
+// rebalance covers trashed todos too, so a restored todo is back in its
+// place. Todos stored before they had positions come first, oldest first.
+// The todos it changes move to a new version, as clients see the positions.
+func (r *todoRepository) rebalance(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "position", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"position": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return 0, err
+	}
+	var todos []positioned
+	if err := cursor.All(ctx, &todos); err != nil {
+		return 0, err
+	}
+
+	positions := rank.Spread(len(todos))
+	var moved []int
+	for i, todo := range todos {
+		if todo.Position != positions[i] {
+			moved = append(moved, i)
+		}
+	}
+	if len(moved) == 0 {
+		return 0, nil
+	}
+
+	// All of them are one change to the user's todos
+	seq, err := r.changes.next(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	models := make([]mongo.WriteModel, len(moved))
+	for i, at := range moved {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": todos[at].ID}).
+			SetUpdate(bson.M{"$set": bson.M{"position": positions[at], "changeSeq": seq}, "$inc": incVersion})
+	}
+	if _, err := r.collection.BulkWrite(ctx, models); err != nil {
+		return 0, err
+	}
+	return len(models), nil
+}
+
+// FindUnbalanced returns the users with todos that have no position or one
+// longer than maxLength, whose positions need to be rebalanced
+func (r *todoRepository) FindUnbalanced(ctx context.Context, maxLength int) ([]primitive.ObjectID, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"position": nil},
+		bson.M{"position": bson.M{"$regex": fmt.Sprintf("^.{%d}", maxLength+1)}},
+	}}
+	values, err := r.collection.Distinct(ctx, "userId", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			users = append(users, id)
+		}
+	}
+	return users, nil
+}
@@ -3,30 +3,129 @@ package repository
 import (
 	"context"
 	"errors"
+	"log"
+	"strconv"
 	"time"
 	"todo-app/internal/model"
+	"todo-app/internal/rank"
+	"todo-app/internal/search"
+	"todo-app/internal/stats"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TodoRepository interface {
 	Create(ctx context.Context, todo *model.TodoCreate) (*model.Todo, error)
 	FindByID(ctx context.Context, id string, userId string) (*model.Todo, error)
-	FindAll(ctx context.Context, userId string) ([]*model.Todo, error)
+	Query(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoPage, error)
+	Search(ctx context.Context, userId string, query *model.TodoSearchQuery) ([]*model.TodoSearchResult, error)
 	Update(ctx context.Context, id string, userId string, todo *model.TodoUpdate) (*model.Todo, error)
-	Delete(ctx context.Context, id string, userId string) error
+	Delete(ctx context.Context, id string, userId string, deleteChildren bool, expected model.Precondition) error
+	FindChildren(ctx context.Context, id string, userId string) ([]*model.Todo, error)
+	FindSubtree(ctx context.Context, id string, userId string) ([]*model.Todo, error)
+	Move(ctx context.Context, id string, userId string, parentID *primitive.ObjectID) (*model.Todo, error)
+	ChildProgress(ctx context.Context, userId string, ids []primitive.ObjectID) (map[primitive.ObjectID]int, error)
+	MoveToList(ctx context.Context, id string, userId string, listID primitive.ObjectID) (*model.Todo, error)
+	SettleStatuses(ctx context.Context, userId string, listID *primitive.ObjectID, workflow *model.Workflow) error
+	AddLabel(ctx context.Context, id string, userId string, labelID primitive.ObjectID) (*model.Todo, error)
+	RemoveLabel(ctx context.Context, id string, userId string, labelID primitive.ObjectID) (*model.Todo, error)
+	SetLabels(ctx context.Context, id string, userId string, labelIDs []primitive.ObjectID) (*model.Todo, error)
+	ClaimNextOccurrence(ctx context.Context, id string, userId string, nextID primitive.ObjectID) (bool, error)
+	FindDueReminders(ctx context.Context, now time.Time, limit int) ([]*model.Todo, error)
+	ClaimReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, sentAt time.Time) (bool, error)
+	RetryReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, attempts int, nextAttemptAt time.Time) error
+	FailReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, attempts int, failedAt time.Time) error
+	Reorder(ctx context.Context, id string, userId string, reorder *model.TodoReorder) (*model.Todo, error)
+	Rebalance(ctx context.Context, userId string) (int, error)
+	FindUnbalanced(ctx context.Context, maxLength int) ([]primitive.ObjectID, error)
+	FindDeleted(ctx context.Context, userId string, query *model.TrashQuery) (*model.TodoPage, error)
+	Restore(ctx context.Context, id string, userId string) (*model.Todo, error)
+	Purge(ctx context.Context, id string, userId string) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	FindIDs(ctx context.Context, userId string, filter *model.TodoBulkFilter, limit int) ([]primitive.ObjectID, error)
+	ListVersion(ctx context.Context, userId string) (*model.TodoListVersion, error)
+	ForEach(ctx context.Context, userId string, fn func(todo *model.Todo) error) error
+	ForEachInList(ctx context.Context, userId string, listId string, fn func(todo *model.Todo) error) error
+	FindByDAVName(ctx context.Context, userId string, name string) (*model.Todo, error)
+	Changes(ctx context.Context, userId string, since string, limit int) (*model.SyncChanges, error)
+	Stats(ctx context.Context, userId string, r stats.Range) (*stats.Totals, error)
+	SupportsTransactions(ctx context.Context) bool
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type todoRepository struct {
 	collection *mongo.Collection
+	changes    *changeSequence
+	tombstones *mongo.Collection
 }
 
+// NewTodoRepository keeps the change counters and the tombstones of purged
+// todos for sync in collections named after the todo collection
 func NewTodoRepository(db *mongo.Database, collectionName string) TodoRepository {
-	return &todoRepository{
-		collection: db.Collection(collectionName),
+	collection := db.Collection(collectionName)
+	repo := &todoRepository{
+		collection: collection,
+		changes:    newChangeSequence(collection),
+		tombstones: db.Collection(collectionName + "_tombstones"),
 	}
+	repo.ensureIndexes()
+	return repo
+}
+
+// ensureIndexes creates the indexes backing the list queries. Failures are
+// logged rather than fatal so the API keeps working, only slower.
+func (r *todoRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "position", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "priorityRank", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "reminders.remindAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "parentId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "labels", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "listId", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "davName", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"davName": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "changeSeq", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "deletedAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("todo_text").
+				SetWeights(bson.D{{Key: "title", Value: 3}, {Key: "description", Value: 1}}).
+				SetDefaultLanguage("none"),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create todo indexes: %v", err)
+	}
+
+	tombstones := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "changeSeq", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "purgedAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(TombstoneRetention.Seconds()))},
+	}
+	if _, err := r.tombstones.Indexes().CreateMany(ctx, tombstones); err != nil {
+		log.Printf("Failed to create tombstone indexes: %v", err)
+	}
+}
+
+// live restricts a filter to todos that are not in the trash
+func live(filter bson.M) bson.M {
+	filter["deletedAt"] = nil
+	return filter
 }
 
 func (r *todoRepository) Create(ctx context.Context, todoCreate *model.TodoCreate) (*model.Todo, error) {
@@ -35,19 +134,60 @@ func (r *todoRepository) Create(ctx context.Context, todoCreate *model.TodoCreat
 		return nil, errors.New("user ID not found in context or invalid format")
 	}
 
+	// Subtasks always live in their parent's list
+	listID := todoCreate.ListID
+	if todoCreate.ParentID != nil {
+		parent, err := r.checkParent(ctx, *todoCreate.ParentID, userID, 1)
+		if err != nil {
+			return nil, err
+		}
+		listID = parent.ListID
+	}
+
+	changeSeq, err := r.changes.next(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// New todos go to the end of the user's manual order
+	last, err := r.lastPosition(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 
 	completed := false
-	if todoCreate.Completed != nil {
-		completed = *todoCreate.Completed
+	var completedAt *time.Time
+	if todoCreate.Completed != nil && *todoCreate.Completed {
+		completed, completedAt = true, &now
 	}
 
 	todo := &model.Todo{
-		Title:     todoCreate.Title,
-		Completed: completed,
-		CreatedAt: now,
-		UpdatedAt: now,
-		UserID:    userID,
+		ID:              todoCreate.ID,
+		Title:           todoCreate.Title,
+		Description:     todoCreate.Description,
+		Completed:       completed,
+		Status:          todoCreate.Status,
+		CompletedAt:     completedAt,
+		Priority:        todoCreate.Priority,
+		PriorityRank:    model.PriorityRank(todoCreate.Priority),
+		Position:        rank.After(last),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		UserID:          userID,
+		DueAt:           todoCreate.DueAt,
+		TimeZone:        todoCreate.TimeZone,
+		Reminders:       todoCreate.Reminders,
+		Recurrence:      todoCreate.Recurrence,
+		RecurrenceStart: todoCreate.RecurrenceStart,
+		ParentID:        todoCreate.ParentID,
+		Labels:          todoCreate.Labels,
+		ListID:          listID,
+		Version:         1,
+		ChangeSeq:       changeSeq,
+		ICalUID:         todoCreate.ICalUID,
+		DAVName:         todoCreate.DAVName,
 	}
 
 	result, err := r.collection.InsertOne(ctx, todo)
@@ -59,24 +199,203 @@ func (r *todoRepository) Create(ctx context.Context, todoCreate *model.TodoCreat
 	return todo, nil
 }
 
-func (r *todoRepository) FindAll(ctx context.Context, userId string) ([]*model.Todo, error) {
+func (r *todoRepository) Query(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoPage, error) {
 	userObjectID, err := primitive.ObjectIDFromHex(userId)
 	if err != nil {
 		return nil, errors.New("invalid user id format")
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{"userId": userObjectID})
+	query.Normalize()
+
+	filter := live(bson.M{"userId": userObjectID})
+	if query.Completed != nil {
+		filter["completed"] = *query.Completed
+	}
+
+	createdAt := bson.M{}
+	if query.CreatedAfter != nil {
+		createdAt["$gt"] = *query.CreatedAfter
+	}
+	if query.CreatedBefore != nil {
+		createdAt["$lt"] = *query.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+	if query.ListID != nil {
+		filter["listId"] = *query.ListID
+	}
+	if len(query.LabelIDs) > 0 {
+		if query.LabelMatch == "any" {
+			filter["labels"] = bson.M{"$in": query.LabelIDs}
+		} else {
+			filter["labels"] = bson.M{"$all": query.LabelIDs}
+		}
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, query.Sort, query.Order)
+		if err != nil {
+			return nil, err
+		}
+		seek, err := cursor.seekFilter()
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, seek}}
+	}
+
+	direction := 1
+	if query.Order == "desc" {
+		direction = -1
+	}
+
+	// Fetch one extra document to find out whether another page follows
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField(query.Sort), Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var todos []*model.Todo
+	todos := make([]*model.Todo, 0, query.Limit+1)
 	if err := cursor.All(ctx, &todos); err != nil {
 		return nil, err
 	}
 
-	return todos, nil
+	page := &model.TodoPage{Items: todos}
+	if len(todos) > query.Limit {
+		page.Items = todos[:query.Limit]
+		last := page.Items[len(page.Items)-1]
+		value, null := sortValue(last, query.Sort)
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:  query.Sort,
+			Order: query.Order,
+			Value: value,
+			ID:    last.ID.Hex(),
+			Null:  null,
+		})
+	}
+
+	return page, nil
+}
+
+// sortValue is a todo's sort key as stored in a cursor. It reports true
+// instead when the todo has no value for the key.
+func sortValue(todo *model.Todo, sort string) (string, bool) {
+	switch sort {
+	case "updatedAt":
+		return todo.UpdatedAt.UTC().Format(time.RFC3339Nano), false
+	case "title":
+		return todo.Title, false
+	case "position":
+		return todo.Position, todo.Position == ""
+	case "priority":
+		return strconv.Itoa(todo.PriorityRank), todo.PriorityRank == 0
+	case "deletedAt":
+		return todo.DeletedAt.UTC().Format(time.RFC3339Nano), false
+	default:
+		return todo.CreatedAt.UTC().Format(time.RFC3339Nano), false
+	}
+}
+
+// searchDocument decodes a todo together with its text search score
+type searchDocument struct {
+	model.Todo `bson:",inline"`
+	Score      float64 `bson:"score"`
+}
+
+func (r *todoRepository) Search(ctx context.Context, userId string, query *model.TodoSearchQuery) ([]*model.TodoSearchResult, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.New("invalid user id format")
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = model.DefaultTodoPageLimit
+	}
+
+	filter := live(bson.M{"userId": userObjectID, "$text": bson.M{"$search": query.Q}})
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(int64(limit))
+
+	var docs []searchDocument
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err == nil {
+		defer cursor.Close(ctx)
+		err = cursor.All(ctx, &docs)
+	}
+	if err != nil {
+		var serverErr mongo.ServerError
+		if !errors.As(err, &serverErr) || !serverErr.HasErrorCode(indexNotFoundCode) {
+			return nil, err
+		}
+		// Without a text index, rank the user's todos in process instead
+		log.Printf("Text index unavailable, falling back to in-process search: %v", err)
+		return r.searchInProcess(ctx, userObjectID, query.Q, limit)
+	}
+
+	results := make([]*model.TodoSearchResult, 0, len(docs))
+	for i := range docs {
+		results = append(results, newSearchResult(&docs[i], query.Q))
+	}
+	return results, nil
+}
+
+// indexNotFoundCode is returned by the server when $text has no index to use
+const indexNotFoundCode = 27
+
+func (r *todoRepository) searchInProcess(ctx context.Context, userID primitive.ObjectID, q string, limit int) ([]*model.TodoSearchResult, error) {
+	cursor, err := r.collection.Find(ctx, live(bson.M{"userId": userID}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []searchDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	searchable := make([]search.Document, len(docs))
+	for i, doc := range docs {
+		searchable[i] = search.Document{Title: doc.Title, Description: doc.Description}
+	}
+
+	matches := search.Rank(q, searchable)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]*model.TodoSearchResult, 0, len(matches))
+	for _, match := range matches {
+		doc := &docs[match.Index]
+		doc.Score = match.Score
+		results = append(results, newSearchResult(doc, q))
+	}
+	return results, nil
+}
+
+func newSearchResult(doc *searchDocument, q string) *model.TodoSearchResult {
+	highlights := map[string]string{
+		"title": search.Highlight(doc.Title, q, search.DefaultSnippetLength),
+	}
+	if doc.Description != "" {
+		highlights["description"] = search.Highlight(doc.Description, q, search.DefaultSnippetLength)
+	}
+
+	todo := doc.Todo
+	return &model.TodoSearchResult{
+		Todo:       &todo,
+		Score:      doc.Score,
+		Highlights: highlights,
+	}
 }
 
 func (r *todoRepository) FindByID(ctx context.Context, id string, userId string) (*model.Todo, error) {
@@ -91,7 +410,7 @@ func (r *todoRepository) FindByID(ctx context.Context, id string, userId string)
 	}
 
 	var todo model.Todo
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}).Decode(&todo)
+	err = r.collection.FindOne(ctx, live(bson.M{"_id": objectID, "userId": userObjectID})).Decode(&todo)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("todo not found")
@@ -102,6 +421,38 @@ func (r *todoRepository) FindByID(ctx context.Context, id string, userId string)
 	return &todo, nil
 }
 
+// ForEach calls fn with every live todo of a user, oldest first, without
+// loading them all at once. It stops at the first error fn returns.
+func (r *todoRepository) ForEach(ctx context.Context, userId string, fn func(todo *model.Todo) error) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user id format")
+	}
+	return r.forEach(ctx, bson.M{"userId": userObjectID}, fn)
+}
+
+func (r *todoRepository) forEach(ctx context.Context, filter bson.M, fn func(todo *model.Todo) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, live(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var todo model.Todo
+		if err := cursor.Decode(&todo); err != nil {
+			return err
+		}
+		if err := fn(&todo); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Update replaces the editable fields of a todo. Empty optional fields are
+// removed from the document rather than stored as zero values.
 func (r *todoRepository) Update(ctx context.Context, id string, userId string, updateData *model.TodoUpdate) (*model.Todo, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -113,22 +464,52 @@ func (r *todoRepository) Update(ctx context.Context, id string, userId string, u
 		return nil, errors.New("invalid user id format")
 	}
 
-	updateData.UpdatedAt = time.Now()
-	update := bson.M{"$set": updateData}
+	set := bson.M{
+		"title":     updateData.Title,
+		"completed": updateData.Completed,
+		"updatedAt": time.Now(),
+	}
+	unset := bson.M{}
+	optional := func(field string, value interface{}, empty bool) {
+		if empty {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	optional("description", updateData.Description, updateData.Description == "")
+	optional("status", updateData.Status, updateData.Status == "")
+	optional("priority", updateData.Priority, updateData.Priority == "")
+	optional("priorityRank", model.PriorityRank(updateData.Priority), updateData.Priority == "")
+	optional("dueAt", updateData.DueAt, updateData.DueAt == nil)
+	optional("timeZone", updateData.TimeZone, updateData.TimeZone == "")
+	optional("reminders", updateData.Reminders, len(updateData.Reminders) == 0)
+	optional("recurrence", updateData.Recurrence, updateData.Recurrence == "")
+	optional("recurrenceStart", updateData.RecurrenceStart, updateData.RecurrenceStart == nil)
+	optional("completedAt", updateData.CompletedAt, updateData.CompletedAt == nil)
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}, update)
+	update := bson.M{"$set": set, "$inc": incVersion}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if update, err = r.changes.stamp(ctx, userObjectID, update); err != nil {
+		return nil, err
+	}
+
+	filter := expectVersion(live(bson.M{"_id": objectID, "userId": userObjectID}), updateData.IfMatch)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return nil, err
 	}
 
 	if result.MatchedCount == 0 {
-		return nil, errors.New("todo not found")
+		return nil, r.missed(ctx, objectID, userObjectID, updateData.IfMatch)
 	}
 
 	return r.FindByID(ctx, id, userId)
 }
 
-func (r *todoRepository) Delete(ctx context.Context, id string, userId string) error {
+func (r *todoRepository) Delete(ctx context.Context, id string, userId string, deleteChildren bool, expected model.Precondition) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid id format")
@@ -139,14 +520,153 @@ func (r *todoRepository) Delete(ctx context.Context, id string, userId string) e
 		return errors.New("invalid user id format")
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "userId": userObjectID})
+	return r.deleteSubtree(ctx, objectID, userObjectID, deleteChildren, expected)
+}
+
+func (r *todoRepository) AddLabel(ctx context.Context, id string, userId string, labelID primitive.ObjectID) (*model.Todo, error) {
+	return r.updateLabels(ctx, id, userId, bson.M{"$addToSet": bson.M{"labels": labelID}})
+}
+
+func (r *todoRepository) RemoveLabel(ctx context.Context, id string, userId string, labelID primitive.ObjectID) (*model.Todo, error) {
+	return r.updateLabels(ctx, id, userId, bson.M{"$pull": bson.M{"labels": labelID}})
+}
+
+// SetLabels replaces all labels of a todo
+func (r *todoRepository) SetLabels(ctx context.Context, id string, userId string, labelIDs []primitive.ObjectID) (*model.Todo, error) {
+	if len(labelIDs) == 0 {
+		return r.updateLabels(ctx, id, userId, bson.M{"$unset": bson.M{"labels": ""}})
+	}
+	return r.updateLabels(ctx, id, userId, bson.M{"$set": bson.M{"labels": labelIDs}})
+}
+
+func (r *todoRepository) updateLabels(ctx context.Context, id string, userId string, update bson.M) (*model.Todo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return nil, errors.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, errors.New("invalid user id format")
+	}
+
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updatedAt"] = time.Now()
+	update["$inc"] = incVersion
+	if update, err = r.changes.stamp(ctx, userObjectID, update); err != nil {
+		return nil, err
+	}
+	result, err := r.collection.UpdateOne(ctx, live(bson.M{"_id": objectID, "userId": userObjectID}), update)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil, errors.New("todo not found")
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// ClaimNextOccurrence reserves nextID as the follow-up of a recurring todo.
+// It reports false when another request already spawned the next occurrence.
+func (r *todoRepository) ClaimNextOccurrence(ctx context.Context, id string, userId string, nextID primitive.ObjectID) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, errors.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false, errors.New("invalid user id format")
+	}
+
+	filter := bson.M{"_id": objectID, "userId": userObjectID, "nextOccurrenceId": nil}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"nextOccurrenceId": nextID}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// FindDueReminders returns open todos holding at least one unsent reminder
+// whose time has come, across all users. Reminders being retried count once
+// their next attempt is due, and come after those not tried yet.
+func (r *todoRepository) FindDueReminders(ctx context.Context, now time.Time, limit int) ([]*model.Todo, error) {
+	filter := live(bson.M{
+		"completed": false,
+		"reminders": bson.M{"$elemMatch": bson.M{
+			"remindAt": bson.M{"$lte": now},
+			"sentAt":   nil,
+			"failedAt": nil,
+			"$or": bson.A{
+				bson.M{"nextAttemptAt": nil},
+				bson.M{"nextAttemptAt": bson.M{"$lte": now}},
+			},
+		}},
+	})
+	sort := bson.D{{Key: "reminders.nextAttemptAt", Value: 1}, {Key: "reminders.remindAt", Value: 1}}
+	opts := options.Find().SetSort(sort).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var todos []*model.Todo
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// ClaimReminder atomically records a reminder as sent. It reports false when
+// the reminder was already claimed, so each reminder is delivered only once
+// even with several schedulers running or after a restart.
+func (r *todoRepository) ClaimReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, sentAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":       todoID,
+		"reminders": bson.M{"$elemMatch": bson.M{"id": reminderID, "sentAt": nil, "failedAt": nil}},
+	}
+	update := bson.M{
+		"$set":   bson.M{"reminders.$.sentAt": sentAt},
+		"$unset": bson.M{"reminders.$.nextAttemptAt": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
 	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RetryReminder clears the delivery record of a reminder whose
+// notification failed so it is picked up again from nextAttemptAt.
+func (r *todoRepository) RetryReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, attempts int, nextAttemptAt time.Time) error {
+	filter := bson.M{"_id": todoID, "reminders.id": reminderID}
+	update := bson.M{
+		"$set":   bson.M{"reminders.$.attempts": attempts, "reminders.$.nextAttemptAt": nextAttemptAt},
+		"$unset": bson.M{"reminders.$.sentAt": ""},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
 
-	if result.DeletedCount == 0 {
-		return errors.New("todo not found")
+// FailReminder records that a reminder's notification was given up on, so
+// it is never picked up again.
+func (r *todoRepository) FailReminder(ctx context.Context, todoID, reminderID primitive.ObjectID, attempts int, failedAt time.Time) error {
+	filter := bson.M{"_id": todoID, "reminders.id": reminderID}
+	update := bson.M{
+		"$set":   bson.M{"reminders.$.attempts": attempts, "reminders.$.failedAt": failedAt},
+		"$unset": bson.M{"reminders.$.sentAt": "", "reminders.$.nextAttemptAt": ""},
 	}
 
-	return nil
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
 }
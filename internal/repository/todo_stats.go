@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"time"
+	"todo-app/internal/stats"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// statsFacets decodes the sums of the stats pipeline, one facet per kind
+type statsFacets struct {
+	Completed []struct {
+		Day    string `bson:"_id"`
+		Count  int    `bson:"count"`
+		Millis int64  `bson:"millis"`
+		Late   int    `bson:"late"`
+	} `bson:"completed"`
+	Created []struct {
+		Count     int `bson:"count"`
+		Completed int `bson:"completed"`
+	} `bson:"created"`
+	Overdue []struct {
+		Count int `bson:"count"`
+	} `bson:"overdue"`
+}
+
+// Stats sums up a user's live todos over a range in a single aggregation,
+// grouping completions by the day they fall on in the range's time zone. It
+// gives the same totals as stats.Aggregate over the same todos.
+func (r *todoRepository) Stats(ctx context.Context, userId string, window stats.Range) (*stats.Totals, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	// Todos completed before completedAt was recorded fall back to their
+	// last change, as in model.Todo.CompletionTime
+	completedAt := bson.M{"$ifNull": bson.A{"$completedAt", "$updatedAt"}}
+	inRange := bson.M{"$gte": window.Start, "$lt": window.End}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: live(bson.M{"userId": userObjectID})}},
+		{{Key: "$facet", Value: bson.M{
+			"completed": bson.A{
+				bson.M{"$match": bson.M{"completed": true}},
+				bson.M{"$addFields": bson.M{"doneAt": completedAt}},
+				bson.M{"$match": bson.M{"doneAt": inRange}},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{"$dateToString": bson.M{
+						"format":   "%Y-%m-%d",
+						"date":     "$doneAt",
+						"timezone": window.Location.String(),
+					}},
+					"count":  bson.M{"$sum": 1},
+					"millis": bson.M{"$sum": bson.M{"$subtract": bson.A{"$doneAt", "$createdAt"}}},
+					"late": bson.M{"$sum": bson.M{"$cond": bson.A{
+						// Dates sort after null and a missing dueAt before it
+						bson.M{"$and": bson.A{
+							bson.M{"$gt": bson.A{"$dueAt", nil}},
+							bson.M{"$gt": bson.A{"$doneAt", "$dueAt"}},
+						}},
+						1, 0,
+					}}},
+				}},
+			},
+			"created": bson.A{
+				bson.M{"$match": bson.M{"createdAt": inRange}},
+				bson.M{"$group": bson.M{
+					"_id":       nil,
+					"count":     bson.M{"$sum": 1},
+					"completed": bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 1, 0}}},
+				}},
+			},
+			"overdue": bson.A{
+				bson.M{"$match": bson.M{
+					"completed": false,
+					"dueAt":     bson.M{"$gte": window.Start, "$lt": window.OverdueBefore()},
+				}},
+				bson.M{"$count": "count"},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facets statsFacets
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&facets); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	totals := &stats.Totals{CompletedPerDay: make(map[string]int, len(facets.Completed))}
+	for _, day := range facets.Completed {
+		totals.CompletedPerDay[day.Day] = day.Count
+		totals.CompletionTime += time.Duration(day.Millis) * time.Millisecond
+		totals.CompletedLate += day.Late
+	}
+	if len(facets.Created) > 0 {
+		totals.Created = facets.Created[0].Count
+		totals.CreatedCompleted = facets.Created[0].Completed
+	}
+	if len(facets.Overdue) > 0 {
+		totals.Overdue = facets.Overdue[0].Count
+	}
+	return totals, nil
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	stderror "errors"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TombstoneRetention is how long purged todos are remembered for sync.
+// A sync token older than this may miss a purge, so it is refused.
+const TombstoneRetention = 90 * 24 * time.Hour
+
+// changeSequence numbers the changes to each user's todos. Every write that
+// moves a todo's version on also stamps it with the next number of its
+// user as changeSeq, so the todos changed since a sync are the ones with a
+// higher number, and the last number taken versions the user's list of
+// todos as a whole. Within a transaction the counter serialises the writes of
+// a user, so numbers are committed in order; without transactions a slow
+// write may commit under a number a concurrent sync has already passed.
+type changeSequence struct {
+	collection *mongo.Collection
+}
+
+// newChangeSequence keeps the counters next to the todo collection, so
+// every repository writing todos shares them
+func newChangeSequence(todos *mongo.Collection) *changeSequence {
+	return &changeSequence{collection: todos.Database().Collection(todos.Name() + "_sequences")}
+}
+
+// next reserves the next change number of a user
+func (c *changeSequence) next(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var counter model.TodoListVersion
+	err := c.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"seq": 1}, "$set": bson.M{"changedAt": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
+
+// current returns the last change number of a user and when it was taken.
+// Users whose todos have never changed are at 0.
+func (c *changeSequence) current(ctx context.Context, userID primitive.ObjectID) (*model.TodoListVersion, error) {
+	var counter model.TodoListVersion
+	err := c.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&counter)
+	if err != nil && !stderror.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return &counter, nil
+}
+
+// stamp adds the next change number of a user to an update of its todos
+func (c *changeSequence) stamp(ctx context.Context, userID primitive.ObjectID, update bson.M) (bson.M, error) {
+	seq, err := c.next(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["changeSeq"] = seq
+	return update, nil
+}
+
+// tombstone records that a todo was purged, so clients that saw it learn
+// it is gone
+type tombstone struct {
+	TodoID    primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	ChangeSeq int64              `bson:"changeSeq"`
+	PurgedAt  time.Time          `bson:"purgedAt"`
+}
+
+// bury replaces purged todos of a user with tombstones. Purging one twice
+// only moves its tombstone on.
+func (r *todoRepository) bury(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	seq, err := r.changes.next(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetReplacement(tombstone{TodoID: id, UserID: userID, ChangeSeq: seq, PurgedAt: now}).
+			SetUpsert(true)
+	}
+	_, err = r.tombstones.BulkWrite(ctx, models)
+	return err
+}
+
+// syncToken is the decoded form of a sync token: the position in the
+// change sequence of the last change sent, and when it was handed out
+type syncToken struct {
+	Seq      int64  `json:"q"`
+	ID       string `json:"id,omitempty"`
+	IssuedAt int64  `json:"t"`
+}
+
+func encodeSyncToken(t syncToken) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSyncToken(token string, now time.Time) (*syncToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.ErrInvalidSyncToken
+	}
+	var t syncToken
+	if err := json.Unmarshal(data, &t); err != nil || t.Seq < 0 || t.IssuedAt == 0 {
+		return nil, errors.ErrInvalidSyncToken
+	}
+	if t.ID != "" && !primitive.IsValidObjectID(t.ID) {
+		return nil, errors.ErrInvalidSyncToken
+	}
+	if now.Sub(time.Unix(t.IssuedAt, 0)) > TombstoneRetention {
+		return nil, errors.ErrSyncTokenExpired
+	}
+	return &t, nil
+}
+
+// after selects the documents past a token's position, ordered by change
+// number and then ID. Todos last written before change numbers existed
+// have none and come first.
+func (t *syncToken) after() bson.M {
+	if t.ID == "" {
+		return bson.M{"changeSeq": bson.M{"$gt": t.Seq}}
+	}
+	id, _ := primitive.ObjectIDFromHex(t.ID)
+	at := bson.M{"changeSeq": t.Seq}
+	if t.Seq == 0 {
+		at = bson.M{"changeSeq": bson.M{"$in": bson.A{0, nil}}}
+	}
+	at["_id"] = bson.M{"$gt": id}
+	return bson.M{"$or": bson.A{bson.M{"changeSeq": bson.M{"$gt": t.Seq}}, at}}
+}
+
+// syncEntry is a todo or tombstone in change order
+type syncEntry struct {
+	seq     int64
+	id      primitive.ObjectID
+	todo    *model.Todo
+	deleted bool
+}
+
+// Changes returns the todos of a user created, changed, trashed or purged
+// since a sync token, oldest change first. Without a token every todo is
+// returned, the trashed ones as deleted. A change that touched several
+// todos can be split across pages, since the token remembers the last todo
+// sent as well as the change number.
+func (r *todoRepository) Changes(ctx context.Context, userId string, since string, limit int) (*model.SyncChanges, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	now := time.Now()
+	position := &syncToken{}
+	if since != "" {
+		if position, err = decodeSyncToken(since, now); err != nil {
+			return nil, err
+		}
+	}
+
+	sort := options.Find().
+		SetSort(bson.D{{Key: "changeSeq", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit + 1))
+
+	var todos []*model.Todo
+	filter := bson.M{"$and": bson.A{bson.M{"userId": userObjectID}, position.after()}}
+	if since == "" {
+		filter = bson.M{"userId": userObjectID}
+	}
+	cursor, err := r.collection.Find(ctx, filter, sort)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+
+	var tombstones []*tombstone
+	if since != "" {
+		filter := bson.M{"$and": bson.A{bson.M{"userId": userObjectID}, position.after()}}
+		cursor, err := r.tombstones.Find(ctx, filter, sort)
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &tombstones); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge the two, both in change order
+	entries := make([]syncEntry, 0, len(todos)+len(tombstones))
+	for len(todos) > 0 || len(tombstones) > 0 {
+		takeTodo := len(tombstones) == 0 ||
+			(len(todos) > 0 && (todos[0].ChangeSeq < tombstones[0].ChangeSeq ||
+				todos[0].ChangeSeq == tombstones[0].ChangeSeq && todos[0].ID.Hex() < tombstones[0].TodoID.Hex()))
+		if takeTodo {
+			todo := todos[0]
+			entries = append(entries, syncEntry{seq: todo.ChangeSeq, id: todo.ID, todo: todo, deleted: todo.DeletedAt != nil})
+			todos = todos[1:]
+		} else {
+			entries = append(entries, syncEntry{seq: tombstones[0].ChangeSeq, id: tombstones[0].TodoID, deleted: true})
+			tombstones = tombstones[1:]
+		}
+	}
+
+	changes := &model.SyncChanges{Changed: []*model.Todo{}, Deleted: []string{}}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		changes.More = true
+	}
+	for _, entry := range entries {
+		if entry.deleted {
+			changes.Deleted = append(changes.Deleted, entry.id.Hex())
+		} else {
+			changes.Changed = append(changes.Changed, entry.todo)
+		}
+	}
+
+	next := syncToken{Seq: position.Seq, ID: position.ID, IssuedAt: now.Unix()}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		next.Seq, next.ID = last.seq, last.id.Hex()
+	}
+	changes.Token = encodeSyncToken(next)
+	return changes, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindDeleted returns a page of the user's trashed todos, most recently
+// deleted first
+func (r *todoRepository) FindDeleted(ctx context.Context, userId string, query *model.TrashQuery) (*model.TodoPage, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = model.DefaultTodoPageLimit
+	}
+
+	filter := bson.M{"userId": userObjectID, "deletedAt": bson.M{"$exists": true}}
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, "deletedAt", "desc")
+		if err != nil {
+			return nil, err
+		}
+		seek, err := cursor.seekFilter()
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, seek}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	todos := make([]*model.Todo, 0, limit+1)
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+
+	page := &model.TodoPage{Items: todos}
+	if len(todos) > limit {
+		page.Items = todos[:limit]
+		last := page.Items[len(page.Items)-1]
+		value, _ := sortValue(last, "deletedAt")
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:  "deletedAt",
+			Order: "desc",
+			Value: value,
+			ID:    last.ID.Hex(),
+		})
+	}
+	return page, nil
+}
+
+// Restore takes a todo out of the trash together with the subtasks deleted
+// along with it. It goes back under its parent when the parent is still
+// live and has room for it, and becomes a top-level todo otherwise.
+func (r *todoRepository) Restore(ctx context.Context, id string, userId string) (*model.Todo, error) {
+	todo, err := r.findDeleted(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil {
+		return nil, stderror.New("todo not found")
+	}
+
+	now := time.Now()
+	update, err := r.changes.stamp(ctx, todo.UserID, bson.M{"$set": bson.M{"updatedAt": now}, "$unset": bson.M{"deletedAt": ""}, "$inc": incVersion})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": todo.subtree()}, "userId": todo.UserID}, update); err != nil {
+		return nil, err
+	}
+
+	if todo.ParentID != nil {
+		parent, err := r.checkParent(ctx, *todo.ParentID, todo.UserID, todo.height())
+		switch {
+		case err == nil:
+			// The parent may have changed lists while the todo was in the trash
+			if err := r.setSubtreeList(ctx, &todo.lineage, todo.UserID, parent.ListID); err != nil {
+				return nil, err
+			}
+		case stderror.Is(err, errors.ErrParentNotFound) || stderror.Is(err, errors.ErrMaxDepthExceeded):
+			_, err := r.collection.UpdateOne(ctx,
+				bson.M{"_id": todo.ID, "userId": todo.UserID},
+				bson.M{"$unset": bson.M{"parentId": ""}, "$set": update["$set"], "$inc": incVersion})
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+	}
+
+	return r.FindByID(ctx, id, userId)
+}
+
+// Purge permanently removes a trashed todo and the subtasks deleted along
+// with it
+func (r *todoRepository) Purge(ctx context.Context, id string, userId string) error {
+	todo, err := r.findDeleted(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+	if todo == nil {
+		return stderror.New("todo not found")
+	}
+
+	if err := r.bury(ctx, todo.UserID, todo.subtree(), time.Now()); err != nil {
+		return err
+	}
+	_, err = r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": todo.subtree()}, "userId": todo.UserID})
+	return err
+}
+
+// PurgeDeletedBefore permanently removes every todo, across all users, that
+// was moved to the trash before cutoff, leaving tombstones for sync
+func (r *todoRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	filter := bson.M{"deletedAt": bson.M{"$lt": cutoff}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1, "userId": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var docs []struct {
+		ID     primitive.ObjectID `bson:"_id"`
+		UserID primitive.ObjectID `bson:"userId"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+
+	byUser := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, doc := range docs {
+		byUser[doc.UserID] = append(byUser[doc.UserID], doc.ID)
+	}
+	now := time.Now()
+	var purged int64
+	for userID, ids := range byUser {
+		if err := r.bury(ctx, userID, ids, now); err != nil {
+			return purged, err
+		}
+		result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "userId": userID})
+		if err != nil {
+			return purged, err
+		}
+		purged += result.DeletedCount
+	}
+	return purged, nil
+}
+
+// deletedTodo is a trashed todo with the subtree that was deleted with it
+type deletedTodo struct {
+	lineage
+	UserID   primitive.ObjectID
+	ParentID *primitive.ObjectID
+}
+
+// findDeleted loads a trashed todo and its subtree, or nil when the user has
+// no such todo in the trash
+func (r *todoRepository) findDeleted(ctx context.Context, id string, userId string) (*deletedTodo, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, stderror.New("invalid id format")
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var todo model.Todo
+	filter := bson.M{"_id": objectID, "userId": userObjectID, "deletedAt": bson.M{"$exists": true}}
+	if err := r.collection.FindOne(ctx, filter).Decode(&todo); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	tree, err := r.findLineageAt(ctx, objectID, userObjectID, todo.DeletedAt)
+	if err != nil || tree == nil {
+		return nil, err
+	}
+	return &deletedTodo{lineage: *tree, UserID: userObjectID, ParentID: todo.ParentID}, nil
+}
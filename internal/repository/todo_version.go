@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// incVersion is added to every update that changes what clients see of a
+// todo, so its version moves on with each edit. Bookkeeping by the workers,
+// such as marking a reminder sent, leaves the version alone so it does not
+// invalidate a client's pending edit. The same updates are stamped with
+// the user's next change number, which is what sync follows.
+var incVersion = bson.M{"version": 1}
+
+// expectVersion restricts a filter to the versions a precondition allows.
+// Todos stored before versions were introduced have none and count as 0.
+func expectVersion(filter bson.M, expected model.Precondition) bson.M {
+	if expected == nil {
+		return filter
+	}
+	versions := bson.A{}
+	for _, version := range expected {
+		versions = append(versions, version)
+		if version == 0 {
+			versions = append(versions, nil)
+		}
+	}
+	filter["version"] = bson.M{"$in": versions}
+	return filter
+}
+
+// missed explains why a conditional write to a live todo matched nothing:
+// either the todo is gone or it is at a version the precondition rejects
+func (r *todoRepository) missed(ctx context.Context, id, userID primitive.ObjectID, expected model.Precondition) error {
+	if expected != nil {
+		count, err := r.collection.CountDocuments(ctx, live(bson.M{"_id": id, "userId": userID}))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.ErrPreconditionFailed
+		}
+	}
+	return stderror.New("todo not found")
+}
+
+// ListVersion returns the position of a user in the change sequence.
+// Every write clients can see, purges included, takes the next number, and
+// numbers are never handed out twice, so a version never comes back.
+func (r *todoRepository) ListVersion(ctx context.Context, userId string) (*model.TodoListVersion, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+	return r.changes.current(ctx, userObjectID)
+}
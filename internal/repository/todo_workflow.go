@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"time"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SettleStatuses brings the todos of a list, or those without one when
+// listID is nil, in line with the workflow they follow. Todos in a state
+// the workflow does not have move to its first done or first open state,
+// according to whether they are completed, and todos whose state is done
+// or not are completed or reopened to match.
+func (r *todoRepository) SettleStatuses(ctx context.Context, userId string, listID *primitive.ObjectID, workflow *model.Workflow) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return stderror.New("invalid user id format")
+	}
+
+	scope := bson.M{"userId": userObjectID, "listId": nil}
+	if listID != nil {
+		scope["listId"] = *listID
+	}
+	keys := bson.A{}
+	for _, state := range workflow.States {
+		keys = append(keys, state.Key)
+	}
+
+	now := time.Now()
+	type settlement struct {
+		filter bson.M
+		set    bson.M
+		unset  bson.M
+	}
+	var settlements []settlement
+	for _, done := range []bool{false, true} {
+		settlements = append(settlements, settlement{
+			filter: bson.M{"status": bson.M{"$nin": keys}, "completed": done},
+			set:    bson.M{"status": workflow.FirstState(done)},
+		})
+	}
+	for _, state := range workflow.States {
+		s := settlement{
+			filter: bson.M{"status": state.Key, "completed": !state.Done},
+			set:    bson.M{"completed": state.Done},
+		}
+		if state.Done {
+			s.set["completedAt"] = now
+		} else {
+			s.unset = bson.M{"completedAt": ""}
+		}
+		settlements = append(settlements, s)
+	}
+
+	// Most workflow changes leave every todo as it is, and those should not
+	// take a change number
+	unsettled := bson.A{}
+	for _, s := range settlements {
+		unsettled = append(unsettled, s.filter)
+	}
+	count, err := r.collection.CountDocuments(ctx, bson.M{"$and": bson.A{scope, bson.M{"$or": unsettled}}}, options.Count().SetLimit(1))
+	if err != nil || count == 0 {
+		return err
+	}
+
+	seq, err := r.changes.next(ctx, userObjectID)
+	if err != nil {
+		return err
+	}
+	for _, s := range settlements {
+		s.set["updatedAt"] = now
+		s.set["changeSeq"] = seq
+		update := bson.M{"$set": s.set, "$inc": incVersion}
+		if s.unset != nil {
+			update["$unset"] = s.unset
+		}
+		if _, err := r.collection.UpdateMany(ctx, bson.M{"$and": bson.A{scope, s.filter}}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,11 +10,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) (*model.User, error)
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	Update(ctx context.Context, id string, update *model.UserUpdate) (*model.User, error)
 }
 
 type userRepository struct {
@@ -60,3 +63,45 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.
 	}
 	return &user, nil
 }
+
+func (r *userRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	var user model.User
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update applies the fields set in update and returns the updated user, or
+// nil when there is no such user
+func (r *userRepository) Update(ctx context.Context, id string, update *model.UserUpdate) (*model.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if update.TimeZone != nil {
+		set["timeZone"] = *update.TimeZone
+	}
+
+	var user model.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": set}, opts).Decode(&user)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deliveryRetention is how long deliveries are kept in the log
+const deliveryRetention = 30 * 24 * time.Hour
+
+// defaultDeliveryLimit is how many deliveries a page of the log holds
+const defaultDeliveryLimit = 50
+
+type WebhookDeliveryRepository interface {
+	CreateMany(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	FindByWebhook(ctx context.Context, webhookID primitive.ObjectID, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt model.WebhookAttempt) error
+	Requeue(ctx context.Context, id string, webhookID primitive.ObjectID, now time.Time) (*model.WebhookDelivery, error)
+	DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) error
+}
+
+type webhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(db *mongo.Database, collectionName string) WebhookDeliveryRepository {
+	repo := &webhookDeliveryRepository{
+		collection: db.Collection(collectionName),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *webhookDeliveryRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "_id", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(deliveryRetention.Seconds())),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create webhook delivery indexes: %v", err)
+	}
+}
+
+// CreateMany queues deliveries. It runs inside the transaction of the change
+// they announce, so a change is never committed without its deliveries.
+func (r *webhookDeliveryRepository) CreateMany(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		documents[i] = delivery
+	}
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+// FindByWebhook returns the newest deliveries of a webhook first
+func (r *webhookDeliveryRepository) FindByWebhook(ctx context.Context, webhookID primitive.ObjectID, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error) {
+	filter := bson.M{"webhookId": webhookID}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*model.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Claim takes the pending delivery that has waited longest, if any is due
+// at now, and holds it for the lease so that no other worker sends it too.
+// A worker that stops before recording the attempt leaves it to be retried
+// once the lease runs out.
+func (r *webhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	filter := bson.M{"status": model.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1})
+
+	var delivery model.WebhookDelivery
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery); err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt logs an attempt and stores the state it left the delivery
+// in: its status, tries and when it is next attempted
+func (r *webhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt model.WebhookAttempt) error {
+	set := bson.M{"status": delivery.Status, "tries": delivery.Tries}
+	if delivery.DeliveredAt != nil {
+		set["deliveredAt"] = delivery.DeliveredAt
+	}
+	update := bson.M{"$set": set, "$push": bson.M{"attempts": attempt}}
+	if delivery.NextAttemptAt != nil {
+		set["nextAttemptAt"] = delivery.NextAttemptAt
+	} else {
+		update["$unset"] = bson.M{"nextAttemptAt": ""}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, update)
+	return err
+}
+
+// Requeue sends a dead delivery again, with a fresh round of retries
+func (r *webhookDeliveryRepository) Requeue(ctx context.Context, id string, webhookID primitive.ObjectID, now time.Time) (*model.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	filter := bson.M{"_id": objectID, "webhookId": webhookID, "status": model.DeliveryDead}
+	update := bson.M{"$set": bson.M{"status": model.DeliveryPending, "tries": 0, "nextAttemptAt": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery model.WebhookDelivery
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == nil {
+		return &delivery, nil
+	}
+	if !stderror.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID, "webhookId": webhookID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.ErrWebhookDeliveryNotFound
+	}
+	return nil, errors.ErrDeliveryNotDead
+}
+
+func (r *webhookDeliveryRepository) DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	return err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, userId string, webhook *model.WebhookCreate) (*model.Webhook, error)
+	FindAll(ctx context.Context, userId string) ([]*model.Webhook, error)
+	FindByID(ctx context.Context, id string, userId string) (*model.Webhook, error)
+	Update(ctx context.Context, id string, userId string, update *model.WebhookUpdate) (*model.Webhook, error)
+	Delete(ctx context.Context, id string, userId string) error
+}
+
+type webhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Database, collectionName string) WebhookRepository {
+	repo := &webhookRepository{
+		collection: db.Collection(collectionName),
+	}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *webhookRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create webhook indexes: %v", err)
+	}
+}
+
+// Create registers a webhook. Without an event filter it subscribes to
+// every event, which is stored as such so that later events are not
+// picked up by accident.
+func (r *webhookRepository) Create(ctx context.Context, userId string, webhookCreate *model.WebhookCreate) (*model.Webhook, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	now := time.Now()
+	webhook := &model.Webhook{
+		UserID:    userObjectID,
+		URL:       webhookCreate.URL,
+		Secret:    webhookCreate.Secret,
+		Events:    webhookEvents(webhookCreate.Events),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return webhook, nil
+}
+
+func webhookEvents(events []string) []string {
+	if len(events) == 0 {
+		return append([]string{}, model.EventTypes...)
+	}
+	return events
+}
+
+func (r *webhookRepository) FindAll(ctx context.Context, userId string) ([]*model.Webhook, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+	return r.find(ctx, bson.M{"userId": userObjectID})
+}
+
+func (r *webhookRepository) find(ctx context.Context, filter bson.M) ([]*model.Webhook, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*model.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id string, userId string) (*model.Webhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var webhook model.Webhook
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}).Decode(&webhook)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, id string, userId string, webhookUpdate *model.WebhookUpdate) (*model.Webhook, error) {
+	webhook, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if webhookUpdate.URL != "" {
+		set["url"] = webhookUpdate.URL
+	}
+	if webhookUpdate.Secret != "" {
+		set["secret"] = webhookUpdate.Secret
+	}
+	if len(webhookUpdate.Events) > 0 {
+		set["events"] = webhookUpdate.Events
+	}
+
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": webhook.ID, "userId": webhook.UserID}, bson.M{"$set": set})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id, userId)
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id string, userId string) error {
+	webhook, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": webhook.ID, "userId": webhook.UserID})
+	return err
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	stderror "errors"
+	"log"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WorkflowRepository interface {
+	Create(ctx context.Context, userId string, workflow *model.WorkflowCreate) (*model.Workflow, error)
+	FindAll(ctx context.Context, userId string) ([]*model.Workflow, error)
+	FindByID(ctx context.Context, id string, userId string) (*model.Workflow, error)
+	FindForList(ctx context.Context, userId string, listID *primitive.ObjectID) (*model.Workflow, error)
+	Update(ctx context.Context, id string, userId string, workflow *model.WorkflowUpdate) (*model.Workflow, error)
+	Delete(ctx context.Context, id string, userId string) error
+}
+
+type workflowRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWorkflowRepository(db *mongo.Database, collectionName string) WorkflowRepository {
+	repo := &workflowRepository{collection: db.Collection(collectionName)}
+	repo.ensureIndexes()
+	return repo
+}
+
+func (r *workflowRepository) ensureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "listIds", Value: 1}}},
+		{
+			// At most one default workflow per user
+			Keys: bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().
+				SetName("unique_default").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"default": true}),
+		},
+	}
+	if _, err := r.collection.Indexes().CreateMany(ctx, models); err != nil {
+		log.Printf("Failed to create workflow indexes: %v", err)
+	}
+}
+
+func (r *workflowRepository) Create(ctx context.Context, userId string, workflowCreate *model.WorkflowCreate) (*model.Workflow, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	now := time.Now()
+	workflow := &model.Workflow{
+		ID:          primitive.NewObjectID(),
+		UserID:      userObjectID,
+		Name:        workflowCreate.Name,
+		Default:     workflowCreate.Default,
+		ListIDs:     workflowCreate.ListIDs,
+		States:      workflowCreate.States,
+		Transitions: workflowCreate.Transitions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err = database.RunInTransaction(ctx, r.collection.Database(), func(ctx context.Context) error {
+		if err := r.takeOver(ctx, workflow); err != nil {
+			return err
+		}
+		_, err := r.collection.InsertOne(ctx, workflow)
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrDuplicateResource
+		}
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// takeOver makes a workflow the only one of its user applying to its lists,
+// and the only default when it is one
+func (r *workflowRepository) takeOver(ctx context.Context, workflow *model.Workflow) error {
+	others := bson.M{"userId": workflow.UserID, "_id": bson.M{"$ne": workflow.ID}}
+	if workflow.Default {
+		if _, err := r.collection.UpdateMany(ctx, others, bson.M{"$set": bson.M{"default": false}}); err != nil {
+			return err
+		}
+	}
+	if len(workflow.ListIDs) > 0 {
+		update := bson.M{"$pull": bson.M{"listIds": bson.M{"$in": workflow.ListIDs}}}
+		if _, err := r.collection.UpdateMany(ctx, others, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *workflowRepository) FindAll(ctx context.Context, userId string) ([]*model.Workflow, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userObjectID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workflows := []*model.Workflow{}
+	if err := cursor.All(ctx, &workflows); err != nil {
+		return nil, err
+	}
+	return workflows, nil
+}
+
+func (r *workflowRepository) FindByID(ctx context.Context, id string, userId string) (*model.Workflow, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.ErrInvalidID
+	}
+
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	var workflow model.Workflow
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "userId": userObjectID}).Decode(&workflow)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrWorkflowNotFound
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// FindForList returns the workflow the todos of a list follow: the one
+// naming the list, or else the user's default. It returns nil when there
+// is neither, and the user's default for todos without a list.
+func (r *workflowRepository) FindForList(ctx context.Context, userId string, listID *primitive.ObjectID) (*model.Workflow, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, stderror.New("invalid user id format")
+	}
+
+	filter := bson.M{"userId": userObjectID, "default": true}
+	if listID != nil {
+		filter = bson.M{"userId": userObjectID, "$or": bson.A{
+			bson.M{"listIds": *listID},
+			bson.M{"default": true},
+		}}
+	}
+	// A workflow naming the list comes before the default
+	opts := options.FindOne().SetSort(bson.D{{Key: "default", Value: 1}, {Key: "_id", Value: 1}})
+
+	var workflow model.Workflow
+	err = r.collection.FindOne(ctx, filter, opts).Decode(&workflow)
+	if err != nil {
+		if stderror.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// Update replaces the definition of a workflow. The todos it affects are
+// settled by the caller, with SettleStatuses.
+func (r *workflowRepository) Update(ctx context.Context, id string, userId string, workflowUpdate *model.WorkflowUpdate) (*model.Workflow, error) {
+	workflow, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow.Name = workflowUpdate.Name
+	workflow.Default = workflowUpdate.Default
+	workflow.ListIDs = workflowUpdate.ListIDs
+	workflow.States = workflowUpdate.States
+	workflow.Transitions = workflowUpdate.Transitions
+	workflow.UpdatedAt = time.Now()
+
+	err = database.RunInTransaction(ctx, r.collection.Database(), func(ctx context.Context) error {
+		if err := r.takeOver(ctx, workflow); err != nil {
+			return err
+		}
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": workflow.ID, "userId": workflow.UserID}, workflow)
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.ErrDuplicateResource
+		}
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// Delete removes a workflow. Its todos follow the workflow that applies to
+// their list after it, once the caller has settled them.
+func (r *workflowRepository) Delete(ctx context.Context, id string, userId string) error {
+	workflow, err := r.FindByID(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": workflow.ID, "userId": workflow.UserID})
+	return err
+}
@@ -9,27 +9,172 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupAuthRoutes(router *gin.Engine, authController *controller.AuthController) {
+func SetupAuthRoutes(router *gin.Engine, authController *controller.AuthController, authService auth.Service) {
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/register", authController.Register)
 		authGroup.POST("/login", authController.Login)
+		authGroup.PATCH("/me", authService.AuthMiddleware(), authController.UpdateMe)
 	}
 }
 
-func SetupTodoRoutes(router *gin.Engine, todoController *controller.TodoController, authService auth.Service) {
+func SetupTodoRoutes(router *gin.Engine, todoController *controller.TodoController, authService auth.Service, idempotency gin.HandlerFunc) {
 	todoGroup := router.Group("/todos")
-	todoGroup.Use(authService.AuthMiddleware())
+	todoGroup.Use(authService.AuthMiddleware(), idempotency)
 	{
 		todoGroup.GET("", todoController.GetAllTodos)
 		todoGroup.POST("", todoController.CreateTodo)
+		todoGroup.POST("/quick", todoController.QuickAddTodo)
+		todoGroup.GET("/search", todoController.SearchTodos)
+		todoGroup.POST("/bulk", todoController.BulkTodos)
+		todoGroup.GET("/export", todoController.ExportTodos)
+		todoGroup.POST("/import", todoController.ImportTodos)
 		todoGroup.GET("/:id", todoController.GetTodo)
 		todoGroup.PUT("/:id", todoController.UpdateTodo)
+		todoGroup.PATCH("/:id", todoController.PatchTodo)
 		todoGroup.DELETE("/:id", todoController.DeleteTodo)
+		todoGroup.GET("/:id/occurrences", todoController.GetOccurrences)
+		todoGroup.GET("/:id/children", todoController.GetChildren)
+		todoGroup.PUT("/:id/parent", todoController.MoveTodo)
+		todoGroup.POST("/:id/move", todoController.ReorderTodo)
+		todoGroup.POST("/:id/transition", todoController.TransitionTodo)
+		todoGroup.POST("/:id/labels/:labelId", todoController.AttachLabel)
+		todoGroup.DELETE("/:id/labels/:labelId", todoController.DetachLabel)
+		todoGroup.PUT("/:id/list", todoController.MoveToList)
+		todoGroup.GET("/:id/history", todoController.GetHistory)
+		todoGroup.POST("/:id/revert/:revision", todoController.RevertTodo)
 	}
 }
 
-func SetupRoutes(router *gin.Engine, authController *controller.AuthController, todoController *controller.TodoController, authService auth.Service) {
+// SetupStreamRoutes serves todo events as they happen. They sit outside the
+// todo group because browsers can only authenticate them with a query
+// parameter, and no response is ever stored for replay.
+func SetupStreamRoutes(router *gin.Engine, streamController *controller.StreamController, authService auth.Service) {
+	router.GET("/todos/stream", authService.StreamAuthMiddleware(), streamController.StreamTodos)
+	router.GET("/todos/ws", authService.StreamAuthMiddleware(), streamController.StreamTodosWebSocket)
+}
+
+func SetupTrashRoutes(router *gin.Engine, todoController *controller.TodoController, authService auth.Service, idempotency gin.HandlerFunc) {
+	trashGroup := router.Group("/trash")
+	trashGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		trashGroup.GET("", todoController.ListTrash)
+		trashGroup.POST("/:id/restore", todoController.RestoreTodo)
+		trashGroup.DELETE("/:id", todoController.PurgeTodo)
+	}
+}
+
+func SetupSyncRoutes(router *gin.Engine, todoController *controller.TodoController, authService auth.Service, idempotency gin.HandlerFunc) {
+	syncGroup := router.Group("/sync")
+	syncGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		syncGroup.GET("", todoController.GetSyncChanges)
+		syncGroup.POST("", todoController.PushSyncChanges)
+	}
+}
+
+func SetupStatsRoutes(router *gin.Engine, todoController *controller.TodoController, authService auth.Service) {
+	router.GET("/stats", authService.AuthMiddleware(), todoController.GetStats)
+}
+
+func SetupLabelRoutes(router *gin.Engine, labelController *controller.LabelController, authService auth.Service, idempotency gin.HandlerFunc) {
+	labelGroup := router.Group("/labels")
+	labelGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		labelGroup.GET("", labelController.GetAllLabels)
+		labelGroup.POST("", labelController.CreateLabel)
+		labelGroup.GET("/:id", labelController.GetLabel)
+		labelGroup.PUT("/:id", labelController.UpdateLabel)
+		labelGroup.DELETE("/:id", labelController.DeleteLabel)
+		labelGroup.POST("/:id/merge", labelController.MergeLabel)
+	}
+}
+
+func SetupListRoutes(router *gin.Engine, listController *controller.ListController, authService auth.Service, idempotency gin.HandlerFunc) {
+	listGroup := router.Group("/lists")
+	listGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		listGroup.GET("", listController.GetAllLists)
+		listGroup.POST("", listController.CreateList)
+		listGroup.GET("/:id", listController.GetList)
+		listGroup.PUT("/:id", listController.UpdateList)
+		listGroup.DELETE("/:id", listController.DeleteList)
+		listGroup.GET("/:id/todos", listController.GetListTodos)
+	}
+}
+
+func SetupWorkflowRoutes(router *gin.Engine, workflowController *controller.WorkflowController, authService auth.Service, idempotency gin.HandlerFunc) {
+	workflowGroup := router.Group("/workflows")
+	workflowGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		workflowGroup.GET("", workflowController.GetAllWorkflows)
+		workflowGroup.POST("", workflowController.CreateWorkflow)
+		workflowGroup.GET("/:id", workflowController.GetWorkflow)
+		workflowGroup.PUT("/:id", workflowController.UpdateWorkflow)
+		workflowGroup.DELETE("/:id", workflowController.DeleteWorkflow)
+	}
+}
+
+func SetupWebhookRoutes(router *gin.Engine, webhookController *controller.WebhookController, authService auth.Service, idempotency gin.HandlerFunc) {
+	webhookGroup := router.Group("/webhooks")
+	webhookGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		webhookGroup.GET("", webhookController.GetAllWebhooks)
+		webhookGroup.POST("", webhookController.CreateWebhook)
+		webhookGroup.GET("/:id", webhookController.GetWebhook)
+		webhookGroup.PUT("/:id", webhookController.UpdateWebhook)
+		webhookGroup.DELETE("/:id", webhookController.DeleteWebhook)
+		webhookGroup.GET("/:id/deliveries", webhookController.ListDeliveries)
+		webhookGroup.POST("/:id/deliveries/:deliveryId/retry", webhookController.RetryDelivery)
+	}
+}
+
+// SetupCalendarRoutes serves todos as iCalendar data. Feeds are read by
+// calendar apps that cannot sign in, so their token is the only credential.
+func SetupCalendarRoutes(router *gin.Engine, calendarController *controller.CalendarController, authService auth.Service, idempotency gin.HandlerFunc) {
+	router.GET("/todos.ics", authService.AuthMiddleware(), calendarController.GetCalendar)
+	router.GET("/calendar/feed/:token", calendarController.GetFeedCalendar)
+
+	calendarGroup := router.Group("/calendar")
+	calendarGroup.Use(authService.AuthMiddleware(), idempotency)
+	{
+		calendarGroup.GET("/feed", calendarController.GetFeed)
+		calendarGroup.POST("/feed", calendarController.CreateFeed)
+		calendarGroup.DELETE("/feed", calendarController.RevokeFeed)
+	}
+}
+
+// SetupCalDAVRoutes serves lists and todos to CalDAV clients, which sign in
+// with HTTP Basic rather than tokens
+func SetupCalDAVRoutes(router *gin.Engine, caldavController *controller.CalDAVController, authService auth.Service) {
+	router.GET("/.well-known/caldav", caldavController.WellKnown)
+
+	davGroup := router.Group(controller.DAVPrefix)
+	davGroup.Use(authService.BasicAuthMiddleware("todo-app"))
+	{
+		for _, method := range controller.DAVMethods {
+			davGroup.Handle(method, "/*path", caldavController.Serve)
+		}
+	}
+}
+
+// Deps holds what the routes are served with. Controllers left nil must not
+// be reached by any request.
+type Deps struct {
+	AuthController     *controller.AuthController
+	TodoController     *controller.TodoController
+	LabelController    *controller.LabelController
+	ListController     *controller.ListController
+	WorkflowController *controller.WorkflowController
+	CalendarController *controller.CalendarController
+	CalDAVController   *controller.CalDAVController
+	WebhookController  *controller.WebhookController
+	StreamController   *controller.StreamController
+	AuthService        auth.Service
+	Idempotency        gin.HandlerFunc
+}
+
+func SetupRoutes(router *gin.Engine, deps Deps) {
 	router.Use(middleware.Logger())
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.CORS())
@@ -38,6 +183,16 @@ func SetupRoutes(router *gin.Engine, authController *controller.AuthController,
 		ctx.JSON(200, gin.H{"status": "ok", "time": time.Now().Format(time.RFC3339)})
 	})
 
-	SetupAuthRoutes(router, authController)
-	SetupTodoRoutes(router, todoController, authService)
+	SetupAuthRoutes(router, deps.AuthController, deps.AuthService)
+	SetupTodoRoutes(router, deps.TodoController, deps.AuthService, deps.Idempotency)
+	SetupTrashRoutes(router, deps.TodoController, deps.AuthService, deps.Idempotency)
+	SetupSyncRoutes(router, deps.TodoController, deps.AuthService, deps.Idempotency)
+	SetupStatsRoutes(router, deps.TodoController, deps.AuthService)
+	SetupLabelRoutes(router, deps.LabelController, deps.AuthService, deps.Idempotency)
+	SetupListRoutes(router, deps.ListController, deps.AuthService, deps.Idempotency)
+	SetupWorkflowRoutes(router, deps.WorkflowController, deps.AuthService, deps.Idempotency)
+	SetupCalendarRoutes(router, deps.CalendarController, deps.AuthService, deps.Idempotency)
+	SetupCalDAVRoutes(router, deps.CalDAVController, deps.AuthService)
+	SetupWebhookRoutes(router, deps.WebhookController, deps.AuthService, deps.Idempotency)
+	SetupStreamRoutes(router, deps.StreamController, deps.AuthService)
 }
@@ -0,0 +1,356 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules used for
+// recurring todos: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY,
+// COUNT and UNTIL. Occurrences keep the wall-clock time of the start in its
+// location, so a 9am rule stays at 9am across DST changes.
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds expansion so a rule that never matches cannot spin forever
+const maxPeriods = 100000
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry such as "MO", "2TU" or "-1FR". N is zero when
+// the entry means every such weekday in the period.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	name := strings.ToUpper(w.Weekday.String()[:2])
+	if w.N == 0 {
+		return name
+	}
+	return strconv.Itoa(w.N) + name
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    *time.Time
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// A leading "RRULE:" is accepted.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch freq := Frequency(strings.ToUpper(val)); freq {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = freq
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(strings.ToUpper(val), ",") {
+				day, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("numbered BYDAY is only valid for MONTHLY or YEARLY rules")
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	weekday, ok := weekdayNames[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n > 53 || n < -53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+	}
+	return WeekdayNum{N: n, Weekday: weekday}, nil
+}
+
+// String renders the rule in canonical form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after t for a series starting
+// at dtstart, or false when the series has ended.
+func (r *Rule) Next(dtstart, t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if occurrence.After(t) {
+			next, found = occurrence, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Between returns up to limit occurrences within [from, to]
+func (r *Rule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if occurrence.After(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// iterate calls fn with every occurrence in order, starting with dtstart,
+// until fn returns false or the rule's COUNT or UNTIL is reached.
+func (r *Rule) iterate(dtstart time.Time, fn func(time.Time) bool) {
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		if r.Count > 0 && emitted >= r.Count {
+			return false
+		}
+		emitted++
+		return fn(t)
+	}
+
+	// DTSTART is always the first occurrence
+	if !emit(dtstart) {
+		return
+	}
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, candidate := range r.expand(dtstart, period) {
+			if !candidate.After(dtstart) {
+				continue
+			}
+			if !emit(candidate) {
+				return
+			}
+		}
+	}
+}
+
+// expand lists the candidate dates, in order, of the n-th period after dtstart
+func (r *Rule) expand(dtstart time.Time, n int) []time.Time {
+	y, m, d := dtstart.Date()
+	step := n * r.Interval
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+step)
+		if len(r.ByDay) == 0 || r.matchesWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+
+	case Weekly:
+		// Weeks start on Monday, as RFC 5545's default WKST
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := at(y, m, d-offset+7*step)
+		if len(r.ByDay) == 0 {
+			days = append(days, at(y, m, d+7*step))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if r.matchesWeekday(day.Weekday()) {
+				days = append(days, at(day.Year(), day.Month(), day.Day()))
+			}
+		}
+
+	case Monthly:
+		first := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, dtstart.Location())
+		year, month := first.Year(), first.Month()
+		if len(r.ByDay) == 0 {
+			if d <= daysIn(year, month) {
+				days = append(days, at(year, month, d))
+			}
+			break
+		}
+		for _, day := range r.weekdaysInRange(year, month, 1, daysIn(year, month)) {
+			days = append(days, at(year, month, day))
+		}
+
+	case Yearly:
+		year := y + step
+		if len(r.ByDay) == 0 {
+			if d <= daysIn(year, m) {
+				days = append(days, at(year, m, d))
+			}
+			break
+		}
+		for _, yday := range r.weekdaysInRange(year, time.January, 1, daysInYear(year)) {
+			days = append(days, at(year, time.January, yday))
+		}
+	}
+
+	return days
+}
+
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdaysInRange returns the sorted day numbers, counted from the first of
+// month, selected by BYDAY within days first..last.
+func (r *Rule) weekdaysInRange(year int, month time.Month, first, last int) []int {
+	selected := make(map[int]bool)
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, byDay := range r.ByDay {
+		var matches []int
+		for day := first; day <= last; day++ {
+			if start.AddDate(0, 0, day-1).Weekday() == byDay.Weekday {
+				matches = append(matches, day)
+			}
+		}
+
+		switch {
+		case byDay.N == 0:
+			for _, day := range matches {
+				selected[day] = true
+			}
+		case byDay.N > 0 && byDay.N <= len(matches):
+			selected[matches[byDay.N-1]] = true
+		case byDay.N < 0 && -byDay.N <= len(matches):
+			selected[matches[len(matches)+byDay.N]] = true
+		}
+	}
+
+	days := make([]int, 0, len(selected))
+	for day := range selected {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+	return days
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
@@ -0,0 +1,176 @@
+// Package search implements the tokenizing, ranking and highlighting used by
+// todo search. It works on plain values so it can back stores that have no
+// native full-text index.
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	titleWeight       = 3.0
+	descriptionWeight = 1.0
+	// prefixFactor discounts a term that only matches the start of a word
+	prefixFactor = 0.5
+	// DefaultSnippetLength is the rough number of characters kept around the first match
+	DefaultSnippetLength = 120
+)
+
+// Document is the searchable text of a single todo
+type Document struct {
+	Title       string
+	Description string
+}
+
+// Match is a ranked hit, Index refers to the position in the searched slice
+type Match struct {
+	Index int
+	Score float64
+}
+
+// Tokenize lower-cases text and splits it into letter and digit runs
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Rank scores every document against the query and returns the ones that
+// match at least one term, best first. Ties keep their original order.
+func Rank(query string, docs []Document) []Match {
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i, doc := range docs {
+		score := titleWeight*scoreField(terms, Tokenize(doc.Title)) +
+			descriptionWeight*scoreField(terms, Tokenize(doc.Description))
+		if score > 0 {
+			matches = append(matches, Match{Index: i, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// Highlight returns an HTML-escaped excerpt of text around the first query
+// term, with every matching word wrapped in <mark> tags. Text that does not
+// mention any term is returned (truncated) without marks.
+func Highlight(text, query string, maxLen int) string {
+	if text == "" {
+		return ""
+	}
+	if maxLen <= 0 {
+		maxLen = DefaultSnippetLength
+	}
+	terms := uniqueTerms(query)
+	runes := []rune(text)
+
+	// Locate word boundaries so marks never split a word
+	type span struct{ start, end int }
+	var words []span
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		words = append(words, span{i, j})
+		i = j
+	}
+
+	matched := make([]bool, len(words))
+	first := -1
+	for w, sp := range words {
+		word := strings.ToLower(string(runes[sp.start:sp.end]))
+		if termWeight(terms, word) > 0 {
+			matched[w] = true
+			if first == -1 {
+				first = sp.start
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > maxLen {
+		if first > maxLen/4 {
+			start = first - maxLen/4
+		}
+		end = start + maxLen
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxLen
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for w, sp := range words {
+		if !matched[w] || sp.end <= start || sp.start >= end {
+			continue
+		}
+		s, e := max(sp.start, start), min(sp.end, end)
+		b.WriteString(html.EscapeString(string(runes[pos:s])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[s:e])))
+		b.WriteString("</mark>")
+		pos = e
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func uniqueTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func scoreField(terms, tokens []string) float64 {
+	score := 0.0
+	for _, token := range tokens {
+		score += termWeight(terms, token)
+	}
+	return score
+}
+
+// termWeight is 1 for an exact match, prefixFactor for a prefix match
+func termWeight(terms []string, token string) float64 {
+	best := 0.0
+	for _, term := range terms {
+		if token == term {
+			return 1
+		}
+		if strings.HasPrefix(token, term) {
+			best = prefixFactor
+		}
+	}
+	return best
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"net/http"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/jsonpatch"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errBulkAborted rolls back a bulk transaction once one of its items fails
+var errBulkAborted = stderrors.New("bulk operation aborted")
+
+// BulkTodos applies one action to many todos. On deployments with
+// transactions the items run in one transaction and a single failure rolls
+// all of them back; otherwise every item is attempted and failures are
+// reported alongside the successes.
+func (s *todoService) BulkTodos(ctx context.Context, userId string, request *model.TodoBulkRequest) (*model.TodoBulkResponse, error) {
+	response := &model.TodoBulkResponse{}
+
+	var ids []string
+	count := len(request.Todos)
+	if request.Action == "create" {
+		if count == 0 || request.IDs != nil || request.Filter != nil {
+			return nil, errors.ErrInvalidBulk
+		}
+	} else {
+		if count > 0 || (request.IDs == nil) == (request.Filter == nil) ||
+			(request.Action == "update" && len(request.Patch) == 0) {
+			return nil, errors.ErrInvalidBulk
+		}
+
+		ids = request.IDs
+		if request.Filter != nil {
+			matched, err := s.repo.FindIDs(ctx, userId, request.Filter, model.MaxBulkItems+1)
+			if err != nil {
+				return nil, err
+			}
+			if len(matched) > model.MaxBulkItems {
+				matched = matched[:model.MaxBulkItems]
+				response.More = true
+			}
+			ids = make([]string, len(matched))
+			for i, id := range matched {
+				ids[i] = id.Hex()
+			}
+		}
+		count = len(ids)
+	}
+
+	response.Atomic = s.repo.SupportsTransactions(ctx)
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		// A transaction may be retried, so start from scratch every time
+		response.Results = make([]*model.TodoBulkResult, 0, count)
+		for i := 0; i < count; i++ {
+			var id string
+			if ids != nil {
+				id = ids[i]
+			}
+			result := s.bulkItem(ctx, userId, request, i, id)
+			response.Results = append(response.Results, result)
+			if result.Error != "" && response.Atomic {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !stderrors.Is(err, errBulkAborted) {
+		return nil, err
+	}
+
+	if err != nil {
+		// Nothing was applied, so every other item is reported as failed too
+		for i := 0; i < count; i++ {
+			if i == len(response.Results) {
+				response.Results = append(response.Results, &model.TodoBulkResult{Index: i})
+				if ids != nil {
+					response.Results[i].ID = ids[i]
+				}
+			}
+			if result := response.Results[i]; result.Error == "" {
+				result.Status = http.StatusFailedDependency
+				result.Error = "not applied because another item failed"
+				result.Todo = nil
+			}
+		}
+	}
+
+	for _, result := range response.Results {
+		if result.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// bulkItem applies the action of a bulk request to the item at index, which
+// is the todo with the given id unless the action creates todos
+func (s *todoService) bulkItem(ctx context.Context, userId string, request *model.TodoBulkRequest, index int, id string) *model.TodoBulkResult {
+	result := &model.TodoBulkResult{Index: index, ID: id}
+
+	var err error
+	if request.Action == "create" {
+		// Work on a copy so a retried transaction starts from the request
+		todoCreate := request.Todos[index]
+		result.Todo, err = s.CreateTodo(ctx, userId, &todoCreate)
+		result.Status = http.StatusCreated
+	} else if !primitive.IsValidObjectID(id) {
+		err = errors.ErrInvalidID
+	} else {
+		switch request.Action {
+		case "update":
+			result.Todo, err = s.PatchTodo(ctx, id, userId, bulkPatch(request.Patch))
+		case "complete":
+			result.Todo, err = s.setCompleted(ctx, id, userId, request.Completed == nil || *request.Completed)
+		case "delete":
+			err = s.DeleteTodo(ctx, id, userId, request.Cascade == "delete", nil)
+			result.Status = http.StatusNoContent
+		}
+	}
+
+	switch {
+	case err != nil:
+		result.Status = errors.HTTPStatus(err)
+		result.Error = errors.Message(err)
+		result.Todo = nil
+	case result.Status == 0:
+		result.Status = http.StatusOK
+	}
+	if result.Todo != nil {
+		result.ID = result.Todo.ID.Hex()
+	}
+	return result
+}
+
+// bulkPatch reads the patch of a bulk update as a JSON Patch when it is an
+// array and as a merge patch otherwise
+func bulkPatch(document []byte) *model.TodoPatch {
+	patch := &model.TodoPatch{ContentType: jsonpatch.MergePatchContentType, Document: document}
+	if bytes.HasPrefix(bytes.TrimSpace(document), []byte("[")) {
+		patch.ContentType = jsonpatch.JSONPatchContentType
+	}
+	return patch
+}
+
+// setCompleted marks a todo done or not done, leaving its other fields alone
+func (s *todoService) setCompleted(ctx context.Context, id string, userId string, completed bool) (*model.Todo, error) {
+	todo, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	update := todo.Editable()
+	update.Completed = completed
+	return s.UpdateTodo(ctx, id, userId, update)
+}
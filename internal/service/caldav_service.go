@@ -0,0 +1,301 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/ical"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// untitled is the title of todos synced from clients that left SUMMARY out
+const untitled = "Untitled"
+
+// CalDAVService maps lists to calendar collections and todos to the VTODO
+// resources in them. A todo is found by the name its client created it
+// under, or else by "<id>.ics".
+type CalDAVService interface {
+	ListCalendars(ctx context.Context, userId string) ([]*model.List, error)
+	GetCalendar(ctx context.Context, userId string, listId string) (*model.List, error)
+	GetVersion(ctx context.Context, userId string) (*model.TodoListVersion, error)
+	ListTodos(ctx context.Context, userId string, listId string) ([]*model.Todo, error)
+	GetTodo(ctx context.Context, userId string, listId string, name string) (*model.Todo, error)
+	PutTodo(ctx context.Context, userId string, listId string, name string, body io.Reader, expected model.Precondition, mustCreate bool) (*model.Todo, bool, error)
+	DeleteTodo(ctx context.Context, userId string, listId string, name string, expected model.Precondition) error
+	RenderTodos(ctx context.Context, userId string, todos []*model.Todo) ([]string, error)
+}
+
+type calDAVService struct {
+	todoService TodoService
+	repo        repository.TodoRepository
+	listRepo    repository.ListRepository
+	labelRepo   repository.LabelRepository
+	userRepo    repository.UserRepository
+}
+
+func NewCalDAVService(todoService TodoService, repo repository.TodoRepository, listRepo repository.ListRepository, labelRepo repository.LabelRepository, userRepo repository.UserRepository) CalDAVService {
+	return &calDAVService{todoService: todoService, repo: repo, listRepo: listRepo, labelRepo: labelRepo, userRepo: userRepo}
+}
+
+// ResourceName is the name a todo has in its calendar collection
+func ResourceName(todo *model.Todo) string {
+	if todo.DAVName != "" {
+		return todo.DAVName
+	}
+	return todo.ID.Hex() + ".ics"
+}
+
+// ListCalendars returns the lists that are shown as calendars, which are
+// the ones that are not archived
+func (s *calDAVService) ListCalendars(ctx context.Context, userId string) ([]*model.List, error) {
+	return s.listRepo.FindAll(ctx, userId, false)
+}
+
+func (s *calDAVService) GetCalendar(ctx context.Context, userId string, listId string) (*model.List, error) {
+	list, err := s.listRepo.FindByID(ctx, listId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if list.Archived {
+		return nil, errors.ErrListNotFound
+	}
+	return list, nil
+}
+
+// GetVersion changes whenever any todo of the user does, which makes it a
+// conservative CTag for every calendar
+func (s *calDAVService) GetVersion(ctx context.Context, userId string) (*model.TodoListVersion, error) {
+	return s.repo.ListVersion(ctx, userId)
+}
+
+func (s *calDAVService) ListTodos(ctx context.Context, userId string, listId string) ([]*model.Todo, error) {
+	if _, err := s.GetCalendar(ctx, userId, listId); err != nil {
+		return nil, err
+	}
+
+	var todos []*model.Todo
+	err := s.repo.ForEachInList(ctx, userId, listId, func(todo *model.Todo) error {
+		todos = append(todos, todo)
+		return nil
+	})
+	return todos, err
+}
+
+func (s *calDAVService) GetTodo(ctx context.Context, userId string, listId string, name string) (*model.Todo, error) {
+	if _, err := s.GetCalendar(ctx, userId, listId); err != nil {
+		return nil, err
+	}
+	return s.findTodo(ctx, userId, listId, name)
+}
+
+// findTodo finds the todo with the given resource name in a list
+func (s *calDAVService) findTodo(ctx context.Context, userId string, listId string, name string) (*model.Todo, error) {
+	todo, err := s.repo.FindByDAVName(ctx, userId, name)
+	if errors.HTTPStatus(err) == http.StatusNotFound {
+		if id, ok := strings.CutSuffix(name, ".ics"); ok && primitive.IsValidObjectID(id) {
+			todo, err = s.repo.FindByID(ctx, id, userId)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if todo.ListID == nil || todo.ListID.Hex() != listId || ResourceName(todo) != name {
+		return nil, errors.ErrNotFound
+	}
+	return todo, nil
+}
+
+// PutTodo creates or replaces the todo stored under name from the VTODO in
+// body, and reports whether it was created. Recurrence, reminders and the
+// parent of a todo are not synced and are kept as they are. With
+// mustCreate, as sent with If-None-Match: *, an existing todo is not
+// replaced.
+func (s *calDAVService) PutTodo(ctx context.Context, userId string, listId string, name string, body io.Reader, expected model.Precondition, mustCreate bool) (*model.Todo, bool, error) {
+	list, err := s.GetCalendar(ctx, userId, listId)
+	if err != nil {
+		return nil, false, err
+	}
+	location, err := s.userLocation(ctx, userId)
+	if err != nil {
+		return nil, false, err
+	}
+	item, err := ical.ReadTodo(body, location)
+	if err != nil {
+		return nil, false, errors.NewAPIError(http.StatusBadRequest, "INVALID_CALENDAR_DATA", err.Error())
+	}
+	if item.Title == "" {
+		item.Title = untitled
+	}
+	labels, err := s.labelIDs(ctx, userId, item.Categories)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current, err := s.findTodo(ctx, userId, listId, name)
+	if err != nil && errors.HTTPStatus(err) != http.StatusNotFound {
+		return nil, false, err
+	}
+
+	if current == nil {
+		// If-Match names a version of a todo that is not there
+		if expected != nil {
+			return nil, false, errors.ErrPreconditionFailed
+		}
+		create := &model.TodoCreate{
+			Title:       item.Title,
+			Description: item.Description,
+			Completed:   &item.Completed,
+			DueAt:       item.Due,
+			Labels:      labels,
+			ListID:      &list.ID,
+			ICalUID:     item.UID,
+			DAVName:     name,
+		}
+		todo, err := s.todoService.CreateTodo(ctx, userId, create)
+		return todo, true, err
+	}
+
+	if mustCreate {
+		return nil, false, errors.ErrPreconditionFailed
+	}
+	update := current.Editable()
+	update.Title = item.Title
+	update.Description = item.Description
+	update.Completed = item.Completed
+	update.DueAt = item.Due
+	update.IfMatch = expected
+	todo, err := s.todoService.UpdateTodo(ctx, current.ID.Hex(), userId, update)
+	if err != nil {
+		return nil, false, err
+	}
+
+	todo, err = s.syncLabels(ctx, userId, todo, labels)
+	return todo, false, err
+}
+
+// syncLabels attaches and detaches labels until todo has exactly labels
+func (s *calDAVService) syncLabels(ctx context.Context, userId string, todo *model.Todo, labels []primitive.ObjectID) (*model.Todo, error) {
+	wanted := make(map[primitive.ObjectID]bool, len(labels))
+	for _, id := range labels {
+		wanted[id] = true
+	}
+	has := make(map[primitive.ObjectID]bool, len(todo.Labels))
+	for _, id := range todo.Labels {
+		has[id] = true
+	}
+
+	var err error
+	for id := range has {
+		if !wanted[id] {
+			if todo, err = s.todoService.DetachLabel(ctx, todo.ID.Hex(), userId, id.Hex()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, id := range labels {
+		if !has[id] {
+			if todo, err = s.todoService.AttachLabel(ctx, todo.ID.Hex(), userId, id.Hex()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return todo, nil
+}
+
+// labelIDs finds the labels with the given names, creating the missing ones
+func (s *calDAVService) labelIDs(ctx context.Context, userId string, names []string) ([]primitive.ObjectID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	labels, err := s.labelRepo.FindAll(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]primitive.ObjectID, len(labels))
+	for _, label := range labels {
+		byKey[label.NameKey] = label.ID
+	}
+
+	var ids []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for _, name := range names {
+		id, ok := byKey[nameKey(name)]
+		if !ok {
+			labelCreate := &model.LabelCreate{Name: strings.TrimSpace(name)}
+			if err := patchValidator.Struct(labelCreate); err != nil {
+				return nil, errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_LABEL", err.Error())
+			}
+			label, err := s.labelRepo.Create(ctx, userId, labelCreate)
+			if err != nil {
+				return nil, err
+			}
+			id = label.ID
+			byKey[nameKey(name)] = id
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteTodo moves the todo stored under name to the trash. Its subtasks
+// move to the top level of the list.
+func (s *calDAVService) DeleteTodo(ctx context.Context, userId string, listId string, name string, expected model.Precondition) error {
+	todo, err := s.GetTodo(ctx, userId, listId, name)
+	if err != nil {
+		return err
+	}
+	return s.todoService.DeleteTodo(ctx, todo.ID.Hex(), userId, false, expected)
+}
+
+// RenderTodos writes each todo as an iCalendar object holding its VTODO
+func (s *calDAVService) RenderTodos(ctx context.Context, userId string, todos []*model.Todo) ([]string, error) {
+	labels, err := s.labelRepo.FindAll(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	labelNames := make(map[primitive.ObjectID]string, len(labels))
+	for _, label := range labels {
+		labelNames[label.ID] = label.Name
+	}
+	locations := map[string]*time.Location{}
+
+	objects := make([]string, len(todos))
+	for i, todo := range todos {
+		var buf bytes.Buffer
+		cal := ical.NewWriter(&buf)
+		cal.BeginObject()
+		cal.Todo(calendarItem(todo, labelNames, locations))
+		cal.EndCalendar()
+		if err := cal.Err(); err != nil {
+			return nil, err
+		}
+		objects[i] = buf.String()
+	}
+	return objects, nil
+}
+
+// userLocation is the time zone dates sent by clients are read in
+func (s *calDAVService) userLocation(ctx context.Context, userId string) (*time.Location, error) {
+	user, err := s.userRepo.FindByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.TimeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return location, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"time"
+	"todo-app/internal/ical"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// calendarName is what calendar apps call a subscribed feed until the user
+// renames it
+const calendarName = "Todos"
+
+type CalendarService interface {
+	WriteCalendar(ctx context.Context, userId string, query *model.CalendarQuery, w io.Writer) error
+	GetVersion(ctx context.Context, userId string) (*model.TodoListVersion, error)
+	GetFeed(ctx context.Context, userId string) (*model.CalendarFeed, error)
+	CreateFeed(ctx context.Context, userId string) (*model.CalendarFeed, error)
+	RevokeFeed(ctx context.Context, userId string) error
+	FindFeedOwner(ctx context.Context, token string) (string, error)
+}
+
+type calendarService struct {
+	repo      repository.TodoRepository
+	labelRepo repository.LabelRepository
+	feedRepo  repository.CalendarFeedRepository
+}
+
+func NewCalendarService(repo repository.TodoRepository, labelRepo repository.LabelRepository, feedRepo repository.CalendarFeedRepository) CalendarService {
+	return &calendarService{repo: repo, labelRepo: labelRepo, feedRepo: feedRepo}
+}
+
+// WriteCalendar writes the user's live todos to w as an iCalendar object:
+// each as a VTODO and those with a due date also as a VEVENT, unless the
+// query asks for one kind only
+func (s *calendarService) WriteCalendar(ctx context.Context, userId string, query *model.CalendarQuery, w io.Writer) error {
+	labels, err := s.labelRepo.FindAll(ctx, userId)
+	if err != nil {
+		return err
+	}
+	labelNames := make(map[primitive.ObjectID]string, len(labels))
+	for _, label := range labels {
+		labelNames[label.ID] = label.Name
+	}
+	locations := map[string]*time.Location{}
+
+	cal := ical.NewWriter(w)
+	cal.BeginCalendar(calendarName)
+	err = s.repo.ForEach(ctx, userId, func(todo *model.Todo) error {
+		if query.Completed != nil && todo.Completed != *query.Completed {
+			return nil
+		}
+
+		item := calendarItem(todo, labelNames, locations)
+		if query.Component != model.CalendarEvents {
+			cal.Todo(item)
+		}
+		if query.Component != model.CalendarTodos {
+			cal.Event(item)
+		}
+		return cal.Err()
+	})
+	if err != nil {
+		return err
+	}
+	cal.EndCalendar()
+	return cal.Err()
+}
+
+// calendarItem is how calendars show a todo. Time zones are loaded once per
+// calendar through locations.
+func calendarItem(todo *model.Todo, labelNames map[primitive.ObjectID]string, locations map[string]*time.Location) *ical.Item {
+	item := &ical.Item{
+		ID:          todo.ID.Hex(),
+		UID:         todo.ICalUID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Created:     todo.CreatedAt,
+		Modified:    todo.UpdatedAt,
+		Due:         todo.DueAt,
+	}
+	if todo.Completed {
+		item.CompletedAt = todo.CompletionTime()
+	}
+	if todo.ParentID != nil {
+		item.ParentID = todo.ParentID.Hex()
+	}
+	for _, labelID := range todo.Labels {
+		if name, ok := labelNames[labelID]; ok {
+			item.Categories = append(item.Categories, name)
+		}
+	}
+	for _, reminder := range todo.Reminders {
+		item.Alarms = append(item.Alarms, reminder.RemindAt)
+	}
+
+	if todo.DueAt != nil {
+		location, ok := locations[todo.TimeZone]
+		if !ok {
+			location = time.UTC
+			if loaded, err := time.LoadLocation(todo.TimeZone); err == nil {
+				location = loaded
+			}
+			locations[todo.TimeZone] = location
+		}
+		due := todo.DueAt.In(location)
+		item.Location = location
+		item.AllDay = due.Hour() == 0 && due.Minute() == 0 && due.Second() == 0
+	}
+	return item
+}
+
+// GetVersion tells when any of the user's todos last changed, so calendar
+// clients polling a feed can be answered with 304
+func (s *calendarService) GetVersion(ctx context.Context, userId string) (*model.TodoListVersion, error) {
+	return s.repo.ListVersion(ctx, userId)
+}
+
+func (s *calendarService) GetFeed(ctx context.Context, userId string) (*model.CalendarFeed, error) {
+	return s.feedRepo.FindByUser(ctx, userId)
+}
+
+// CreateFeed gives the user a feed with a new secret token, revoking any
+// feed they had
+func (s *calendarService) CreateFeed(ctx context.Context, userId string) (*model.CalendarFeed, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	feed, err := s.feedRepo.Replace(ctx, userId, hashFeedToken(token))
+	if err != nil {
+		return nil, err
+	}
+	feed.Token = token
+	return feed, nil
+}
+
+func (s *calendarService) RevokeFeed(ctx context.Context, userId string) error {
+	return s.feedRepo.Delete(ctx, userId)
+}
+
+// FindFeedOwner returns the ID of the user a feed token belongs to
+func (s *calendarService) FindFeedOwner(ctx context.Context, token string) (string, error) {
+	feed, err := s.feedRepo.FindByTokenHash(ctx, hashFeedToken(token))
+	if err != nil {
+		return "", err
+	}
+	return feed.UserID.Hex(), nil
+}
+
+// hashFeedToken is what is stored of a feed token. The token is random and
+// long, so a plain hash is enough to keep a leaked database from giving
+// away working feed addresses.
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
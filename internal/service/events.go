@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"time"
+	"todo-app/internal/model"
+)
+
+// EventPublisher is told about every change to a todo. It is called inside
+// the transaction of the change, so an error undoes the change.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...*model.TodoEvent) error
+}
+
+// EventBroadcaster is told about every change to a todo once it has been
+// committed, and must not block
+type EventBroadcaster interface {
+	Broadcast(events ...*model.TodoEvent)
+}
+
+type pendingEventsKey struct{}
+
+// pendingEvents collects the events of a transaction until it commits
+type pendingEvents struct {
+	events []*model.TodoEvent
+}
+
+// inTransaction runs fn in a transaction, like the repository does, and
+// broadcasts the events it published once the outermost one has committed.
+// Nothing is broadcast for a change that is rolled back.
+func (s *todoService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, nested := ctx.Value(pendingEventsKey{}).(*pendingEvents); nested {
+		return s.repo.RunInTransaction(ctx, fn)
+	}
+
+	pending := &pendingEvents{}
+	ctx = context.WithValue(ctx, pendingEventsKey{}, pending)
+	err := s.repo.RunInTransaction(ctx, func(ctx context.Context) error {
+		// A transaction may be retried, so start from scratch every time
+		pending.events = nil
+		return fn(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if s.bus != nil && len(pending.events) > 0 {
+		s.bus.Broadcast(pending.events...)
+	}
+	return nil
+}
+
+// publish announces a change recorded as a revision with the given action.
+// Completing a todo is announced both as an update and as a completion.
+// Restoring one from the trash announces it as created again, since it was
+// announced as deleted when it was trashed.
+func (s *todoService) publish(ctx context.Context, action string, before, after *model.Todo) error {
+	if s.events == nil && s.bus == nil {
+		return nil
+	}
+
+	now := time.Now()
+	event := func(eventType string, todo *model.Todo) *model.TodoEvent {
+		return &model.TodoEvent{Type: eventType, UserID: todo.UserID, Todo: todo, OccurredAt: now}
+	}
+
+	var events []*model.TodoEvent
+	switch action {
+	case model.RevisionCreate, model.RevisionRestore:
+		events = append(events, event(model.EventTodoCreated, after))
+	case model.RevisionDelete:
+		events = append(events, event(model.EventTodoDeleted, before))
+	default:
+		events = append(events, event(model.EventTodoUpdated, after))
+		if before != nil && !before.Completed && after.Completed {
+			events = append(events, event(model.EventTodoCompleted, after))
+		}
+	}
+
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents); ok {
+		pending.events = append(pending.events, events...)
+	} else if s.bus != nil {
+		s.bus.Broadcast(events...)
+	}
+
+	if s.events == nil {
+		return nil
+	}
+	return s.events.Publish(ctx, events...)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+type LabelService interface {
+	CreateLabel(ctx context.Context, userId string, label *model.LabelCreate) (*model.Label, error)
+	GetLabel(ctx context.Context, id string, userId string) (*model.Label, error)
+	GetAllLabels(ctx context.Context, userId string) ([]*model.Label, error)
+	UpdateLabel(ctx context.Context, id string, userId string, label *model.LabelUpdate) (*model.Label, error)
+	DeleteLabel(ctx context.Context, id string, userId string) error
+	MergeLabel(ctx context.Context, id string, userId string, merge *model.LabelMerge) (*model.Label, error)
+}
+
+type labelService struct {
+	repo repository.LabelRepository
+}
+
+func NewLabelService(repo repository.LabelRepository) LabelService {
+	return &labelService{repo: repo}
+}
+
+func (s *labelService) CreateLabel(ctx context.Context, userId string, label *model.LabelCreate) (*model.Label, error) {
+	return s.repo.Create(ctx, userId, label)
+}
+
+func (s *labelService) GetLabel(ctx context.Context, id string, userId string) (*model.Label, error) {
+	return s.repo.FindByID(ctx, id, userId)
+}
+
+func (s *labelService) GetAllLabels(ctx context.Context, userId string) ([]*model.Label, error) {
+	return s.repo.FindAll(ctx, userId)
+}
+
+func (s *labelService) UpdateLabel(ctx context.Context, id string, userId string, label *model.LabelUpdate) (*model.Label, error) {
+	return s.repo.Update(ctx, id, userId, label)
+}
+
+func (s *labelService) DeleteLabel(ctx context.Context, id string, userId string) error {
+	return s.repo.Delete(ctx, id, userId)
+}
+
+func (s *labelService) MergeLabel(ctx context.Context, id string, userId string, merge *model.LabelMerge) (*model.Label, error) {
+	return s.repo.Merge(ctx, id, userId, merge.TargetID)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+type ListService interface {
+	CreateList(ctx context.Context, userId string, list *model.ListCreate) (*model.List, error)
+	GetList(ctx context.Context, id string, userId string) (*model.List, error)
+	GetAllLists(ctx context.Context, userId string, query *model.ListQuery) ([]*model.List, error)
+	GetListTodos(ctx context.Context, id string, userId string, query *model.TodoQuery) (*model.TodoPage, error)
+	UpdateList(ctx context.Context, id string, userId string, list *model.ListUpdate) (*model.List, error)
+	DeleteList(ctx context.Context, id string, userId string, query *model.ListDeleteQuery) error
+}
+
+type listService struct {
+	repo        repository.ListRepository
+	todoService TodoService
+}
+
+func NewListService(repo repository.ListRepository, todoService TodoService) ListService {
+	return &listService{repo: repo, todoService: todoService}
+}
+
+func (s *listService) CreateList(ctx context.Context, userId string, list *model.ListCreate) (*model.List, error) {
+	return s.repo.Create(ctx, userId, list)
+}
+
+func (s *listService) GetList(ctx context.Context, id string, userId string) (*model.List, error) {
+	return s.repo.FindByID(ctx, id, userId)
+}
+
+func (s *listService) GetAllLists(ctx context.Context, userId string, query *model.ListQuery) ([]*model.List, error) {
+	// Make sure users who predate lists see their Inbox
+	if _, err := s.repo.EnsureInbox(ctx, userId); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAll(ctx, userId, query.Archived)
+}
+
+func (s *listService) GetListTodos(ctx context.Context, id string, userId string, query *model.TodoQuery) (*model.TodoPage, error) {
+	list, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	query.ListID = &list.ID
+	return s.todoService.ListTodos(ctx, userId, query)
+}
+
+func (s *listService) UpdateList(ctx context.Context, id string, userId string, list *model.ListUpdate) (*model.List, error) {
+	return s.repo.Update(ctx, id, userId, list)
+}
+
+func (s *listService) DeleteList(ctx context.Context, id string, userId string, query *model.ListDeleteQuery) error {
+	return s.repo.Delete(ctx, id, userId, query.Todos == "delete")
+}
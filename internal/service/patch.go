@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/pkg/jsonpatch"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// patchValidator checks patched documents against the same binding tags the
+// request handlers validate with
+var patchValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+}()
+
+// PatchTodo applies a patch to the editable fields of a todo and stores the
+// result as a full update
+func (s *todoService) PatchTodo(ctx context.Context, id string, userId string, patch *model.TodoPatch) (*model.Todo, error) {
+	current, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var update model.TodoUpdate
+	if err := applyPatch(patch, current.Editable(), &update); err != nil {
+		return nil, err
+	}
+	update.IfMatch = patch.IfMatch
+	return s.UpdateTodo(ctx, id, userId, &update)
+}
+
+// applyPatch applies patch to current, using the format named by its content
+// type, and decodes the result into target. Fields that target does not know
+// about are rejected, so a patch cannot touch read-only fields, and the
+// result must pass target's validation.
+func applyPatch(patch *model.TodoPatch, current, target interface{}) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch patch.ContentType {
+	case jsonpatch.MergePatchContentType:
+		patched, err = jsonpatch.MergePatch(doc, patch.Document)
+	case jsonpatch.JSONPatchContentType:
+		patched, err = jsonpatch.Apply(doc, patch.Document)
+	default:
+		return errors.NewAPIError(http.StatusUnsupportedMediaType, "UNSUPPORTED_PATCH", fmt.Sprintf(
+			"unsupported patch format %q, use %s or %s", patch.ContentType,
+			jsonpatch.MergePatchContentType, jsonpatch.JSONPatchContentType))
+	}
+	switch {
+	case stderrors.Is(err, jsonpatch.ErrTestFailed):
+		return errors.NewAPIError(http.StatusConflict, "PATCH_TEST_FAILED", err.Error())
+	case err != nil:
+		return errors.NewAPIError(http.StatusBadRequest, "INVALID_PATCH", err.Error())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_TODO", err.Error())
+	}
+	if err := patchValidator.Struct(target); err != nil {
+		return errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_TODO", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/portability"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportTodos streams every live todo of a user to w in format. Subtasks
+// are exported as todos of their own.
+func (s *todoService) ExportTodos(ctx context.Context, userId string, format string, w io.Writer) error {
+	labels, err := s.labelRepo.FindAll(ctx, userId)
+	if err != nil {
+		return err
+	}
+	labelNames := make(map[primitive.ObjectID]string, len(labels))
+	for _, label := range labels {
+		labelNames[label.ID] = label.Name
+	}
+	lists, err := s.listRepo.FindAll(ctx, userId, true)
+	if err != nil {
+		return err
+	}
+	listNames := make(map[primitive.ObjectID]string, len(lists))
+	for _, list := range lists {
+		listNames[list.ID] = list.Name
+	}
+
+	writer, err := portability.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+	err = s.repo.ForEach(ctx, userId, func(todo *model.Todo) error {
+		createdAt, updatedAt := todo.CreatedAt, todo.UpdatedAt
+		record := &model.TodoRecord{
+			ID:          todo.ID.Hex(),
+			Title:       todo.Title,
+			Description: todo.Description,
+			Completed:   todo.Completed,
+			DueAt:       todo.DueAt,
+			Recurrence:  todo.Recurrence,
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &updatedAt,
+		}
+		if todo.ListID != nil {
+			record.List = listNames[*todo.ListID]
+		}
+		for _, labelID := range todo.Labels {
+			if name, ok := labelNames[labelID]; ok {
+				record.Labels = append(record.Labels, name)
+			}
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// ImportTodos creates a todo for every record read from r. Records naming a
+// todo the user already has, by exported id or by title and due date, are
+// skipped, and records that cannot be read or stored are rejected with
+// their line. Lists and labels are matched by name and created when
+// missing. With dryRun nothing is stored and the result tells what would
+// happen.
+func (s *todoService) ImportTodos(ctx context.Context, userId string, format string, r io.Reader, dryRun bool) (*model.TodoImportResult, error) {
+	entries, err := portability.Read(format, r)
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		return nil, errors.ErrImportTooLarge
+	}
+	if err != nil {
+		return nil, errors.NewAPIError(http.StatusBadRequest, "INVALID_IMPORT", err.Error())
+	}
+	if len(entries) > model.MaxImportItems {
+		return nil, errors.ErrImportTooLarge
+	}
+
+	importer, err := s.newImporter(ctx, userId, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.TodoImportResult{DryRun: dryRun, Items: make([]*model.TodoImportItem, 0, len(entries))}
+	for _, entry := range entries {
+		item := &model.TodoImportItem{Line: entry.Line}
+		if entry.Record != nil {
+			item.Title = entry.Record.Title
+		}
+		if entry.Err == nil {
+			importer.add(ctx, entry.Record, item)
+		} else {
+			item.Status, item.Error = model.ImportRejected, entry.Err.Error()
+		}
+
+		switch item.Status {
+		case model.ImportCreated:
+			result.Created++
+		case model.ImportSkipped:
+			result.Skipped++
+		default:
+			result.Rejected++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// importer holds what an import matches its records against
+type importer struct {
+	service *todoService
+	userId  string
+	dryRun  bool
+	// IDs of the todos by exported id and by title and due date
+	ids    map[string]bool
+	titles map[string]string
+	labels map[string]primitive.ObjectID
+	lists  map[string]*model.List
+}
+
+func (s *todoService) newImporter(ctx context.Context, userId string, dryRun bool) (*importer, error) {
+	im := &importer{
+		service: s,
+		userId:  userId,
+		dryRun:  dryRun,
+		ids:     map[string]bool{},
+		titles:  map[string]string{},
+		labels:  map[string]primitive.ObjectID{},
+		lists:   map[string]*model.List{},
+	}
+
+	err := s.repo.ForEach(ctx, userId, func(todo *model.Todo) error {
+		im.ids[todo.ID.Hex()] = true
+		im.titles[importKey(todo.Title, todo.DueAt)] = todo.ID.Hex()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.labelRepo.FindAll(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		im.labels[label.NameKey] = label.ID
+	}
+	lists, err := s.listRepo.FindAll(ctx, userId, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if key := nameKey(list.Name); im.lists[key] == nil {
+			im.lists[key] = list
+		}
+	}
+	return im, nil
+}
+
+// add imports one record and fills in the outcome on item
+func (im *importer) add(ctx context.Context, record *model.TodoRecord, item *model.TodoImportItem) {
+	key := importKey(record.Title, record.DueAt)
+	if id, ok := im.titles[key]; ok {
+		item.Status, item.ID = model.ImportSkipped, id
+		return
+	}
+	if im.ids[record.ID] {
+		item.Status, item.ID = model.ImportSkipped, record.ID
+		return
+	}
+
+	completed := record.Completed
+	create := &model.TodoCreate{
+		Title:       strings.TrimSpace(record.Title),
+		Description: record.Description,
+		Completed:   &completed,
+		DueAt:       record.DueAt,
+		Recurrence:  record.Recurrence,
+	}
+	id, err := im.create(ctx, record, create)
+	if err != nil {
+		item.Status, item.Error = model.ImportRejected, errors.Message(err)
+		return
+	}
+
+	item.Status, item.ID = model.ImportCreated, id
+	im.titles[key] = id
+	if id != "" {
+		im.ids[id] = true
+	}
+}
+
+// create stores a todo, or in a dry run only checks that it could be
+// stored, and returns its id
+func (im *importer) create(ctx context.Context, record *model.TodoRecord, create *model.TodoCreate) (string, error) {
+	if err := patchValidator.Struct(create); err != nil {
+		return "", errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_TODO", err.Error())
+	}
+	check := *create
+	if err := prepareRecurrence(&check); err != nil {
+		return "", err
+	}
+
+	if record.List != "" {
+		list, err := im.list(ctx, record.List)
+		if err != nil {
+			return "", err
+		}
+		if list != nil {
+			create.ListID = &list.ID
+		}
+	}
+	for _, name := range record.Labels {
+		labelID, err := im.label(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if !labelID.IsZero() {
+			create.Labels = append(create.Labels, labelID)
+		}
+	}
+	if im.dryRun {
+		return "", nil
+	}
+
+	todo, err := im.service.CreateTodo(ctx, im.userId, create)
+	if err != nil {
+		return "", err
+	}
+	return todo.ID.Hex(), nil
+}
+
+// list finds the list with the given name, creating it unless this is a
+// dry run. A dry run returns nil for lists it would create.
+func (im *importer) list(ctx context.Context, name string) (*model.List, error) {
+	key := nameKey(name)
+	if list, ok := im.lists[key]; ok {
+		if list.Archived {
+			return nil, errors.ErrListArchived
+		}
+		return list, nil
+	}
+
+	listCreate := &model.ListCreate{Name: strings.TrimSpace(name)}
+	if err := patchValidator.Struct(listCreate); err != nil {
+		return nil, errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_LIST", err.Error())
+	}
+	if im.dryRun {
+		return nil, nil
+	}
+	list, err := im.service.listRepo.Create(ctx, im.userId, listCreate)
+	if err != nil {
+		return nil, err
+	}
+	im.lists[key] = list
+	return list, nil
+}
+
+// label finds the label with the given name, creating it unless this is a
+// dry run. A dry run returns a zero id for labels it would create.
+func (im *importer) label(ctx context.Context, name string) (primitive.ObjectID, error) {
+	key := nameKey(name)
+	if id, ok := im.labels[key]; ok {
+		return id, nil
+	}
+
+	labelCreate := &model.LabelCreate{Name: strings.TrimSpace(name)}
+	if err := patchValidator.Struct(labelCreate); err != nil {
+		return primitive.NilObjectID, errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_LABEL", err.Error())
+	}
+	if im.dryRun {
+		return primitive.NilObjectID, nil
+	}
+	label, err := im.service.labelRepo.Create(ctx, im.userId, labelCreate)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	im.labels[key] = label.ID
+	return label.ID, nil
+}
+
+// importKey identifies a todo for duplicate detection by its title and due
+// date
+func importKey(title string, dueAt *time.Time) string {
+	key := nameKey(title)
+	if dueAt != nil {
+		key += "\x00" + dueAt.UTC().Format(time.RFC3339)
+	}
+	return key
+}
+
+// nameKey compares names the way label names are compared: ignoring case
+// and surrounding space
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
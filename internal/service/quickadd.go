@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/quickadd"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuickAddTodo reads a todo from a line of text in the user's time zone and,
+// unless this is a preview, creates it. Labels are matched by name, ignoring
+// case, and created when missing.
+func (s *todoService) QuickAddTodo(ctx context.Context, userId string, quickAdd *model.QuickAdd, preview bool) (*model.QuickAddResult, error) {
+	timeZone, err := s.userTimeZone(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	parsed := quickadd.Parse(quickAdd.Text, time.Now(), loc)
+	result := &model.QuickAddResult{Parsed: parsed}
+	if preview {
+		return result, nil
+	}
+	if parsed.Title == "" {
+		return nil, errors.ErrQuickAddNoTitle
+	}
+
+	result.Todo, err = s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		labels, err := s.labelsNamed(ctx, userId, parsed.Labels)
+		if err != nil {
+			return nil, err
+		}
+		return s.CreateTodo(ctx, userId, &model.TodoCreate{
+			Title:      parsed.Title,
+			DueAt:      parsed.DueAt,
+			Priority:   parsed.Priority,
+			Recurrence: parsed.Recurrence,
+			Labels:     labels,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// labelsNamed returns the IDs of the user's labels with the given names,
+// creating those that do not exist yet
+func (s *todoService) labelsNamed(ctx context.Context, userId string, names []string) ([]primitive.ObjectID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	existing, err := s.labelRepo.FindByNames(ctx, userId, names)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]primitive.ObjectID, len(existing))
+	for _, label := range existing {
+		found[label.NameKey] = label.ID
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(names))
+	for _, name := range names {
+		id, ok := found[nameKey(name)]
+		if !ok {
+			labelCreate := &model.LabelCreate{Name: strings.TrimSpace(name)}
+			if err := patchValidator.Struct(labelCreate); err != nil {
+				return nil, errors.NewAPIError(http.StatusUnprocessableEntity, "INVALID_LABEL", err.Error())
+			}
+			label, err := s.labelRepo.Create(ctx, userId, labelCreate)
+			if err != nil {
+				return nil, err
+			}
+			id = label.ID
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/rrule"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultOccurrenceWindow is previewed when no end is requested
+	defaultOccurrenceWindow = 90 * 24 * time.Hour
+	defaultOccurrenceLimit  = 50
+)
+
+// prepareRecurrence validates and normalizes the rule of a new todo and
+// anchors the series at its due date.
+func prepareRecurrence(todoCreate *model.TodoCreate) error {
+	recurrence, err := normalizeRecurrence(todoCreate.Recurrence, todoCreate.DueAt)
+	if err != nil {
+		return err
+	}
+
+	todoCreate.Recurrence = recurrence
+	if recurrence != "" && todoCreate.RecurrenceStart == nil {
+		todoCreate.RecurrenceStart = todoCreate.DueAt
+	}
+	return nil
+}
+
+// prepareUpdatedRecurrence does the same for a replaced todo. The series
+// keeps its anchor while the rule is unchanged and restarts at the due date
+// when the rule changes.
+func prepareUpdatedRecurrence(update *model.TodoUpdate, existing *model.Todo) error {
+	recurrence, err := normalizeRecurrence(update.Recurrence, update.DueAt)
+	if err != nil {
+		return err
+	}
+
+	update.Recurrence = recurrence
+	switch {
+	case recurrence == "":
+		update.RecurrenceStart = nil
+	case recurrence == existing.Recurrence && existing.RecurrenceStart != nil:
+		update.RecurrenceStart = existing.RecurrenceStart
+	default:
+		update.RecurrenceStart = update.DueAt
+	}
+	return nil
+}
+
+// normalizeRecurrence returns a rule in canonical form. Recurring todos need
+// a due date to anchor the series.
+func normalizeRecurrence(recurrence string, dueAt *time.Time) (string, error) {
+	if recurrence == "" {
+		return "", nil
+	}
+
+	rule, err := rrule.Parse(recurrence)
+	if err != nil {
+		return "", errors.NewAPIError(http.StatusBadRequest, "INVALID_RECURRENCE", err.Error())
+	}
+	if dueAt == nil {
+		return "", errors.NewAPIError(http.StatusBadRequest, "INVALID_RECURRENCE", "recurring todos require a due date")
+	}
+	return rule.String(), nil
+}
+
+// spawnNextOccurrence creates the follow-up of a recurring todo that has just
+// been completed. The next ID is claimed on the completed todo first, so
+// completing the same todo twice never produces two follow-ups.
+func (s *todoService) spawnNextOccurrence(ctx context.Context, todo *model.Todo) error {
+	if todo.Recurrence == "" || todo.DueAt == nil || todo.NextOccurrenceID != nil {
+		return nil
+	}
+
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return fmt.Errorf("stored recurrence of todo %s is invalid: %w", todo.ID.Hex(), err)
+	}
+
+	loc := loadLocation(todo.TimeZone)
+	start := *todo.DueAt
+	if todo.RecurrenceStart != nil {
+		start = *todo.RecurrenceStart
+	}
+
+	next, ok := rule.Next(start.In(loc), *todo.DueAt)
+	if !ok {
+		return nil
+	}
+	due := next.UTC()
+
+	nextID := primitive.NewObjectID()
+	claimed, err := s.repo.ClaimNextOccurrence(ctx, todo.ID.Hex(), todo.UserID.Hex(), nextID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	reminders, err := buildReminders(&due, todo.ReminderOffsets(), todo.TimeZone, nil)
+	if err != nil {
+		return err
+	}
+	workflow, err := s.workflowFor(ctx, todo.UserID.Hex(), todo.ListID)
+	if err != nil {
+		return err
+	}
+
+	ctxWithUserId := context.WithValue(ctx, "userId", todo.UserID)
+	spawned, err := s.repo.Create(ctxWithUserId, &model.TodoCreate{
+		ID:              nextID,
+		Title:           todo.Title,
+		Description:     todo.Description,
+		Status:          workflow.FirstState(false),
+		Priority:        todo.Priority,
+		DueAt:           &due,
+		Recurrence:      todo.Recurrence,
+		RecurrenceStart: &start,
+		TimeZone:        todo.TimeZone,
+		Reminders:       reminders,
+		ParentID:        todo.ParentID,
+		Labels:          todo.Labels,
+		ListID:          todo.ListID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.record(ctx, todo.UserID.Hex(), model.RevisionCreate, nil, spawned, 0); err != nil {
+		return err
+	}
+
+	todo.NextOccurrenceID = &nextID
+	return nil
+}
+
+func (s *todoService) GetOccurrences(ctx context.Context, id string, userId string, query *model.TodoOccurrencesQuery) (*model.TodoOccurrences, error) {
+	todo, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return nil, errors.NewAPIError(http.StatusBadRequest, "NOT_RECURRING", "todo is not recurring")
+	}
+
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now()
+	if query.From != nil {
+		from = *query.From
+	}
+	to := from.Add(defaultOccurrenceWindow)
+	if query.To != nil {
+		to = *query.To
+	}
+	if to.Before(from) {
+		return nil, errors.NewAPIError(http.StatusBadRequest, "INVALID_RANGE", "to must not be before from")
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultOccurrenceLimit
+	}
+
+	loc := loadLocation(todo.TimeZone)
+	start := *todo.DueAt
+	if todo.RecurrenceStart != nil {
+		start = *todo.RecurrenceStart
+	}
+
+	occurrences := rule.Between(start.In(loc), from, to, limit)
+	if occurrences == nil {
+		occurrences = []time.Time{}
+	}
+	return &model.TodoOccurrences{
+		Recurrence:  todo.Recurrence,
+		Occurrences: occurrences,
+	}, nil
+}
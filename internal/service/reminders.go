@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reminderOffset is a parsed reminder offset. Weeks and days are kept apart
+// from the clock part because they move by calendar days in the todo's time
+// zone, while hours and minutes are exact durations.
+type reminderOffset struct {
+	days     int
+	duration time.Duration
+}
+
+// parseReminderOffset parses offsets such as "-1d", "-1w2d", "-2h30m", "+15m" or "0"
+func parseReminderOffset(value string) (reminderOffset, error) {
+	var offset reminderOffset
+	if value == "0" {
+		return offset, nil
+	}
+
+	s := value
+	sign := 1
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if s == "" {
+		return offset, invalidReminderError(value)
+	}
+
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return offset, invalidReminderError(value)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return offset, invalidReminderError(value)
+		}
+
+		switch s[i] {
+		case 'w':
+			offset.days += 7 * n
+		case 'd':
+			offset.days += n
+		case 'h':
+			offset.duration += time.Duration(n) * time.Hour
+		case 'm':
+			offset.duration += time.Duration(n) * time.Minute
+		default:
+			return offset, invalidReminderError(value)
+		}
+		s = s[i+1:]
+	}
+
+	offset.days *= sign
+	offset.duration *= time.Duration(sign)
+	return offset, nil
+}
+
+// apply shifts due by the offset, counting days on the wall clock of loc
+func (o reminderOffset) apply(due time.Time, loc *time.Location) time.Time {
+	local := due.In(loc)
+	return local.AddDate(0, 0, o.days).Add(o.duration).UTC().Truncate(time.Millisecond)
+}
+
+func invalidReminderError(value string) error {
+	return errors.NewAPIError(http.StatusBadRequest, "INVALID_REMINDER",
+		fmt.Sprintf("invalid reminder offset %q", value))
+}
+
+// loadLocation resolves an IANA zone name, treating an empty name as UTC
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// buildReminders computes the reminders for a due date. Reminders for the
+// same offset and time keep their delivery record so that rescheduling an
+// unrelated field never re-sends them or resets their retries.
+func buildReminders(due *time.Time, offsets []string, timeZone string, existing []model.Reminder) ([]model.Reminder, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+	if due == nil {
+		return nil, errors.NewAPIError(http.StatusBadRequest, "INVALID_REMINDER", "reminders require a due date")
+	}
+
+	loc := loadLocation(timeZone)
+	reminders := make([]model.Reminder, 0, len(offsets))
+	seen := make(map[string]bool)
+	for _, value := range offsets {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+
+		offset, err := parseReminderOffset(value)
+		if err != nil {
+			return nil, err
+		}
+
+		reminder := model.Reminder{
+			ID:       primitive.NewObjectID(),
+			Offset:   value,
+			RemindAt: offset.apply(*due, loc),
+		}
+		for _, old := range existing {
+			if old.Offset == reminder.Offset && old.RemindAt.Equal(reminder.RemindAt) {
+				reminder = old
+				break
+			}
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
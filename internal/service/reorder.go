@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReorderTodo places a todo in the user's manual order. The position is not
+// part of the todo's revisions, so the change is published without one.
+func (s *todoService) ReorderTodo(ctx context.Context, id string, userId string, reorder *model.TodoReorder) (*model.Todo, error) {
+	if reorder.After == nil && reorder.Before == nil {
+		return nil, errors.ErrInvalidReorder
+	}
+	for _, anchor := range []*primitive.ObjectID{reorder.After, reorder.Before} {
+		if anchor != nil && anchor.Hex() == id {
+			return nil, errors.ErrInvalidReorder
+		}
+	}
+
+	todo, err := s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		before, err := s.repo.FindByID(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		after, err := s.repo.Reorder(ctx, id, userId, reorder)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.publish(ctx, model.RevisionUpdate, before, after); err != nil {
+			return nil, err
+		}
+		return after, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"reflect"
+	"slices"
+	"strings"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *todoService) GetHistory(ctx context.Context, id string, userId string, query *model.RevisionQuery) ([]*model.Revision, error) {
+	revisions, err := s.revisionRepo.FindByTodo(ctx, id, userId, query)
+	if err != nil {
+		return nil, err
+	}
+	// Every todo has at least its create revision
+	if len(revisions) == 0 && query.Before == 0 {
+		if _, err := s.repo.FindByID(ctx, id, userId); err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+// RevertTodo brings back the tracked fields of a todo as they were at the
+// given revision, including its parent, list and labels. Labels deleted
+// since are left out, while a parent or list that is gone fails the revert.
+// The revert is itself recorded as a new revision, and subtasks carried
+// along to another list get an update each.
+func (s *todoService) RevertTodo(ctx context.Context, id string, userId string, number int) (*model.Todo, error) {
+	revision, err := s.revisionRepo.FindOne(ctx, id, userId, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		before, err := s.repo.FindSubtree(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.restorePlacement(ctx, userId, before[0], &revision.State); err != nil {
+			return nil, err
+		}
+
+		existing, err := s.repo.FindByID(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		todo, err := s.applyUpdate(ctx, userId, before[0], existing, revision.State.Editable(), model.RevisionRevert, number)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := s.repo.FindSubtree(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.recordChanges(ctx, userId, before[1:], after[1:]); err != nil {
+			return nil, err
+		}
+		return todo, nil
+	})
+}
+
+// restorePlacement puts a todo back under the parent, in the list and with
+// the labels it had in state
+func (s *todoService) restorePlacement(ctx context.Context, userId string, todo *model.Todo, state *model.TodoState) error {
+	id := todo.ID.Hex()
+	moved := todo
+	if !sameID(state.ParentID, todo.ParentID) {
+		var err error
+		if moved, err = s.repo.Move(ctx, id, userId, state.ParentID); err != nil {
+			return err
+		}
+	}
+	// Subtasks are in their parent's list, which the move took care of
+	if state.ParentID == nil && state.ListID != nil && !sameID(state.ListID, moved.ListID) {
+		list, err := s.targetList(ctx, userId, state.ListID)
+		if err != nil {
+			return err
+		}
+		if moved, err = s.repo.MoveToList(ctx, id, userId, list.ID); err != nil {
+			return err
+		}
+	}
+	if moved != todo {
+		if err := settleLists(ctx, s.repo, s.workflowRepo, userId, moved.ListID); err != nil {
+			return err
+		}
+	}
+
+	labels := make([]primitive.ObjectID, 0, len(state.Labels))
+	for _, labelID := range state.Labels {
+		if _, err := s.labelRepo.FindByID(ctx, labelID.Hex(), userId); err != nil {
+			if stderrors.Is(err, errors.ErrLabelNotFound) {
+				continue
+			}
+			return err
+		}
+		labels = append(labels, labelID)
+	}
+	if !slices.Equal(labels, todo.Labels) {
+		if _, err := s.repo.SetLabels(ctx, id, userId, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameID reports whether two optional IDs are the same
+func sameID(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// atomically runs a change together with the revisions it records, in one
+// transaction where the deployment supports it
+func (s *todoService) atomically(ctx context.Context, fn func(ctx context.Context) (*model.Todo, error)) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		todo, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// changeTodo runs a change to a single todo and records it as an update
+func (s *todoService) changeTodo(ctx context.Context, id string, userId string, fn func(ctx context.Context) (*model.Todo, error)) (*model.Todo, error) {
+	return s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		before, err := s.repo.FindByID(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		after, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, userId, model.RevisionUpdate, before, after, 0); err != nil {
+			return nil, err
+		}
+		return after, nil
+	})
+}
+
+// changeSubtree runs a change that may carry a todo's subtasks along with
+// it, records an update for every todo it changed and returns the todo
+func (s *todoService) changeSubtree(ctx context.Context, id string, userId string, fn func(ctx context.Context) error) (*model.Todo, error) {
+	return s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		before, err := s.repo.FindSubtree(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(ctx); err != nil {
+			return nil, err
+		}
+		after, err := s.repo.FindSubtree(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.recordChanges(ctx, userId, before, after); err != nil {
+			return nil, err
+		}
+		return after[0], nil
+	})
+}
+
+// record stores a revision of a todo changing from before to after, either
+// of which is nil when the todo was created or deleted, and publishes the
+// change. Updates that change none of the tracked fields are not recorded.
+func (s *todoService) record(ctx context.Context, userId string, action string, before, after *model.Todo, revertedTo int) error {
+	actorID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+
+	var old, state *model.TodoState
+	todo := after
+	if before != nil {
+		old = before.State()
+		todo = before
+	}
+	changes := []model.FieldChange{}
+	if after != nil {
+		state = after.State()
+		changes = diffStates(old, state)
+		todo = after
+	} else {
+		state = old
+	}
+	if action == model.RevisionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	revision, err := s.revisionRepo.Create(ctx, &model.Revision{
+		TodoID:     todo.ID,
+		UserID:     todo.UserID,
+		Action:     action,
+		ActorID:    actorID,
+		RevertedTo: revertedTo,
+		Changes:    changes,
+		State:      *state,
+	})
+	if err != nil {
+		return err
+	}
+	todo.Revision = revision.Revision
+	return s.publish(ctx, action, before, after)
+}
+
+// recordChanges records an update for every todo in after that differs from
+// its counterpart in before, such as the subtasks carried along by a move
+func (s *todoService) recordChanges(ctx context.Context, userId string, before, after []*model.Todo) error {
+	previous := make(map[primitive.ObjectID]*model.Todo, len(before))
+	for _, todo := range before {
+		previous[todo.ID] = todo
+	}
+	for _, todo := range after {
+		if err := s.record(ctx, userId, model.RevisionUpdate, previous[todo.ID], todo, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffStates lists the fields that differ between two states, where a nil
+// state has every field empty
+func diffStates(before, after *model.TodoState) []model.FieldChange {
+	if before == nil {
+		before = &model.TodoState{}
+	}
+	if after == nil {
+		after = &model.TodoState{}
+	}
+
+	changes := []model.FieldChange{}
+	old, updated := reflect.ValueOf(before).Elem(), reflect.ValueOf(after).Elem()
+	for i := 0; i < old.NumField(); i++ {
+		from, to := old.Field(i), updated.Field(i)
+		if isEmptyValue(from) && isEmptyValue(to) {
+			continue
+		}
+		if reflect.DeepEqual(from.Interface(), to.Interface()) {
+			continue
+		}
+
+		change := model.FieldChange{Field: strings.Split(old.Type().Field(i).Tag.Get("json"), ",")[0]}
+		if !isEmptyValue(from) {
+			change.Old = from.Interface()
+		}
+		if !isEmptyValue(to) {
+			change.New = to.Interface()
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// isEmptyValue treats empty slices like nil ones
+func isEmptyValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Slice {
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/stats"
+)
+
+// GetStats sums up the user's todos over a range of days in their time zone
+func (s *todoService) GetStats(ctx context.Context, userId string, query *model.StatsQuery) (*model.TodoStats, error) {
+	timeZone, err := s.userTimeZone(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	now := time.Now()
+	window, err := statsRange(query, loc, now)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.repo.Stats(ctx, userId, window)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Summarize(totals, window), nil
+}
+
+// statsRange reads the days a stats query asks for, a week up to today when
+// it names none
+func statsRange(query *model.StatsQuery, loc *time.Location, now time.Time) (stats.Range, error) {
+	last := now.In(loc)
+	if query.To != "" {
+		var err error
+		if last, err = time.ParseInLocation(stats.DateLayout, query.To, loc); err != nil {
+			return stats.Range{}, errors.ErrInvalidStatsRange
+		}
+	}
+	first := last.AddDate(0, 0, -6)
+	if query.From != "" {
+		var err error
+		if first, err = time.ParseInLocation(stats.DateLayout, query.From, loc); err != nil {
+			return stats.Range{}, errors.ErrInvalidStatsRange
+		}
+	}
+
+	window := stats.NewRange(first, last, loc, now)
+	if !window.Start.Before(window.End) || window.End.After(window.Start.AddDate(0, 0, model.MaxStatsDays)) {
+		return stats.Range{}, errors.ErrInvalidStatsRange
+	}
+	return window, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SyncChanges returns a page of the changes to the user's todos since a
+// sync token
+func (s *todoService) SyncChanges(ctx context.Context, userId string, query *model.SyncQuery) (*model.SyncChanges, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = model.DefaultSyncLimit
+	}
+	return s.repo.Changes(ctx, userId, query.Since, limit)
+}
+
+// ApplySync applies the changes a client made offline, in order. Each runs
+// on its own, so a conflict on one does not hold back the others. A change
+// to a todo that has moved on since the version the client saw is a
+// conflict, reported with the todo as it is now for the client to resolve.
+func (s *todoService) ApplySync(ctx context.Context, userId string, request *model.SyncRequest) (*model.SyncResponse, error) {
+	response := &model.SyncResponse{Results: make([]*model.SyncResult, 0, len(request.Mutations))}
+	for i := range request.Mutations {
+		result := s.syncItem(ctx, userId, &request.Mutations[i], i)
+		// Only conflicts come back with the todo alongside an error
+		switch {
+		case result.Error != "" && result.Todo != nil:
+			response.Conflicts++
+		case result.Error != "":
+			response.Failed++
+		default:
+			response.Applied++
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+func (s *todoService) syncItem(ctx context.Context, userId string, mutation *model.SyncMutation, index int) *model.SyncResult {
+	result := &model.SyncResult{Index: index, ID: mutation.ID}
+
+	var err error
+	id, idErr := primitive.ObjectIDFromHex(mutation.ID)
+	switch {
+	case idErr != nil:
+		err = errors.ErrInvalidID
+	case mutation.Op == "create":
+		if mutation.Todo == nil {
+			err = errors.ErrInvalidSyncMutation
+			break
+		}
+		result.Todo, result.Status, err = s.syncCreate(ctx, userId, id, *mutation.Todo)
+	case mutation.Op == "update":
+		if len(mutation.Patch) == 0 || mutation.BaseVersion == nil {
+			err = errors.ErrInvalidSyncMutation
+			break
+		}
+		patch := bulkPatch(mutation.Patch)
+		patch.IfMatch = model.Precondition{*mutation.BaseVersion}
+		result.Todo, err = s.PatchTodo(ctx, mutation.ID, userId, patch)
+	case mutation.Op == "delete":
+		var expected model.Precondition
+		if mutation.BaseVersion != nil {
+			expected = model.Precondition{*mutation.BaseVersion}
+		}
+		err = s.DeleteTodo(ctx, mutation.ID, userId, false, expected)
+		// Deleting a todo that is already gone leaves it gone
+		if err != nil && errors.HTTPStatus(err) == http.StatusNotFound {
+			err = nil
+		}
+		result.Status = http.StatusNoContent
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrPreconditionFailed):
+		result.Status = http.StatusConflict
+		result.Error = errors.Message(err)
+		result.Todo, _ = s.repo.FindByID(ctx, mutation.ID, userId)
+	case err != nil:
+		result.Status = errors.HTTPStatus(err)
+		result.Error = errors.Message(err)
+		result.Todo = nil
+	case result.Status == 0:
+		result.Status = http.StatusOK
+	}
+	return result
+}
+
+// syncCreate creates a todo under the ID the client gave it. When the user
+// already has that todo, the mutation is a retry and the todo is returned
+// as it is.
+func (s *todoService) syncCreate(ctx context.Context, userId string, id primitive.ObjectID, todoCreate model.TodoCreate) (*model.Todo, int, error) {
+	if todo, err := s.repo.FindByID(ctx, id.Hex(), userId); err == nil {
+		return todo, http.StatusOK, nil
+	}
+
+	todoCreate.ID = id
+	todo, err := s.CreateTodo(ctx, userId, &todoCreate)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, 0, errors.ErrTodoIDTaken
+	}
+	return todo, http.StatusCreated, err
+}
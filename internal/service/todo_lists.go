@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *todoService) MoveToList(ctx context.Context, id string, userId string, move *model.TodoListMove) (*model.Todo, error) {
+	list, err := s.targetList(ctx, userId, &move.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.changeSubtree(ctx, id, userId, func(ctx context.Context) error {
+		if _, err := s.repo.MoveToList(ctx, id, userId, list.ID); err != nil {
+			return err
+		}
+		return settleLists(ctx, s.repo, s.workflowRepo, userId, &list.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// targetList returns the list todos are being put in, defaulting to the
+// user's Inbox. Archived lists take no new todos.
+func (s *todoService) targetList(ctx context.Context, userId string, listID *primitive.ObjectID) (*model.List, error) {
+	if listID == nil {
+		return s.listRepo.EnsureInbox(ctx, userId)
+	}
+
+	list, err := s.listRepo.FindByID(ctx, listID.Hex(), userId)
+	if err != nil {
+		return nil, err
+	}
+	if list.Archived {
+		return nil, errors.ErrListArchived
+	}
+	return list, nil
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"strings"
+	"time"
 	"todo-app/internal/model"
 	"todo-app/internal/repository"
 
@@ -11,18 +14,51 @@ import (
 
 type TodoService interface {
 	CreateTodo(ctx context.Context, userId string, todoCreate *model.TodoCreate) (*model.Todo, error)
+	QuickAddTodo(ctx context.Context, userId string, quickAdd *model.QuickAdd, preview bool) (*model.QuickAddResult, error)
 	GetTodo(ctx context.Context, id string, userId string) (*model.Todo, error)
-	GetAllTodos(ctx context.Context, userId string) ([]*model.Todo, error)
+	ListTodos(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoPage, error)
+	GetListVersion(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoListVersion, error)
+	SearchTodos(ctx context.Context, userId string, query *model.TodoSearchQuery) ([]*model.TodoSearchResult, error)
 	UpdateTodo(ctx context.Context, id string, userId string, todo *model.TodoUpdate) (*model.Todo, error)
-	DeleteTodo(ctx context.Context, id string, userId string) error
+	PatchTodo(ctx context.Context, id string, userId string, patch *model.TodoPatch) (*model.Todo, error)
+	DeleteTodo(ctx context.Context, id string, userId string, deleteChildren bool, expected model.Precondition) error
+	GetChildren(ctx context.Context, id string, userId string) ([]*model.Todo, error)
+	MoveTodo(ctx context.Context, id string, userId string, move *model.TodoMove) (*model.Todo, error)
+	TransitionTodo(ctx context.Context, id string, userId string, transition *model.TodoTransition) (*model.Todo, error)
+	ReorderTodo(ctx context.Context, id string, userId string, reorder *model.TodoReorder) (*model.Todo, error)
+	GetOccurrences(ctx context.Context, id string, userId string, query *model.TodoOccurrencesQuery) (*model.TodoOccurrences, error)
+	AttachLabel(ctx context.Context, id string, userId string, labelId string) (*model.Todo, error)
+	DetachLabel(ctx context.Context, id string, userId string, labelId string) (*model.Todo, error)
+	MoveToList(ctx context.Context, id string, userId string, move *model.TodoListMove) (*model.Todo, error)
+	ListTrash(ctx context.Context, userId string, query *model.TrashQuery) (*model.TodoPage, error)
+	RestoreTodo(ctx context.Context, id string, userId string) (*model.Todo, error)
+	PurgeTodo(ctx context.Context, id string, userId string) error
+	BulkTodos(ctx context.Context, userId string, request *model.TodoBulkRequest) (*model.TodoBulkResponse, error)
+	GetHistory(ctx context.Context, id string, userId string, query *model.RevisionQuery) ([]*model.Revision, error)
+	RevertTodo(ctx context.Context, id string, userId string, revision int) (*model.Todo, error)
+	ExportTodos(ctx context.Context, userId string, format string, w io.Writer) error
+	ImportTodos(ctx context.Context, userId string, format string, r io.Reader, dryRun bool) (*model.TodoImportResult, error)
+	SyncChanges(ctx context.Context, userId string, query *model.SyncQuery) (*model.SyncChanges, error)
+	ApplySync(ctx context.Context, userId string, request *model.SyncRequest) (*model.SyncResponse, error)
+	GetStats(ctx context.Context, userId string, query *model.StatsQuery) (*model.TodoStats, error)
 }
 
 type todoService struct {
-	repo repository.TodoRepository
+	repo         repository.TodoRepository
+	userRepo     repository.UserRepository
+	labelRepo    repository.LabelRepository
+	listRepo     repository.ListRepository
+	workflowRepo repository.WorkflowRepository
+	revisionRepo repository.RevisionRepository
+	events       EventPublisher
+	bus          EventBroadcaster
 }
 
-func NewTodoService(repo repository.TodoRepository) TodoService {
-	return &todoService{repo: repo}
+// NewTodoService takes the publisher changes to todos are announced to
+// within their transaction and the broadcaster they are announced to once
+// committed, either of which may be nil
+func NewTodoService(repo repository.TodoRepository, userRepo repository.UserRepository, labelRepo repository.LabelRepository, listRepo repository.ListRepository, workflowRepo repository.WorkflowRepository, revisionRepo repository.RevisionRepository, events EventPublisher, bus EventBroadcaster) TodoService {
+	return &todoService{repo: repo, userRepo: userRepo, labelRepo: labelRepo, listRepo: listRepo, workflowRepo: workflowRepo, revisionRepo: revisionRepo, events: events, bus: bus}
 }
 
 func (s *todoService) CreateTodo(ctx context.Context, userId string, todoCreate *model.TodoCreate) (*model.Todo, error) {
@@ -31,22 +67,331 @@ func (s *todoService) CreateTodo(ctx context.Context, userId string, todoCreate
 		return nil, errors.New("invalid user id format")
 	}
 
-	ctxWithUserId := context.WithValue(ctx, "userId", userObjectID)
-	return s.repo.Create(ctxWithUserId, todoCreate)
+	todoCreate.TimeZone, err = s.userTimeZone(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	todoCreate.Reminders, err = buildReminders(todoCreate.DueAt, todoCreate.RemindAt, todoCreate.TimeZone, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareRecurrence(todoCreate); err != nil {
+		return nil, err
+	}
+	for _, labelID := range todoCreate.Labels {
+		if _, err := s.labelRepo.FindByID(ctx, labelID.Hex(), userId); err != nil {
+			return nil, err
+		}
+	}
+	// Subtasks take their parent's list, so only top-level todos need one
+	if todoCreate.ParentID == nil {
+		list, err := s.targetList(ctx, userId, todoCreate.ListID)
+		if err != nil {
+			return nil, err
+		}
+		todoCreate.ListID = &list.ID
+	}
+	if err := s.initialStatus(ctx, userId, todoCreate); err != nil {
+		return nil, err
+	}
+
+	return s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		ctxWithUserId := context.WithValue(ctx, "userId", userObjectID)
+		todo, err := s.repo.Create(ctxWithUserId, todoCreate)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.record(ctx, userId, model.RevisionCreate, nil, todo, 0); err != nil {
+			return nil, err
+		}
+		return todo, nil
+	})
 }
 
 func (s *todoService) GetTodo(ctx context.Context, id string, userId string) (*model.Todo, error) {
-	return s.repo.FindByID(ctx, id, userId)
+	todo, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
 }
 
-func (s *todoService) GetAllTodos(ctx context.Context, userId string) ([]*model.Todo, error) {
-	return s.repo.FindAll(ctx, userId)
+// GetListVersion returns what the result of ListTodos depends on, so a
+// client can tell whether its copy of a page is still current without the
+// page being loaded
+func (s *todoService) GetListVersion(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoListVersion, error) {
+	if _, err := s.resolveLabels(ctx, userId, query); err != nil {
+		return nil, err
+	}
+	version, err := s.repo.ListVersion(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	version.Labels = query.LabelIDs
+	return version, nil
+}
+
+func (s *todoService) ListTodos(ctx context.Context, userId string, query *model.TodoQuery) (*model.TodoPage, error) {
+	matchable, err := s.resolveLabels(ctx, userId, query)
+	if err != nil {
+		return nil, err
+	}
+	if !matchable {
+		return &model.TodoPage{Items: []*model.Todo{}}, nil
+	}
+
+	page, err := s.repo.Query(ctx, userId, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, page.Items...); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
+func (s *todoService) SearchTodos(ctx context.Context, userId string, query *model.TodoSearchQuery) ([]*model.TodoSearchResult, error) {
+	return s.repo.Search(ctx, userId, query)
+}
+
+// UpdateTodo replaces the editable fields of a todo with those of todo
 func (s *todoService) UpdateTodo(ctx context.Context, id string, userId string, todo *model.TodoUpdate) (*model.Todo, error) {
-	return s.repo.Update(ctx, id, userId, todo)
+	return s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		return s.updateTodo(ctx, id, userId, todo, model.RevisionUpdate, 0)
+	})
+}
+
+// updateTodo applies an update and records it as a revision with the given
+// action
+func (s *todoService) updateTodo(ctx context.Context, id string, userId string, todo *model.TodoUpdate, action string, revertedTo int) (*model.Todo, error) {
+	existing, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyUpdate(ctx, userId, existing, existing, todo, action, revertedTo)
+}
+
+// applyUpdate applies an update to the existing todo. The revision is
+// recorded from before, which is an earlier copy of the todo when the same
+// change already moved it, as a revert does.
+func (s *todoService) applyUpdate(ctx context.Context, userId string, before, existing *model.Todo, todo *model.TodoUpdate, action string, revertedTo int) (*model.Todo, error) {
+	id := existing.ID.Hex()
+	workflow, err := s.workflowFor(ctx, userId, existing.ListID)
+	if err != nil {
+		return nil, err
+	}
+	if err := settleStatus(workflow, existing, todo); err != nil {
+		return nil, err
+	}
+
+	todo.TimeZone = existing.TimeZone
+	if todo.TimeZone == "" {
+		if todo.TimeZone, err = s.userTimeZone(ctx, userId); err != nil {
+			return nil, err
+		}
+	}
+	todo.Reminders, err = buildReminders(todo.DueAt, todo.RemindAt, todo.TimeZone, existing.Reminders)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareUpdatedRecurrence(todo, existing); err != nil {
+		return nil, err
+	}
+	todo.CompletedAt = nil
+	if todo.Completed {
+		// Keep when the todo was first completed, not when it was last saved
+		now := time.Now()
+		todo.CompletedAt = &now
+		if existing.Completed {
+			todo.CompletedAt = existing.CompletedAt
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, id, userId, todo)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, userId, action, before, updated, revertedTo); err != nil {
+		return nil, err
+	}
+
+	if !existing.Completed && updated.Completed {
+		if err := s.spawnNextOccurrence(ctx, updated); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.attachProgress(ctx, userId, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteTodo moves a todo, and with deleteChildren its subtasks, to the
+// trash. Otherwise its children are moved to the top level. Nothing is
+// changed unless the todo is at a version expected allows.
+func (s *todoService) DeleteTodo(ctx context.Context, id string, userId string, deleteChildren bool, expected model.Precondition) error {
+	_, err := s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		var trashed, orphaned []*model.Todo
+		if deleteChildren {
+			subtree, err := s.repo.FindSubtree(ctx, id, userId)
+			if err != nil {
+				return nil, err
+			}
+			trashed = subtree
+		} else {
+			todo, err := s.repo.FindByID(ctx, id, userId)
+			if err != nil {
+				return nil, err
+			}
+			children, err := s.repo.FindChildren(ctx, id, userId)
+			if err != nil {
+				return nil, err
+			}
+			trashed, orphaned = []*model.Todo{todo}, children
+		}
+
+		if err := s.repo.Delete(ctx, id, userId, deleteChildren, expected); err != nil {
+			return nil, err
+		}
+
+		for _, todo := range trashed {
+			if err := s.record(ctx, userId, model.RevisionDelete, todo, nil, 0); err != nil {
+				return nil, err
+			}
+		}
+		for _, child := range orphaned {
+			topLevel := *child
+			topLevel.ParentID = nil
+			if err := s.record(ctx, userId, model.RevisionUpdate, child, &topLevel, 0); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (s *todoService) GetChildren(ctx context.Context, id string, userId string) ([]*model.Todo, error) {
+	children, err := s.repo.FindChildren(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, children...); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+func (s *todoService) MoveTodo(ctx context.Context, id string, userId string, move *model.TodoMove) (*model.Todo, error) {
+	todo, err := s.changeSubtree(ctx, id, userId, func(ctx context.Context) error {
+		moved, err := s.repo.Move(ctx, id, userId, move.ParentID)
+		if err != nil {
+			return err
+		}
+		return settleLists(ctx, s.repo, s.workflowRepo, userId, moved.ListID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *todoService) AttachLabel(ctx context.Context, id string, userId string, labelId string) (*model.Todo, error) {
+	label, err := s.labelRepo.FindByID(ctx, labelId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeTodo(ctx, id, userId, func(ctx context.Context) (*model.Todo, error) {
+		return s.repo.AddLabel(ctx, id, userId, label.ID)
+	})
+}
+
+func (s *todoService) DetachLabel(ctx context.Context, id string, userId string, labelId string) (*model.Todo, error) {
+	label, err := s.labelRepo.FindByID(ctx, labelId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeTodo(ctx, id, userId, func(ctx context.Context) (*model.Todo, error) {
+		return s.repo.RemoveLabel(ctx, id, userId, label.ID)
+	})
 }
 
-func (s *todoService) DeleteTodo(ctx context.Context, id string, userId string) error {
-	return s.repo.Delete(ctx, id, userId)
+// resolveLabels turns the label names of a query, given as repeated or
+// comma-separated values, into label IDs. It reports false when the filter
+// cannot match anything, such as an unknown label in "all" mode.
+func (s *todoService) resolveLabels(ctx context.Context, userId string, query *model.TodoQuery) (bool, error) {
+	var names []string
+	for _, value := range query.Labels {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return true, nil
+	}
+
+	labels, err := s.labelRepo.FindByNames(ctx, userId, names)
+	if err != nil {
+		return false, err
+	}
+
+	query.LabelIDs = make([]primitive.ObjectID, len(labels))
+	for i, label := range labels {
+		query.LabelIDs[i] = label.ID
+	}
+
+	if query.LabelMatch == "any" {
+		return len(labels) > 0, nil
+	}
+	// Every requested name must exist for an "all" match to succeed
+	found := make(map[string]bool)
+	for _, label := range labels {
+		found[label.NameKey] = true
+	}
+	for _, name := range names {
+		if !found[strings.ToLower(name)] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// attachProgress fills in the completion percentage of todos that have children
+func (s *todoService) attachProgress(ctx context.Context, userId string, todos ...*model.Todo) error {
+	ids := make([]primitive.ObjectID, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	progress, err := s.repo.ChildProgress(ctx, userId, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, todo := range todos {
+		if percent, ok := progress[todo.ID]; ok {
+			todo.Progress = &percent
+		}
+	}
+	return nil
+}
+
+// userTimeZone returns the IANA zone of the user, or "" when none is set
+func (s *todoService) userTimeZone(ctx context.Context, userId string) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", nil
+	}
+	return user.TimeZone, nil
 }
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"todo-app/internal/model"
+)
+
+func (s *todoService) ListTrash(ctx context.Context, userId string, query *model.TrashQuery) (*model.TodoPage, error) {
+	return s.repo.FindDeleted(ctx, userId, query)
+}
+
+// RestoreTodo takes a todo out of the trash along with the subtasks deleted
+// with it, recording a restore revision for each of them
+func (s *todoService) RestoreTodo(ctx context.Context, id string, userId string) (*model.Todo, error) {
+	todo, err := s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		if _, err := s.repo.Restore(ctx, id, userId); err != nil {
+			return nil, err
+		}
+		restored, err := s.repo.FindSubtree(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range restored {
+			if err := s.record(ctx, userId, model.RevisionRestore, todo, todo, 0); err != nil {
+				return nil, err
+			}
+		}
+		return restored[0], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *todoService) PurgeTodo(ctx context.Context, id string, userId string) error {
+	return s.repo.Purge(ctx, id, userId)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransitionTodo moves a todo to another state of its workflow, which
+// completes or reopens it when one of the two states is done and the other
+// is not. Moving a todo to the state it is in changes nothing.
+func (s *todoService) TransitionTodo(ctx context.Context, id string, userId string, transition *model.TodoTransition) (*model.Todo, error) {
+	todo, err := s.atomically(ctx, func(ctx context.Context) (*model.Todo, error) {
+		existing, err := s.repo.FindByID(ctx, id, userId)
+		if err != nil {
+			return nil, err
+		}
+		workflow, err := s.workflowFor(ctx, userId, existing.ListID)
+		if err != nil {
+			return nil, err
+		}
+		if workflow.State(transition.Status) == nil {
+			return nil, errors.ErrUnknownTodoStatus
+		}
+
+		if workflow.StateOf(existing) == transition.Status {
+			if !transition.IfMatch.Allows(existing.Version) {
+				return nil, errors.ErrPreconditionFailed
+			}
+			return existing, nil
+		}
+
+		update := existing.Editable()
+		update.Status = transition.Status
+		update.IfMatch = transition.IfMatch
+		return s.updateTodo(ctx, id, userId, update, model.RevisionUpdate, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachProgress(ctx, userId, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// settleLists brings the status and completed flag of the todos in the
+// given lists in line with the workflows that now apply to them, a nil list
+// standing for todos without one. It runs whenever todos change workflow,
+// because they moved or a workflow changed.
+func settleLists(ctx context.Context, todoRepo repository.TodoRepository, workflowRepo repository.WorkflowRepository, userId string, listIDs ...*primitive.ObjectID) error {
+	for _, listID := range listIDs {
+		workflow, err := workflowRepo.FindForList(ctx, userId, listID)
+		if err != nil {
+			return err
+		}
+		if workflow == nil {
+			workflow = model.DefaultWorkflow()
+		}
+		if err := todoRepo.SettleStatuses(ctx, userId, listID, workflow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workflowFor returns the workflow the todos of a list follow
+func (s *todoService) workflowFor(ctx context.Context, userId string, listID *primitive.ObjectID) (*model.Workflow, error) {
+	workflow, err := s.workflowRepo.FindForList(ctx, userId, listID)
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil {
+		return model.DefaultWorkflow(), nil
+	}
+	return workflow, nil
+}
+
+// initialStatus puts a new todo in the state it asks for, which decides
+// whether it is completed, or else in the first done or open state of its
+// workflow. Subtasks follow the workflow of their parent's list.
+func (s *todoService) initialStatus(ctx context.Context, userId string, todoCreate *model.TodoCreate) error {
+	listID := todoCreate.ListID
+	if todoCreate.ParentID != nil {
+		parent, err := s.repo.FindByID(ctx, todoCreate.ParentID.Hex(), userId)
+		if err != nil {
+			if errors.HTTPStatus(err) == http.StatusNotFound {
+				return errors.ErrParentNotFound
+			}
+			return err
+		}
+		listID = parent.ListID
+	}
+
+	workflow, err := s.workflowFor(ctx, userId, listID)
+	if err != nil {
+		return err
+	}
+	if todoCreate.Status != "" {
+		state := workflow.State(todoCreate.Status)
+		if state == nil {
+			return errors.ErrUnknownTodoStatus
+		}
+		todoCreate.Completed = &state.Done
+		return nil
+	}
+	todoCreate.Status = workflow.FirstState(todoCreate.Completed != nil && *todoCreate.Completed)
+	return nil
+}
+
+// settleStatus keeps the status and the completed flag of an updated todo
+// in line. A status the update names, such as the one of a transition or a
+// revert, decides whether the todo is completed. Otherwise the todo keeps
+// its state unless the update completes or reopens it, which moves it to
+// the first done or open state of its workflow. Either way the workflow
+// must allow the move, so the completed flag is no way around it.
+func settleStatus(workflow *model.Workflow, existing *model.Todo, update *model.TodoUpdate) error {
+	current := workflow.StateOf(existing)
+	if workflow.State(update.Status) == nil {
+		update.Status = current
+		if workflow.State(current).Done != update.Completed {
+			update.Status = workflow.FirstState(update.Completed)
+		}
+	}
+	update.Completed = workflow.State(update.Status).Done
+
+	if update.Status != current && !workflow.Allows(current, update.Status) {
+		// A client editing an old version learns that first
+		if !update.IfMatch.Allows(existing.Version) {
+			return errors.ErrPreconditionFailed
+		}
+		return errors.ErrTransitionNotAllowed
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/netguard"
+	"todo-app/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookService manages the user's webhooks and queues a delivery to each
+// webhook subscribed to an event. Deliveries are sent by the webhook
+// dispatcher in the background.
+type WebhookService interface {
+	EventPublisher
+	CreateWebhook(ctx context.Context, userId string, webhook *model.WebhookCreate) (*model.Webhook, error)
+	GetWebhook(ctx context.Context, id string, userId string) (*model.Webhook, error)
+	GetAllWebhooks(ctx context.Context, userId string) ([]*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, userId string, webhook *model.WebhookUpdate) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string, userId string) error
+	ListDeliveries(ctx context.Context, id string, userId string, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, id string, userId string, deliveryId string) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo         repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	guard        *netguard.Guard
+}
+
+// NewWebhookService takes the guard webhook URLs are checked against, the
+// same one the dispatcher connects through
+func NewWebhookService(repo repository.WebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, guard *netguard.Guard) WebhookService {
+	return &webhookService{repo: repo, deliveryRepo: deliveryRepo, guard: guard}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, userId string, webhook *model.WebhookCreate) (*model.Webhook, error) {
+	if !s.guard.AllowsURL(ctx, webhook.URL) {
+		return nil, errors.ErrWebhookURLNotAllowed
+	}
+	return s.repo.Create(ctx, userId, webhook)
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id string, userId string) (*model.Webhook, error) {
+	return s.repo.FindByID(ctx, id, userId)
+}
+
+func (s *webhookService) GetAllWebhooks(ctx context.Context, userId string) ([]*model.Webhook, error) {
+	return s.repo.FindAll(ctx, userId)
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, id string, userId string, webhook *model.WebhookUpdate) (*model.Webhook, error) {
+	if webhook.URL != "" && !s.guard.AllowsURL(ctx, webhook.URL) {
+		return nil, errors.ErrWebhookURLNotAllowed
+	}
+	return s.repo.Update(ctx, id, userId, webhook)
+}
+
+// DeleteWebhook removes a webhook along with its deliveries, including the
+// ones not sent yet
+func (s *webhookService) DeleteWebhook(ctx context.Context, id string, userId string) error {
+	webhook, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id, userId); err != nil {
+		return err
+	}
+	return s.deliveryRepo.DeleteByWebhook(ctx, webhook.ID)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, id string, userId string, query *model.WebhookDeliveryQuery) ([]*model.WebhookDelivery, error) {
+	webhook, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.FindByWebhook(ctx, webhook.ID, query)
+}
+
+// RetryDelivery queues a dead delivery to be sent again straight away
+func (s *webhookService) RetryDelivery(ctx context.Context, id string, userId string, deliveryId string) (*model.WebhookDelivery, error) {
+	webhook, err := s.repo.FindByID(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.Requeue(ctx, deliveryId, webhook.ID, time.Now())
+}
+
+// Publish queues a delivery of each event to every webhook of its user
+// that subscribes to it. The payload is rendered now, so retries send the
+// todo as it was when the event happened.
+func (s *webhookService) Publish(ctx context.Context, events ...*model.TodoEvent) error {
+	webhooks := map[primitive.ObjectID][]*model.Webhook{}
+	var deliveries []*model.WebhookDelivery
+	for _, event := range events {
+		subscribed, ok := webhooks[event.UserID]
+		if !ok {
+			var err error
+			if subscribed, err = s.repo.FindAll(ctx, event.UserID.Hex()); err != nil {
+				return err
+			}
+			webhooks[event.UserID] = subscribed
+		}
+
+		for _, webhook := range subscribed {
+			if !slices.Contains(webhook.Events, event.Type) {
+				continue
+			}
+			delivery, err := newDelivery(webhook, event)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return s.deliveryRepo.CreateMany(ctx, deliveries)
+}
+
+func newDelivery(webhook *model.Webhook, event *model.TodoEvent) (*model.WebhookDelivery, error) {
+	id := primitive.NewObjectID()
+	payload, err := json.Marshal(model.WebhookPayload{
+		ID:         id.Hex(),
+		Event:      event.Type,
+		OccurredAt: event.OccurredAt,
+		Todo:       event.Todo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queuedAt := event.OccurredAt
+	return &model.WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhook.ID,
+		UserID:        webhook.UserID,
+		Event:         event.Type,
+		Payload:       payload,
+		Status:        model.DeliveryPending,
+		Attempts:      []model.WebhookAttempt{},
+		NextAttemptAt: &queuedAt,
+		CreatedAt:     event.OccurredAt,
+	}, nil
+}
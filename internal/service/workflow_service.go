@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WorkflowService interface {
+	CreateWorkflow(ctx context.Context, userId string, workflow *model.WorkflowCreate) (*model.Workflow, error)
+	GetWorkflow(ctx context.Context, id string, userId string) (*model.Workflow, error)
+	GetAllWorkflows(ctx context.Context, userId string) ([]*model.Workflow, error)
+	UpdateWorkflow(ctx context.Context, id string, userId string, workflow *model.WorkflowUpdate) (*model.Workflow, error)
+	DeleteWorkflow(ctx context.Context, id string, userId string) error
+}
+
+type workflowService struct {
+	repo     repository.WorkflowRepository
+	listRepo repository.ListRepository
+	todoRepo repository.TodoRepository
+}
+
+// NewWorkflowService settles the todos a workflow change affects through
+// todoRepo, in the same transaction as the change
+func NewWorkflowService(repo repository.WorkflowRepository, listRepo repository.ListRepository, todoRepo repository.TodoRepository) WorkflowService {
+	return &workflowService{repo: repo, listRepo: listRepo, todoRepo: todoRepo}
+}
+
+func (s *workflowService) CreateWorkflow(ctx context.Context, userId string, workflow *model.WorkflowCreate) (*model.Workflow, error) {
+	if err := s.validate(ctx, userId, workflow.States, workflow.Transitions, workflow.ListIDs); err != nil {
+		return nil, err
+	}
+	var created *model.Workflow
+	err := s.todoRepo.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.Create(ctx, userId, workflow); err != nil {
+			return err
+		}
+		return s.settleTodos(ctx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *workflowService) GetWorkflow(ctx context.Context, id string, userId string) (*model.Workflow, error) {
+	return s.repo.FindByID(ctx, id, userId)
+}
+
+func (s *workflowService) GetAllWorkflows(ctx context.Context, userId string) ([]*model.Workflow, error) {
+	return s.repo.FindAll(ctx, userId)
+}
+
+func (s *workflowService) UpdateWorkflow(ctx context.Context, id string, userId string, workflow *model.WorkflowUpdate) (*model.Workflow, error) {
+	if err := s.validate(ctx, userId, workflow.States, workflow.Transitions, workflow.ListIDs); err != nil {
+		return nil, err
+	}
+	var updated *model.Workflow
+	err := s.todoRepo.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if updated, err = s.repo.Update(ctx, id, userId, workflow); err != nil {
+			return err
+		}
+		return s.settleTodos(ctx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *workflowService) DeleteWorkflow(ctx context.Context, id string, userId string) error {
+	return s.todoRepo.RunInTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id, userId); err != nil {
+			return err
+		}
+		return s.settleTodos(ctx, userId)
+	})
+}
+
+// settleTodos brings every todo of the user in line with the workflow that
+// applies to it after a change. A change can rename or drop states, flip
+// whether they are done, and hand lists or the default to another
+// workflow, so every list is settled.
+func (s *workflowService) settleTodos(ctx context.Context, userId string) error {
+	lists, err := s.listRepo.FindAll(ctx, userId, true)
+	if err != nil {
+		return err
+	}
+	listIDs := []*primitive.ObjectID{nil}
+	for _, list := range lists {
+		listIDs = append(listIDs, &list.ID)
+	}
+	return settleLists(ctx, s.todoRepo, s.repo, userId, listIDs...)
+}
+
+// validate checks a workflow definition and that the lists it applies to
+// belong to the user
+func (s *workflowService) validate(ctx context.Context, userId string, states []model.WorkflowState, transitions []model.WorkflowTransition, listIDs []primitive.ObjectID) error {
+	if err := model.ValidateWorkflow(states, transitions); err != nil {
+		return errors.NewAPIError(http.StatusBadRequest, "INVALID_WORKFLOW", err.Error())
+	}
+	for _, listID := range listIDs {
+		if _, err := s.listRepo.FindByID(ctx, listID.Hex(), userId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
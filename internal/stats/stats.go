@@ -0,0 +1,159 @@
+// Package stats computes productivity statistics over a user's todos. Stores
+// that can aggregate natively sum up the Totals themselves; Aggregate does the
+// same over plain values for those that cannot, and Summarize turns either
+// into the statistics sent to clients.
+package stats
+
+import (
+	"math"
+	"time"
+	"todo-app/internal/model"
+)
+
+// DateLayout is how days are named, both in queries and in results
+const DateLayout = "2006-01-02"
+
+// Range is a span of whole days in a time zone
+type Range struct {
+	// Midnight at the start of the first day and after the last one
+	Start, End time.Time
+	Location   *time.Location
+	// The moment the statistics are taken, which decides what is overdue
+	Now time.Time
+}
+
+// NewRange covers the days from first to last, both included, as they fall
+// in loc. Days are found by date rather than by adding hours, so a range
+// across a DST change still covers whole days.
+func NewRange(first, last time.Time, loc *time.Location, now time.Time) Range {
+	first, last = first.In(loc), last.In(loc)
+	return Range{
+		Start:    time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc),
+		End:      time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, loc),
+		Location: loc,
+		Now:      now,
+	}
+}
+
+// Contains reports whether t falls on one of the days of the range
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// OverdueBefore is the end of the due dates that count as overdue: those in
+// the range that have already passed
+func (r Range) OverdueBefore() time.Time {
+	if r.Now.Before(r.End) {
+		return r.Now
+	}
+	return r.End
+}
+
+// Day names the day t falls on in the range's time zone
+func (r Range) Day(t time.Time) string {
+	return t.In(r.Location).Format(DateLayout)
+}
+
+// Days names every day of the range, oldest first
+func (r Range) Days() []string {
+	var days []string
+	for day := r.Start; day.Before(r.End); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, r.Location) {
+		days = append(days, day.Format(DateLayout))
+	}
+	return days
+}
+
+// Totals are the sums statistics are derived from
+type Totals struct {
+	// Todos completed on each day of the range, keyed by Day; days without
+	// completions may be left out
+	CompletedPerDay map[string]int
+	// Time from creating to completing them, summed
+	CompletionTime time.Duration
+	// Those of them completed after they were due
+	CompletedLate int
+	// Todos created in the range, and how many of those are completed
+	Created          int
+	CreatedCompleted int
+	// Open todos due in the range before OverdueBefore
+	Overdue int
+}
+
+// Aggregate sums up the totals of a user's live todos over a range
+func Aggregate(todos []*model.Todo, r Range) *Totals {
+	totals := &Totals{CompletedPerDay: map[string]int{}}
+	overdueBefore := r.OverdueBefore()
+	for _, todo := range todos {
+		if todo.DeletedAt != nil {
+			continue
+		}
+		if r.Contains(todo.CreatedAt) {
+			totals.Created++
+			if todo.Completed {
+				totals.CreatedCompleted++
+			}
+		}
+		if !todo.Completed {
+			if todo.DueAt != nil && !todo.DueAt.Before(r.Start) && todo.DueAt.Before(overdueBefore) {
+				totals.Overdue++
+			}
+			continue
+		}
+		completedAt := *todo.CompletionTime()
+		if !r.Contains(completedAt) {
+			continue
+		}
+		totals.CompletedPerDay[r.Day(completedAt)]++
+		totals.CompletionTime += completedAt.Sub(todo.CreatedAt)
+		if todo.DueAt != nil && completedAt.After(*todo.DueAt) {
+			totals.CompletedLate++
+		}
+	}
+	return totals
+}
+
+// Summarize derives the statistics of a range from its totals
+func Summarize(totals *Totals, r Range) *model.TodoStats {
+	days := r.Days()
+	stats := &model.TodoStats{
+		From:          days[0],
+		To:            days[len(days)-1],
+		TimeZone:      r.Location.String(),
+		Created:       totals.Created,
+		Overdue:       totals.Overdue,
+		CompletedLate: totals.CompletedLate,
+		Days:          make([]*model.StatsDay, len(days)),
+	}
+
+	run := 0
+	for i, day := range days {
+		count := totals.CompletedPerDay[day]
+		stats.Days[i] = &model.StatsDay{Date: day, Completed: count}
+		stats.Completed += count
+		if count == 0 {
+			run = 0
+			continue
+		}
+		run++
+		if run > stats.LongestStreak {
+			stats.LongestStreak = run
+		}
+	}
+
+	// Today still counts towards the streak until it is over
+	last := len(days) - 1
+	if days[last] == r.Day(r.Now) && stats.Days[last].Completed == 0 {
+		last--
+	}
+	for i := last; i >= 0 && stats.Days[i].Completed > 0; i-- {
+		stats.CurrentStreak++
+	}
+
+	if totals.Created > 0 {
+		stats.CompletionRate = math.Round(float64(totals.CreatedCompleted)/float64(totals.Created)*1000) / 1000
+	}
+	if stats.Completed > 0 {
+		stats.AverageCompletionSeconds = int64((totals.CompletionTime / time.Duration(stats.Completed)).Seconds())
+	}
+	return stats
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-app/internal/repository"
+)
+
+// RankRebalancer periodically gives short positions back to the users whose
+// manual order has grown long ones from many moves into the same gap
+type RankRebalancer struct {
+	todoRepo  repository.TodoRepository
+	maxLength int
+	interval  time.Duration
+}
+
+func NewRankRebalancer(todoRepo repository.TodoRepository, maxLength int, interval time.Duration) *RankRebalancer {
+	return &RankRebalancer{
+		todoRepo:  todoRepo,
+		maxLength: maxLength,
+		interval:  interval,
+	}
+}
+
+// Start runs the rebalancer until ctx is cancelled
+func (b *RankRebalancer) Start(ctx context.Context) {
+	log.Printf("Rank rebalancer started (max length %d, interval %s)", b.maxLength, b.interval)
+	runEvery(ctx, b.interval, func(ctx context.Context) {
+		if err := b.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Rank rebalance failed: %v", err)
+		}
+	})
+}
+
+// RunOnce rebalances every user with a todo positioned by a rank longer
+// than the maximum, or not positioned at all. A failure for one user does
+// not hold up the others.
+func (b *RankRebalancer) RunOnce(ctx context.Context, now time.Time) error {
+	users, err := b.todoRepo.FindUnbalanced(ctx, b.maxLength)
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		moved, err := b.todoRepo.Rebalance(ctx, userID.Hex())
+		if err != nil {
+			log.Printf("Failed to rebalance todos of user %s: %v", userID.Hex(), err)
+			continue
+		}
+		if moved > 0 {
+			log.Printf("Rebalanced %d todos of user %s", moved, userID.Hex())
+		}
+	}
+	return nil
+}
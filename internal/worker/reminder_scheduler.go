@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/notifier"
+	"todo-app/internal/repository"
+)
+
+// reminderBatchSize caps the number of todos handled in one scan
+const reminderBatchSize = 100
+
+// ReminderScheduler periodically scans for reminders that have come due and
+// hands them to a notifier. Each reminder is claimed in the database before
+// it is sent, so it fires once even across restarts or several instances.
+// A failed notification is retried after backoff, doubling the wait each
+// time, until it has been attempted maxAttempts times and is given up on.
+type ReminderScheduler struct {
+	todoRepo    repository.TodoRepository
+	userRepo    repository.UserRepository
+	notifier    notifier.Notifier
+	maxAttempts int
+	backoff     time.Duration
+	interval    time.Duration
+}
+
+func NewReminderScheduler(todoRepo repository.TodoRepository, userRepo repository.UserRepository, notifier notifier.Notifier, maxAttempts int, backoff, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		todoRepo:    todoRepo,
+		userRepo:    userRepo,
+		notifier:    notifier,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		interval:    interval,
+	}
+}
+
+// Start runs the scheduler until ctx is cancelled
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	log.Printf("Reminder scheduler started (interval %s, %d attempts)", s.interval, s.maxAttempts)
+	runEvery(ctx, s.interval, func(ctx context.Context) {
+		if err := s.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Reminder scan failed: %v", err)
+		}
+	})
+}
+
+// RunOnce delivers every reminder due at now
+func (s *ReminderScheduler) RunOnce(ctx context.Context, now time.Time) error {
+	todos, err := s.todoRepo.FindDueReminders(ctx, now, reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	emails := make(map[string]string)
+	for _, todo := range todos {
+		for _, reminder := range todo.Reminders {
+			if reminder.SentAt != nil || reminder.FailedAt != nil || reminder.RemindAt.After(now) {
+				continue
+			}
+			if reminder.NextAttemptAt != nil && reminder.NextAttemptAt.After(now) {
+				continue
+			}
+			s.deliver(ctx, todo, reminder, now, emails)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderScheduler) deliver(ctx context.Context, todo *model.Todo, reminder model.Reminder, now time.Time, emails map[string]string) {
+	claimed, err := s.todoRepo.ClaimReminder(ctx, todo.ID, reminder.ID, now)
+	if err != nil {
+		log.Printf("Failed to claim reminder %s: %v", reminder.ID.Hex(), err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	userID := todo.UserID.Hex()
+	email, ok := emails[userID]
+	if !ok {
+		if user, err := s.userRepo.FindByID(ctx, userID); err == nil && user != nil {
+			email = user.Email
+		}
+		emails[userID] = email
+	}
+
+	notification := notifier.Notification{
+		TodoID:    todo.ID.Hex(),
+		UserID:    userID,
+		Email:     email,
+		Title:     todo.Title,
+		RemindAt:  reminder.RemindAt,
+		Offset:    reminder.Offset,
+		TimeZone:  todo.TimeZone,
+		CreatedAt: now,
+	}
+	if todo.DueAt != nil {
+		notification.DueAt = *todo.DueAt
+	}
+
+	if err := s.notifier.Notify(ctx, notification); err != nil {
+		s.release(ctx, todo, reminder, now, err)
+	}
+}
+
+// release hands back a reminder whose notification failed, to be retried
+// later or, once it has run out of attempts, never again
+func (s *ReminderScheduler) release(ctx context.Context, todo *model.Todo, reminder model.Reminder, now time.Time, cause error) {
+	attempts := reminder.Attempts + 1
+	if attempts >= s.maxAttempts {
+		log.Printf("Giving up on reminder %s after %d attempts: %v", reminder.ID.Hex(), attempts, cause)
+		if err := s.todoRepo.FailReminder(ctx, todo.ID, reminder.ID, attempts, now); err != nil {
+			log.Printf("Failed to release reminder %s: %v", reminder.ID.Hex(), err)
+		}
+		return
+	}
+
+	log.Printf("Failed to send reminder %s: %v", reminder.ID.Hex(), cause)
+	if err := s.todoRepo.RetryReminder(ctx, todo.ID, reminder.ID, attempts, now.Add(retryDelay(s.backoff, attempts))); err != nil {
+		log.Printf("Failed to release reminder %s: %v", reminder.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo-app/internal/repository"
+)
+
+// TrashPurger periodically hard-deletes todos that have been in the trash
+// for longer than the retention period
+type TrashPurger struct {
+	todoRepo  repository.TodoRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(todoRepo repository.TodoRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		todoRepo:  todoRepo,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Start runs the purger until ctx is cancelled
+func (p *TrashPurger) Start(ctx context.Context) {
+	log.Printf("Trash purger started (retention %s, interval %s)", p.retention, p.interval)
+	runEvery(ctx, p.interval, func(ctx context.Context) {
+		if err := p.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Trash purge failed: %v", err)
+		}
+	})
+}
+
+// RunOnce removes every todo trashed more than the retention period before now
+func (p *TrashPurger) RunOnce(ctx context.Context, now time.Time) error {
+	purged, err := p.todoRepo.PurgeDeletedBefore(ctx, now.Add(-p.retention))
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d todos from the trash", purged)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/netguard"
+	"todo-app/internal/repository"
+)
+
+// Headers sent with every webhook request
+const (
+	WebhookSignatureHeader = "X-Todo-Signature-256"
+	WebhookEventHeader     = "X-Todo-Event"
+	WebhookDeliveryHeader  = "X-Todo-Delivery"
+)
+
+const (
+	// webhookBatchSize caps the number of deliveries sent in one scan
+	webhookBatchSize = 100
+	// webhookTimeout is how long an endpoint has to answer
+	webhookTimeout = 10 * time.Second
+	// webhookLease is how long a claimed delivery is held. It outlasts the
+	// timeout so a delivery is not sent twice at once.
+	webhookLease = time.Minute
+)
+
+// WebhookSignature is the value of the signature header for a body: the
+// hex HMAC-SHA256 of the body keyed with the webhook's secret
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher periodically sends the webhook deliveries that are due.
+// A failed delivery is retried after backoff, doubling the wait each time,
+// until it has been attempted maxAttempts times and is marked dead.
+type WebhookDispatcher struct {
+	deliveryRepo repository.WebhookDeliveryRepository
+	webhookRepo  repository.WebhookRepository
+	client       *http.Client
+	maxAttempts  int
+	backoff      time.Duration
+	interval     time.Duration
+}
+
+// NewWebhookDispatcher connects to endpoints through guard, so deliveries
+// cannot reach addresses inside the server's network
+func NewWebhookDispatcher(deliveryRepo repository.WebhookDeliveryRepository, webhookRepo repository.WebhookRepository, guard *netguard.Guard, maxAttempts int, backoff, interval time.Duration) *WebhookDispatcher {
+	client := guard.Client(webhookTimeout)
+	// A redirect is not a delivery
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &WebhookDispatcher{
+		deliveryRepo: deliveryRepo,
+		webhookRepo:  webhookRepo,
+		client:       client,
+		maxAttempts:  maxAttempts,
+		backoff:      backoff,
+		interval:     interval,
+	}
+}
+
+// Start runs the dispatcher until ctx is cancelled
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	log.Printf("Webhook dispatcher started (interval %s, %d attempts)", d.interval, d.maxAttempts)
+	runEvery(ctx, d.interval, func(ctx context.Context) {
+		if err := d.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Webhook dispatch failed: %v", err)
+		}
+	})
+}
+
+// RunOnce sends every delivery due at now
+func (d *WebhookDispatcher) RunOnce(ctx context.Context, now time.Time) error {
+	for i := 0; i < webhookBatchSize; i++ {
+		delivery, err := d.deliveryRepo.Claim(ctx, now, webhookLease)
+		if err != nil {
+			return err
+		}
+		if delivery == nil {
+			return nil
+		}
+		d.deliver(ctx, delivery, now)
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery, now time.Time) {
+	webhook, err := d.webhookRepo.FindByID(ctx, delivery.WebhookID.Hex(), delivery.UserID.Hex())
+	if err != nil {
+		// Deleted webhooks take their deliveries with them, so this is a
+		// lookup failure; the lease makes the delivery come round again
+		log.Printf("Failed to load webhook %s: %v", delivery.WebhookID.Hex(), err)
+		return
+	}
+
+	started := time.Now()
+	statusCode, err := d.send(ctx, webhook, delivery)
+	attempt := model.WebhookAttempt{
+		At:         now,
+		StatusCode: statusCode,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+
+	delivery.Tries++
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Tries >= d.maxAttempts:
+		attempt.Error = err.Error()
+		delivery.Status = model.DeliveryDead
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook delivery %s is dead after %d attempts: %v", delivery.ID.Hex(), delivery.Tries, err)
+	default:
+		attempt.Error = err.Error()
+		next := now.Add(retryDelay(d.backoff, delivery.Tries))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := d.deliveryRepo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID.Hex(), err)
+	}
+}
+
+// send POSTs a delivery and returns the status the endpoint answered with.
+// Anything but a 2xx status is an error.
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-app-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(webhook.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,35 @@
+// Package worker holds the background jobs started alongside the HTTP server.
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// maxRetryDelay caps the wait between attempts of a failed job
+const maxRetryDelay = 6 * time.Hour
+
+// retryDelay is how long to wait after the given number of failed tries,
+// starting at backoff and doubling each time
+func retryDelay(backoff time.Duration, tries int) time.Duration {
+	delay := backoff
+	for i := 1; i < tries && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// runEvery calls fn straight away and then once per interval until ctx is done
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
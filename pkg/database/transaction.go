@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transactionSupport caches, per client, whether the deployment can run
+// multi-document transactions
+var transactionSupport sync.Map
+
+// SupportsTransactions reports whether the deployment behind db is a replica
+// set or sharded cluster. Standalone servers cannot run transactions.
+func SupportsTransactions(ctx context.Context, db *mongo.Database) bool {
+	client := db.Client()
+	if supported, ok := transactionSupport.Load(client); ok {
+		return supported.(bool)
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	transactionSupport.Store(client, supported)
+	return supported
+}
+
+// RunInTransaction runs fn inside a transaction when the deployment supports
+// one, and directly otherwise. fn must use the context it is given so its
+// operations join the transaction. Calls made inside a transaction join it
+// rather than starting another one.
+func RunInTransaction(ctx context.Context, db *mongo.Database, fn func(ctx context.Context) error) error {
+	if !SupportsTransactions(ctx, db) || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
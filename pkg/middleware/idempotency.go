@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the header clients send to make a POST safe to
+// retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodyBytes caps the body read to fingerprint a request. No
+// endpoint takes a larger body than an import.
+const maxIdempotentBodyBytes = model.MaxImportBytes
+
+// replayedHeaders are the response headers stored along with the body
+var replayedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Location"}
+
+// IdempotencyStore keeps requests made with an Idempotency-Key, per user
+type IdempotencyStore interface {
+	// Reserve claims the key of record, or returns the record already
+	// holding it
+	Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	// Complete stores the response of a reserved request
+	Complete(ctx context.Context, record *model.IdempotencyRecord) error
+	// Release frees the key of a request that did not complete
+	Release(ctx context.Context, record *model.IdempotencyRecord) error
+}
+
+// Idempotency makes POST requests sent with an Idempotency-Key safe to
+// retry. The first request with a key is handled as usual and its response
+// kept for ttl; retries get that response replayed instead of running the
+// request again. Reusing a key for a different request is refused with 422.
+// Keys are scoped to the user, so it must run after authentication.
+// Responses marked Cache-Control: no-store, such as those issuing secrets,
+// are never kept; a retry of their request runs again.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		userId, authenticated := ctx.Get("userId")
+		if ctx.Request.Method != http.MethodPost || key == "" || !authenticated {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(ctx, errors.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if stderrors.As(err, &tooLarge) {
+				respondWithError(ctx, errors.ErrRequestTooLarge)
+				return
+			}
+			respondWithError(ctx, errors.NewAPIError(http.StatusBadRequest, "INVALID_BODY", err.Error()))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &model.IdempotencyRecord{
+			UserID:      userId.(string),
+			Key:         key,
+			Fingerprint: fingerprint(ctx.Request, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		existing, err := store.Reserve(ctx.Request.Context(), record)
+		if err != nil {
+			respondWithError(ctx, err)
+			return
+		}
+		if existing != nil {
+			replay(ctx, record, existing)
+			return
+		}
+
+		// A request that fails or panics frees its key, so the retry runs.
+		// The client may be gone by then, hence the detached context.
+		stored := false
+		defer func() {
+			if !stored {
+				if err := store.Release(context.WithoutCancel(ctx.Request.Context()), record); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// Server errors may be transient and are not replayed
+		if recorder.Status() >= http.StatusInternalServerError || noStore(recorder.Header()) {
+			return
+		}
+		record.Completed = true
+		record.Status = recorder.Status()
+		record.Body = recorder.body.Bytes()
+		record.Header = map[string][]string{}
+		for _, name := range replayedHeaders {
+			if values := recorder.Header().Values(name); len(values) > 0 {
+				record.Header[name] = values
+			}
+		}
+		if err := store.Complete(context.WithoutCancel(ctx.Request.Context()), record); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replay answers a retried request with the stored response
+func replay(ctx *gin.Context, record, existing *model.IdempotencyRecord) {
+	if existing.Fingerprint != record.Fingerprint {
+		respondWithError(ctx, errors.ErrIdempotencyKeyReused)
+		return
+	}
+	if !existing.Completed {
+		ctx.Header("Retry-After", "1")
+		respondWithError(ctx, errors.ErrIdempotencyInProgress)
+		return
+	}
+
+	for name, values := range existing.Header {
+		for _, value := range values {
+			ctx.Writer.Header().Add(name, value)
+		}
+	}
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.Status(existing.Status)
+	if _, err := ctx.Writer.Write(existing.Body); err != nil {
+		log.Printf("Failed to replay idempotent response: %v", err)
+	}
+	ctx.Abort()
+}
+
+// noStore reports whether a response forbids being stored
+func noStore(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fingerprint identifies a request by its method, target and body
+func fingerprint(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, request.Method+" "+request.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
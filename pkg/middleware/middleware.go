@@ -9,13 +9,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Logger logs each request with its route rather than its path, since
+// paths can hold secrets such as calendar feed tokens. Requests matching
+// no route are logged with their path.
 func Logger() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		start := time.Now()
 		ctx.Next()
 		duration := time.Since(start)
+		path := ctx.FullPath()
+		if path == "" {
+			path = ctx.Request.URL.Path
+		}
 		log.Printf("Request - Method: %s | Status: %d | Path: %s | Duration: %v",
-			ctx.Request.Method, ctx.Writer.Status(), ctx.Request.URL.Path, duration)
+			ctx.Request.Method, ctx.Writer.Status(), path, duration)
 	}
 }
 
@@ -61,10 +68,13 @@ func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match, If-None-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
-		if c.Request.Method == "OPTIONS" {
+		// Only preflights are answered here, so that CalDAV clients can
+		// discover what the server supports with OPTIONS
+		if c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != "" {
 			c.AbortWithStatus(204)
 			return
 		}
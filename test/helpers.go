@@ -6,12 +6,19 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
 func CreateTestRequest(t *testing.T, router http.Handler, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	return CreateTestRequestWithHeaders(t, router, method, path, body, token, nil)
+}
+
+// CreateTestRequestWithHeaders sends a JSON request with extra headers, such
+// as If-Match
+func CreateTestRequestWithHeaders(t *testing.T, router http.Handler, method, path string, body interface{}, token string, headers map[string]string) *httptest.ResponseRecorder {
 	var requestBody io.Reader
 
 	if body != nil {
@@ -25,6 +32,41 @@ func CreateTestRequest(t *testing.T, router http.Handler, method, path string, b
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// CreateRawTestRequest sends body as is with the given content type, for
+// endpoints that take something other than plain JSON
+func CreateRawTestRequest(t *testing.T, router http.Handler, method, path, contentType, body string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// CreateDAVTestRequest sends a CalDAV request signed in with HTTP Basic
+func CreateDAVTestRequest(t *testing.T, router http.Handler, method, path, body, email, password string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	}
+	if email != "" {
+		req.SetBasicAuth(email, password)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
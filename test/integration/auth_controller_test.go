@@ -13,6 +13,7 @@ import (
 	"todo-app/internal/repository"
 	"todo-app/internal/routes"
 	"todo-app/pkg/database"
+	"todo-app/pkg/middleware"
 	"todo-app/test"
 
 	"github.com/gin-gonic/gin"
@@ -38,13 +39,19 @@ func (suite *AuthControllerTestSuite) SetupSuite() {
 
 	// Initialize repository and services
 	suite.userRepo = repository.NewUserRepository(mongoDB.Database, "users")
-	suite.authService = auth.NewAuthService(config.JWTSecret, config.JWTExpiration, config.PasswordPepper, suite.userRepo)
+	listRepo := repository.NewListRepository(mongoDB.Database, "lists", "todos")
+	suite.authService = auth.NewAuthService(config.JWTSecret, config.JWTExpiration, config.PasswordPepper, suite.userRepo, listRepo)
 	suite.authController = controller.NewAuthController(suite.authService)
 
 	// Setup Gin
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	routes.SetupRoutes(router, suite.authController, nil, suite.authService)
+	idempotency := middleware.Idempotency(repository.NewIdempotencyRepository(mongoDB.Database, "idempotency_keys"), time.Hour)
+	routes.SetupRoutes(router, routes.Deps{
+		AuthController: suite.authController,
+		AuthService:    suite.authService,
+		Idempotency:    idempotency,
+	})
 	suite.router = router
 
 	// Clear the database before running tests
@@ -198,6 +205,46 @@ func (suite *AuthControllerTestSuite) TestLogin_InvalidCredentials() {
 	suite.Empty(response["token"])
 }
 
+func (suite *AuthControllerTestSuite) TestUpdateMe_ChangesTimeZone() {
+	register := model.UserRegister{Email: "zone@example.com", Password: "password123", FullName: "Zone User", TimeZone: "Europe/London"}
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/auth/register", register, "")
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/auth/login", model.AuthUser{Email: register.Email, Password: register.Password}, "")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var login map[string]string
+	test.ParseResponse(suite.T(), w, &login)
+	token := login["token"]
+
+	zone := "America/New_York"
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/auth/me", model.UserUpdate{TimeZone: &zone}, token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var user model.User
+	test.ParseResponse(suite.T(), w, &user)
+	suite.Equal("America/New_York", user.TimeZone)
+	suite.Equal("Zone User", user.FullName)
+
+	stored, err := suite.userRepo.FindByEmail(context.Background(), register.Email)
+	suite.Require().NoError(err)
+	suite.Equal("America/New_York", stored.TimeZone)
+
+	// Leaving the field out keeps it
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/auth/me", map[string]interface{}{}, token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &user)
+	suite.Equal("America/New_York", user.TimeZone)
+
+	for _, invalid := range []string{"Mars/Olympus_Mons", "Local", ""} {
+		w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/auth/me", model.UserUpdate{TimeZone: &invalid}, token)
+		suite.Equal(http.StatusBadRequest, w.Code, invalid)
+		var response map[string]interface{}
+		test.ParseResponse(suite.T(), w, &response)
+		suite.Contains(response["message"], "TimeZone must be an IANA time zone")
+	}
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/auth/me", model.UserUpdate{TimeZone: &zone}, "")
+	suite.Equal(http.StatusUnauthorized, w.Code)
+}
+
 func TestAuthControllerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthControllerTestSuite))
 }
@@ -0,0 +1,181 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/internal/config"
+	"todo-app/internal/model"
+	"todo-app/internal/notifier"
+	"todo-app/internal/repository"
+	"todo-app/internal/worker"
+	"todo-app/pkg/database"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []notifier.Notification
+	fail bool
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail {
+		return fmt.Errorf("delivery failed")
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+type ReminderSchedulerTestSuite struct {
+	suite.Suite
+	mongoDB  *database.MongoDB
+	todoRepo repository.TodoRepository
+	userRepo repository.UserRepository
+	userID   primitive.ObjectID
+}
+
+func (suite *ReminderSchedulerTestSuite) SetupSuite() {
+	config := config.LoadConfig()
+
+	mongoDB, err := database.NewMongoDB(config.MongoURI, "todo-test-db")
+	suite.Require().NoError(err)
+	suite.mongoDB = mongoDB
+
+	suite.todoRepo = repository.NewTodoRepository(mongoDB.Database, "todos")
+	suite.userRepo = repository.NewUserRepository(mongoDB.Database, "users")
+}
+
+func (suite *ReminderSchedulerTestSuite) TearDownSuite() {
+	if suite.mongoDB != nil {
+		suite.mongoDB.Close()
+	}
+}
+
+func (suite *ReminderSchedulerTestSuite) SetupTest() {
+	ctx := context.Background()
+	for _, name := range []string{"todos", "users"} {
+		_, err := suite.mongoDB.Database.Collection(name).DeleteMany(ctx, bson.M{})
+		suite.Require().NoError(err)
+	}
+
+	user, err := suite.userRepo.Create(ctx, &model.User{Email: "remind@example.com", FullName: "Remind Me"})
+	suite.Require().NoError(err)
+	suite.userID = user.ID
+}
+
+func (suite *ReminderSchedulerTestSuite) createTodoWithReminder(remindAt time.Time) *model.Todo {
+	due := remindAt.Add(time.Hour)
+	ctx := context.WithValue(context.Background(), "userId", suite.userID)
+	todo, err := suite.todoRepo.Create(ctx, &model.TodoCreate{
+		Title: "Water plants",
+		DueAt: &due,
+		Reminders: []model.Reminder{
+			{ID: primitive.NewObjectID(), Offset: "-1h", RemindAt: remindAt},
+		},
+	})
+	suite.Require().NoError(err)
+	return todo
+}
+
+func (suite *ReminderSchedulerTestSuite) TestRunOnce_FiresDueReminderOnce() {
+	now := time.Now()
+	suite.createTodoWithReminder(now.Add(-time.Minute))
+	suite.createTodoWithReminder(now.Add(time.Hour))
+
+	recorder := &recordingNotifier{}
+	scheduler := worker.NewReminderScheduler(suite.todoRepo, suite.userRepo, recorder, 3, time.Minute, time.Minute)
+
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now))
+	// A second scheduler, as after a restart, must not fire it again
+	restarted := worker.NewReminderScheduler(suite.todoRepo, suite.userRepo, recorder, 3, time.Minute, time.Minute)
+	suite.Require().NoError(restarted.RunOnce(context.Background(), now.Add(time.Second)))
+
+	suite.Require().Len(recorder.sent, 1)
+	suite.Equal("Water plants", recorder.sent[0].Title)
+	suite.Equal("remind@example.com", recorder.sent[0].Email)
+}
+
+func (suite *ReminderSchedulerTestSuite) TestRunOnce_RetriesFailedDelivery() {
+	now := time.Now()
+	todo := suite.createTodoWithReminder(now.Add(-time.Minute))
+
+	recorder := &recordingNotifier{fail: true}
+	scheduler := worker.NewReminderScheduler(suite.todoRepo, suite.userRepo, recorder, 3, time.Minute, time.Minute)
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now))
+	suite.Empty(recorder.sent)
+
+	reminder := suite.reminder(todo.ID)
+	suite.Nil(reminder.SentAt)
+	suite.Equal(1, reminder.Attempts)
+	suite.WithinDuration(now.Add(time.Minute), *reminder.NextAttemptAt, time.Millisecond)
+
+	// Nothing is tried again before the backoff is over
+	recorder.fail = false
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now.Add(30*time.Second)))
+	suite.Empty(recorder.sent)
+
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now.Add(time.Minute)))
+	suite.Len(recorder.sent, 1)
+	reminder = suite.reminder(todo.ID)
+	suite.NotNil(reminder.SentAt)
+	suite.Nil(reminder.NextAttemptAt)
+}
+
+func (suite *ReminderSchedulerTestSuite) TestRunOnce_GivesUpAfterMaxAttempts() {
+	now := time.Now()
+	todo := suite.createTodoWithReminder(now.Add(-time.Minute))
+
+	recorder := &recordingNotifier{fail: true}
+	scheduler := worker.NewReminderScheduler(suite.todoRepo, suite.userRepo, recorder, 3, time.Minute, time.Minute)
+	// Retries wait one minute, then two
+	for _, at := range []time.Duration{0, time.Minute, 3 * time.Minute} {
+		suite.Require().NoError(scheduler.RunOnce(context.Background(), now.Add(at)))
+	}
+
+	reminder := suite.reminder(todo.ID)
+	suite.Equal(3, reminder.Attempts)
+	suite.NotNil(reminder.FailedAt)
+	suite.Nil(reminder.NextAttemptAt)
+
+	recorder.fail = false
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now.Add(time.Hour)))
+	suite.Empty(recorder.sent)
+}
+
+func (suite *ReminderSchedulerTestSuite) TestRunOnce_FailingRemindersDoNotHoldBackOthers() {
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		suite.createTodoWithReminder(now.Add(-time.Hour))
+	}
+
+	recorder := &recordingNotifier{fail: true}
+	scheduler := worker.NewReminderScheduler(suite.todoRepo, suite.userRepo, recorder, 3, time.Minute, time.Minute)
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now))
+
+	// A later reminder is due while the failed ones wait for their retry
+	suite.createTodoWithReminder(now)
+	recorder.fail = false
+	suite.Require().NoError(scheduler.RunOnce(context.Background(), now.Add(time.Second)))
+	suite.Len(recorder.sent, 1)
+}
+
+// reminder reads back the only reminder of a todo
+func (suite *ReminderSchedulerTestSuite) reminder(todoID primitive.ObjectID) model.Reminder {
+	var todo model.Todo
+	err := suite.mongoDB.Database.Collection("todos").FindOne(context.Background(), bson.M{"_id": todoID}).Decode(&todo)
+	suite.Require().NoError(err)
+	suite.Require().Len(todo.Reminders, 1)
+	return todo.Reminders[0]
+}
+
+func TestReminderSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(ReminderSchedulerTestSuite))
+}
@@ -0,0 +1,1831 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/internal/auth"
+	"todo-app/internal/config"
+	"todo-app/internal/controller"
+	"todo-app/internal/events"
+	"todo-app/internal/model"
+	"todo-app/internal/netguard"
+	"todo-app/internal/repository"
+	"todo-app/internal/routes"
+	"todo-app/internal/service"
+	"todo-app/internal/stats"
+	"todo-app/internal/worker"
+	"todo-app/pkg/database"
+	"todo-app/pkg/middleware"
+	"todo-app/test"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+type TodoControllerTestSuite struct {
+	suite.Suite
+	router      *gin.Engine
+	mongoDB     *database.MongoDB
+	userRepo    repository.UserRepository
+	authService auth.Service
+	todoService service.TodoService
+	idempotency gin.HandlerFunc
+	// Lets webhooks reach receivers started by the tests
+	webhookGuard *netguard.Guard
+	token        string
+}
+
+func (suite *TodoControllerTestSuite) SetupSuite() {
+	config := config.LoadConfig()
+
+	mongoDB, err := database.NewMongoDB(config.MongoURI, "todo-test-db")
+	suite.Require().NoError(err)
+	suite.mongoDB = mongoDB
+
+	suite.userRepo = repository.NewUserRepository(mongoDB.Database, "users")
+	todoRepo := repository.NewTodoRepository(mongoDB.Database, "todos")
+	labelRepo := repository.NewLabelRepository(mongoDB.Database, "labels", "todos")
+	listRepo := repository.NewListRepository(mongoDB.Database, "lists", "todos")
+	workflowRepo := repository.NewWorkflowRepository(mongoDB.Database, "workflows")
+	revisionRepo := repository.NewRevisionRepository(mongoDB.Database, "revisions", "todos")
+	suite.idempotency = middleware.Idempotency(repository.NewIdempotencyRepository(mongoDB.Database, "idempotency_keys"), time.Hour)
+	suite.authService = auth.NewAuthService(config.JWTSecret, config.JWTExpiration, config.PasswordPepper, suite.userRepo, listRepo)
+	suite.webhookGuard, err = netguard.New([]string{"127.0.0.1", "::1"})
+	suite.Require().NoError(err)
+	webhookService := service.NewWebhookService(repository.NewWebhookRepository(mongoDB.Database, "webhooks"), repository.NewWebhookDeliveryRepository(mongoDB.Database, "webhook_deliveries"), suite.webhookGuard)
+	eventBus := events.NewBus(100, 16, 2)
+	todoService := service.NewTodoService(todoRepo, suite.userRepo, labelRepo, listRepo, workflowRepo, revisionRepo, webhookService, eventBus)
+	suite.todoService = todoService
+	labelService := service.NewLabelService(labelRepo)
+	listService := service.NewListService(listRepo, todoService)
+	calendarService := service.NewCalendarService(todoRepo, labelRepo, repository.NewCalendarFeedRepository(mongoDB.Database, "calendar_feeds"))
+	caldavService := service.NewCalDAVService(todoService, todoRepo, listRepo, labelRepo, suite.userRepo)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, routes.Deps{
+		AuthController:     controller.NewAuthController(suite.authService),
+		TodoController:     controller.NewTodoController(todoService, false),
+		LabelController:    controller.NewLabelController(labelService),
+		ListController:     controller.NewListController(listService),
+		WorkflowController: controller.NewWorkflowController(service.NewWorkflowService(workflowRepo, listRepo, todoRepo)),
+		CalendarController: controller.NewCalendarController(calendarService, ""),
+		CalDAVController:   controller.NewCalDAVController(caldavService),
+		WebhookController:  controller.NewWebhookController(webhookService),
+		StreamController:   controller.NewStreamController(eventBus, time.Hour),
+		AuthService:        suite.authService,
+		Idempotency:        suite.idempotency,
+	})
+	suite.router = router
+}
+
+func (suite *TodoControllerTestSuite) TearDownSuite() {
+	if suite.mongoDB != nil {
+		suite.mongoDB.Close()
+	}
+}
+
+func (suite *TodoControllerTestSuite) SetupTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Empty the collections rather than dropping them so the indexes
+	// created by the repositories survive between tests
+	for _, name := range []string{"todos", "users", "labels", "lists", "revisions", "idempotency_keys", "calendar_feeds", "webhooks", "webhook_deliveries", "todos_sequences", "todos_tombstones", "workflows"} {
+		_, err := suite.mongoDB.Database.Collection(name).DeleteMany(ctx, bson.M{})
+		suite.Require().NoError(err, "Failed to clear %s collection", name)
+	}
+
+	suite.token = suite.registerAndLogin("todo@example.com")
+}
+
+func (suite *TodoControllerTestSuite) registerAndLogin(email string) string {
+	return suite.registerAndLoginIn(email, "")
+}
+
+func (suite *TodoControllerTestSuite) registerAndLoginIn(email, timeZone string) string {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/auth/register", model.UserRegister{
+		Email:    email,
+		Password: "password123",
+		FullName: "Todo User",
+		TimeZone: timeZone,
+	}, "")
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/auth/login", model.AuthUser{
+		Email:    email,
+		Password: "password123",
+	}, "")
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var response map[string]string
+	test.ParseResponse(suite.T(), w, &response)
+	return response["token"]
+}
+
+func (suite *TodoControllerTestSuite) createTodo(body interface{}) model.Todo {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", body, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var todo model.Todo
+	test.ParseResponse(suite.T(), w, &todo)
+	return todo
+}
+
+func (suite *TodoControllerTestSuite) createLabel(name string) model.Label {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/labels", model.LabelCreate{Name: name}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var label model.Label
+	test.ParseResponse(suite.T(), w, &label)
+	return label
+}
+
+func (suite *TodoControllerTestSuite) listTitles(path string) []string {
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	titles := []string{}
+	for _, todo := range page.Items {
+		titles = append(titles, todo.Title)
+	}
+	return titles
+}
+
+func (suite *TodoControllerTestSuite) TestGetAllTodos_PaginatesWithCursor() {
+	for i := 0; i < 5; i++ {
+		suite.createTodo(model.TodoCreate{Title: fmt.Sprintf("Todo %d", i)})
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		path := "/todos?limit=2&sort=title&order=asc"
+		if cursor != "" {
+			path += "&cursor=" + cursor
+		}
+		w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code)
+
+		var page model.TodoPage
+		test.ParseResponse(suite.T(), w, &page)
+		for _, todo := range page.Items {
+			seen = append(seen, todo.Title)
+		}
+
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	suite.Equal([]string{"Todo 0", "Todo 1", "Todo 2", "Todo 3", "Todo 4"}, seen)
+}
+
+func (suite *TodoControllerTestSuite) TestGetAllTodos_FiltersByCompleted() {
+	completed := true
+	suite.createTodo(model.TodoCreate{Title: "Done", Completed: &completed})
+	suite.createTodo(model.TodoCreate{Title: "Open"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?completed=true", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	suite.Require().Len(page.Items, 1)
+	suite.Equal("Done", page.Items[0].Title)
+	suite.Empty(page.NextCursor)
+}
+
+func (suite *TodoControllerTestSuite) TestGetAllTodos_RejectsCursorForDifferentSort() {
+	for i := 0; i < 3; i++ {
+		suite.createTodo(model.TodoCreate{Title: fmt.Sprintf("Todo %d", i)})
+	}
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?limit=1&sort=title", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	suite.Require().NotEmpty(page.NextCursor)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?sort=createdAt&cursor="+page.NextCursor, nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestGetAllTodos_RejectsInvalidSort() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?sort=priority", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestSearchTodos_RanksAndHighlights() {
+	suite.createTodo(model.TodoCreate{Title: "Buy groceries"})
+	suite.createTodo(model.TodoCreate{Title: "Call mum", Description: "ask about the groceries"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/search?q=Groceries", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var results []model.TodoSearchResult
+	test.ParseResponse(suite.T(), w, &results)
+	suite.Require().Len(results, 2)
+	suite.Equal("Buy groceries", results[0].Todo.Title)
+	suite.Equal("Buy <mark>groceries</mark>", results[0].Highlights["title"])
+	suite.Equal("ask about the <mark>groceries</mark>", results[1].Highlights["description"])
+}
+
+func (suite *TodoControllerTestSuite) TestCreateTodo_RemindersFollowUserTimeZoneAcrossDST() {
+	suite.token = suite.registerAndLoginIn("newyork@example.com", "America/New_York")
+
+	// 9am on the Monday after US clocks went forward on 2025-03-09
+	due := time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC)
+	todo := suite.createTodo(model.TodoCreate{Title: "Pay rent", DueAt: &due, RemindAt: []string{"-1d", "-1w", "-30m"}})
+
+	suite.Require().Len(todo.Reminders, 3)
+	suite.Equal("America/New_York", todo.TimeZone)
+	suite.Equal(time.Date(2025, 3, 9, 13, 0, 0, 0, time.UTC), todo.Reminders[0].RemindAt.UTC())
+	// A week earlier is still 9am local, which was 14:00 UTC before the change
+	suite.Equal(time.Date(2025, 3, 3, 14, 0, 0, 0, time.UTC), todo.Reminders[1].RemindAt.UTC())
+	suite.Equal(time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC), todo.Reminders[2].RemindAt.UTC())
+}
+
+func (suite *TodoControllerTestSuite) TestCreateTodo_RejectsInvalidReminder() {
+	due := time.Now().Add(time.Hour)
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Bad", DueAt: &due, RemindAt: []string{"-1y"}}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "No due date", RemindAt: []string{"-1d"}}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestUpdateTodo_CompletingRecurringTodoSpawnsNext() {
+	due := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC) // a Monday
+	todo := suite.createTodo(model.TodoCreate{Title: "Take out bins", Priority: model.PriorityHigh, DueAt: &due, Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3"})
+
+	for i := 0; i < 2; i++ {
+		w := test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"completed":true}`, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code)
+	}
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?completed=false", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	suite.Require().Len(page.Items, 1, "completing twice must spawn a single follow-up")
+	suite.Equal("Take out bins", page.Items[0].Title)
+	suite.Equal(model.PriorityHigh, page.Items[0].Priority)
+	suite.Equal(time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC), page.Items[0].DueAt.UTC())
+}
+
+func (suite *TodoControllerTestSuite) TestGetOccurrences_PreviewsWindow() {
+	due := time.Date(2030, 1, 31, 9, 0, 0, 0, time.UTC)
+	todo := suite.createTodo(model.TodoCreate{Title: "Monthly report", DueAt: &due, Recurrence: "FREQ=MONTHLY;BYDAY=-1FR"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+todo.ID.Hex()+"/occurrences?from=2030-02-01T00:00:00Z&to=2030-04-30T00:00:00Z", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var occurrences model.TodoOccurrences
+	test.ParseResponse(suite.T(), w, &occurrences)
+	suite.Require().Len(occurrences.Occurrences, 3)
+	suite.Equal(time.Date(2030, 2, 22, 9, 0, 0, 0, time.UTC), occurrences.Occurrences[0].UTC())
+}
+
+func (suite *TodoControllerTestSuite) TestCreateTodo_RejectsInvalidRecurrence() {
+	due := time.Now()
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Bad", DueAt: &due, Recurrence: "FREQ=HOURLY"}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestSubtasks_ProgressAndCycles() {
+	parent := suite.createTodo(model.TodoCreate{Title: "Move house"})
+	completed := true
+	suite.createTodo(model.TodoCreate{Title: "Pack", ParentID: &parent.ID, Completed: &completed})
+	child := suite.createTodo(model.TodoCreate{Title: "Book van", ParentID: &parent.ID})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+parent.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var fetched model.Todo
+	test.ParseResponse(suite.T(), w, &fetched)
+	suite.Require().NotNil(fetched.Progress)
+	suite.Equal(50, *fetched.Progress)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+parent.ID.Hex()+"/children", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var children []model.Todo
+	test.ParseResponse(suite.T(), w, &children)
+	suite.Len(children, 2)
+
+	// Moving the parent under its own child would create a cycle
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+parent.ID.Hex()+"/parent", model.TodoMove{ParentID: &child.ID}, suite.token)
+	suite.Equal(http.StatusConflict, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+child.ID.Hex()+"/parent", model.TodoMove{}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var moved model.Todo
+	test.ParseResponse(suite.T(), w, &moved)
+	suite.Nil(moved.ParentID)
+}
+
+func (suite *TodoControllerTestSuite) TestSubtasks_CapsDepth() {
+	parent := suite.createTodo(model.TodoCreate{Title: "Level 1"})
+	for level := 2; level <= model.MaxTodoDepth; level++ {
+		parent = suite.createTodo(model.TodoCreate{Title: fmt.Sprintf("Level %d", level), ParentID: &parent.ID})
+	}
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Too deep", ParentID: &parent.ID}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestDeleteTodo_CascadeModes() {
+	orphanParent := suite.createTodo(model.TodoCreate{Title: "Orphan parent"})
+	orphan := suite.createTodo(model.TodoCreate{Title: "Orphan", ParentID: &orphanParent.ID})
+	deleteParent := suite.createTodo(model.TodoCreate{Title: "Delete parent"})
+	deleted := suite.createTodo(model.TodoCreate{Title: "Deleted", ParentID: &deleteParent.ID})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+orphanParent.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+orphan.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var kept model.Todo
+	test.ParseResponse(suite.T(), w, &kept)
+	suite.Nil(kept.ParentID)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+deleteParent.ID.Hex()+"?cascade=delete", nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+deleted.ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestLabels_UniquePerUserIgnoringCase() {
+	suite.createLabel("Work")
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/labels", model.LabelCreate{Name: "work"}, suite.token)
+	suite.Equal(http.StatusConflict, w.Code)
+
+	// Another user may use the same name
+	otherToken := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/labels", model.LabelCreate{Name: "Work"}, otherToken)
+	suite.Equal(http.StatusCreated, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestLabels_FilterTodosByAllOrAny() {
+	work := suite.createLabel("Work")
+	urgent := suite.createLabel("Urgent")
+	suite.createTodo(model.TodoCreate{Title: "Both", Labels: []primitive.ObjectID{work.ID, urgent.ID}})
+	suite.createTodo(model.TodoCreate{Title: "Work only", Labels: []primitive.ObjectID{work.ID}})
+	plain := suite.createTodo(model.TodoCreate{Title: "Plain"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+plain.ID.Hex()+"/labels/"+urgent.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	suite.ElementsMatch([]string{"Both"}, suite.listTitles("/todos?label=work,urgent"))
+	suite.ElementsMatch([]string{"Both", "Work only", "Plain"}, suite.listTitles("/todos?label=Work&label=Urgent&labelMatch=any"))
+	suite.Empty(suite.listTitles("/todos?label=missing"))
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+plain.ID.Hex()+"/labels/"+urgent.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.ElementsMatch([]string{"Both"}, suite.listTitles("/todos?label=urgent"))
+}
+
+func (suite *TodoControllerTestSuite) TestLabels_MergeMovesTodos() {
+	work := suite.createLabel("Work")
+	job := suite.createLabel("Job")
+	suite.createTodo(model.TodoCreate{Title: "Both", Labels: []primitive.ObjectID{work.ID, job.ID}})
+	suite.createTodo(model.TodoCreate{Title: "Job only", Labels: []primitive.ObjectID{job.ID}})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/labels/"+job.ID.Hex()+"/merge", model.LabelMerge{TargetID: work.ID}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	suite.ElementsMatch([]string{"Both", "Job only"}, suite.listTitles("/todos?label=work"))
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/labels/"+job.ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?label=work&limit=1", nil, suite.token)
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	suite.Require().Len(page.Items, 1)
+	suite.Equal([]primitive.ObjectID{work.ID}, page.Items[0].Labels)
+}
+
+func (suite *TodoControllerTestSuite) TestLists_InboxCreatedAtRegistration() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/lists", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var lists []model.List
+	test.ParseResponse(suite.T(), w, &lists)
+	suite.Require().Len(lists, 1)
+	suite.True(lists[0].Inbox)
+	suite.Equal(model.InboxListName, lists[0].Name)
+
+	// New todos land in the Inbox
+	todo := suite.createTodo(model.TodoCreate{Title: "Unsorted"})
+	suite.Require().NotNil(todo.ListID)
+	suite.Equal(lists[0].ID, *todo.ListID)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/lists/"+lists[0].ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestLists_MoveTodosBetweenLists() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: "Home", Icon: "🏠"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var home model.List
+	test.ParseResponse(suite.T(), w, &home)
+
+	parent := suite.createTodo(model.TodoCreate{Title: "Paint fence"})
+	child := suite.createTodo(model.TodoCreate{Title: "Buy paint", ParentID: &parent.ID})
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+parent.ID.Hex()+"/list", model.TodoListMove{ListID: home.ID}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// The subtask moves with its parent
+	suite.ElementsMatch([]string{"Paint fence", "Buy paint"}, suite.listTitles("/lists/"+home.ID.Hex()+"/todos"))
+
+	archived := true
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/lists/"+home.ID.Hex(), model.ListUpdate{Archived: &archived}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Mow lawn", ListID: &home.ID}, suite.token)
+	suite.Equal(http.StatusConflict, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+child.ID.Hex(), nil, suite.token)
+	var moved model.Todo
+	test.ParseResponse(suite.T(), w, &moved)
+	suite.Equal(home.ID, *moved.ListID)
+}
+
+func (suite *TodoControllerTestSuite) TestLists_DeleteMovesOrDeletesTodos() {
+	newList := func(name string) model.List {
+		w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: name}, suite.token)
+		suite.Require().Equal(http.StatusCreated, w.Code)
+		var list model.List
+		test.ParseResponse(suite.T(), w, &list)
+		return list
+	}
+	kept := newList("Kept")
+	dropped := newList("Dropped")
+	keptTodo := suite.createTodo(model.TodoCreate{Title: "Keep me", ListID: &kept.ID})
+	droppedTodo := suite.createTodo(model.TodoCreate{Title: "Drop me", ListID: &dropped.ID})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/lists/"+kept.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+keptTodo.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var todo model.Todo
+	test.ParseResponse(suite.T(), w, &todo)
+	suite.NotEqual(kept.ID, *todo.ListID)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/lists/"+dropped.ID.Hex()+"?todos=delete", nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+droppedTodo.ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestDescription_PersistsAndRendersHTML() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Shop", Description: "Get **milk** <script>alert(1)</script>"})
+	suite.Equal("Get **milk** <script>alert(1)</script>", todo.Description)
+	suite.Empty(todo.DescriptionHTML)
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+todo.ID.Hex()+"?render=html", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var fetched model.Todo
+	test.ParseResponse(suite.T(), w, &fetched)
+	suite.Equal("Get **milk** <script>alert(1)</script>", fetched.Description)
+	suite.Equal("<p>Get <strong>milk</strong> &lt;script&gt;alert(1)&lt;/script&gt;</p>\n", fetched.DescriptionHTML)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Shop", Description: "[docs](javascript:alert(1))"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+todo.ID.Hex()+"?render=html", nil, suite.token)
+	test.ParseResponse(suite.T(), w, &fetched)
+	suite.Equal("<p>docs</p>\n", fetched.DescriptionHTML)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+todo.ID.Hex()+"?render=pdf", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestUpdateTodo_ReplacesWholeTodo() {
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	todo := suite.createTodo(model.TodoCreate{Title: "Draft", Description: "notes", DueAt: &due, RemindAt: []string{"-1h"}})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Description: "no title"}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Final"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var replaced model.Todo
+	test.ParseResponse(suite.T(), w, &replaced)
+	suite.Equal("Final", replaced.Title)
+	suite.Empty(replaced.Description)
+	suite.Nil(replaced.DueAt)
+	suite.Empty(replaced.Reminders)
+}
+
+func (suite *TodoControllerTestSuite) TestPatchTodo_MergePatchSetsFalseAndNull() {
+	completed := true
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	todo := suite.createTodo(model.TodoCreate{Title: "Done", Description: "keep me", Completed: &completed, DueAt: &due})
+
+	w := test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"completed":false,"dueAt":null}`, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var patched model.Todo
+	test.ParseResponse(suite.T(), w, &patched)
+	suite.False(patched.Completed)
+	suite.Nil(patched.DueAt)
+	suite.Equal("Done", patched.Title)
+	suite.Equal("keep me", patched.Description)
+
+	// Removing the title leaves an invalid todo
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"title":null}`, suite.token)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	// Read-only fields cannot be patched
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"userId":"5f8d0614db5c5c7b3a18f200"}`, suite.token)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/json", `{"title":"x"}`, suite.token)
+	suite.Equal(http.StatusUnsupportedMediaType, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestPatchTodo_JSONPatch() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Old"})
+
+	patch := `[{"op":"test","path":"/title","value":"Old"},{"op":"replace","path":"/title","value":"New"},{"op":"add","path":"/description","value":"added"}]`
+	w := test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/json-patch+json", patch, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var patched model.Todo
+	test.ParseResponse(suite.T(), w, &patched)
+	suite.Equal("New", patched.Title)
+	suite.Equal("added", patched.Description)
+
+	// The test operation now fails, so nothing is applied
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/json-patch+json", patch, suite.token)
+	suite.Equal(http.StatusConflict, w.Code)
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/json-patch+json", `[{"op":"remove","path":"/missing"}]`, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestBulkTodos_CreateCompleteAndDeleteByFilter() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/bulk", model.TodoBulkRequest{
+		Action: "create",
+		Todos:  []model.TodoCreate{{Title: "One"}, {Title: "Two"}, {Title: "Three"}},
+	}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var created model.TodoBulkResponse
+	test.ParseResponse(suite.T(), w, &created)
+	suite.Require().Len(created.Results, 3)
+	suite.Equal(3, created.Succeeded)
+	suite.Equal(http.StatusCreated, created.Results[0].Status)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/bulk", model.TodoBulkRequest{
+		Action: "complete",
+		IDs:    []string{created.Results[0].ID, created.Results[1].ID},
+	}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.ElementsMatch([]string{"One", "Two"}, suite.listTitles("/todos?completed=true"))
+
+	completed := true
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/bulk", model.TodoBulkRequest{
+		Action: "delete",
+		Filter: &model.TodoBulkFilter{Completed: &completed},
+	}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var deleted model.TodoBulkResponse
+	test.ParseResponse(suite.T(), w, &deleted)
+	suite.Equal(2, deleted.Succeeded)
+	suite.Equal(http.StatusNoContent, deleted.Results[0].Status)
+	suite.Equal([]string{"Three"}, suite.listTitles("/todos"))
+}
+
+func (suite *TodoControllerTestSuite) TestBulkTodos_ReportsFailedItems() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Old"})
+	missing := primitive.NewObjectID().Hex()
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/bulk", model.TodoBulkRequest{
+		Action: "update",
+		IDs:    []string{todo.ID.Hex(), missing},
+		Patch:  []byte(`{"title":"New"}`),
+	}, suite.token)
+	suite.Require().Equal(http.StatusMultiStatus, w.Code, w.Body.String())
+	var response model.TodoBulkResponse
+	test.ParseResponse(suite.T(), w, &response)
+	suite.Require().Len(response.Results, 2)
+	suite.Equal(http.StatusNotFound, response.Results[1].Status)
+
+	// A transaction rolls back the successful update along with the failure
+	if response.Atomic {
+		suite.Equal(http.StatusFailedDependency, response.Results[0].Status)
+		suite.Equal([]string{"Old"}, suite.listTitles("/todos"))
+	} else {
+		suite.Equal(http.StatusOK, response.Results[0].Status)
+		suite.Equal([]string{"New"}, suite.listTitles("/todos"))
+	}
+
+	// Either ids or a filter must be given
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/bulk", model.TodoBulkRequest{Action: "delete"}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestTrash_RestoreAndPurge() {
+	parent := suite.createTodo(model.TodoCreate{Title: "Parent"})
+	child := suite.createTodo(model.TodoCreate{Title: "Child", ParentID: &parent.ID})
+	other := suite.createTodo(model.TodoCreate{Title: "Other"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+parent.ID.Hex()+"?cascade=delete", nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+other.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+
+	// Trashed todos are hidden from normal queries
+	suite.Empty(suite.listTitles("/todos"))
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+child.ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal([]string{"Other", "Child", "Parent"}, suite.listTitles("/trash"))
+
+	// Restoring the parent brings back the subtask deleted with it
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/trash/"+parent.ID.Hex()+"/restore", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var restored model.Todo
+	test.ParseResponse(suite.T(), w, &restored)
+	suite.Nil(restored.DeletedAt)
+	suite.Require().NotNil(restored.Progress)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+child.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var restoredChild model.Todo
+	test.ParseResponse(suite.T(), w, &restoredChild)
+	suite.Equal(parent.ID, *restoredChild.ParentID)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/trash/"+other.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	suite.Empty(suite.listTitles("/trash"))
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/trash/"+other.ID.Hex()+"/restore", nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestTrash_PurgerRemovesExpiredTodos() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Old news"})
+	w := test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+todo.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+
+	purger := worker.NewTrashPurger(repository.NewTodoRepository(suite.mongoDB.Database, "todos"), 24*time.Hour, time.Hour)
+	suite.Require().NoError(purger.RunOnce(context.Background(), time.Now()))
+	suite.Equal([]string{"Old news"}, suite.listTitles("/trash"))
+
+	suite.Require().NoError(purger.RunOnce(context.Background(), time.Now().Add(25*time.Hour)))
+	suite.Empty(suite.listTitles("/trash"))
+}
+
+func (suite *TodoControllerTestSuite) history(id primitive.ObjectID) []model.Revision {
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+id.Hex()+"/history", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var revisions []model.Revision
+	test.ParseResponse(suite.T(), w, &revisions)
+	return revisions
+}
+
+func (suite *TodoControllerTestSuite) TestHistory_RecordsAndRevertsRevisions() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Draft"})
+	suite.Equal(1, todo.Revision)
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Final", Completed: true}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	revisions := suite.history(todo.ID)
+	suite.Require().Len(revisions, 2)
+	suite.Equal(model.RevisionUpdate, revisions[0].Action)
+	suite.Equal(2, revisions[0].Revision)
+	suite.Equal(todo.UserID, revisions[0].ActorID)
+	suite.Require().Len(revisions[0].Changes, 3)
+	suite.Equal("title", revisions[0].Changes[0].Field)
+	suite.Equal("Draft", revisions[0].Changes[0].Old)
+	suite.Equal("Final", revisions[0].Changes[0].New)
+	suite.Equal("completed", revisions[0].Changes[1].Field)
+	suite.Equal("status", revisions[0].Changes[2].Field)
+	suite.Equal("todo", revisions[0].Changes[2].Old)
+	suite.Equal("done", revisions[0].Changes[2].New)
+	suite.Equal(model.RevisionCreate, revisions[1].Action)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+todo.ID.Hex()+"/revert/1", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var reverted model.Todo
+	test.ParseResponse(suite.T(), w, &reverted)
+	suite.Equal("Draft", reverted.Title)
+	suite.False(reverted.Completed)
+	suite.Equal(3, reverted.Revision)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+todo.ID.Hex()+"/revert/9", nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+
+	// The history outlives the todo
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+todo.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	revisions = suite.history(todo.ID)
+	suite.Require().Len(revisions, 4)
+	suite.Equal(model.RevisionDelete, revisions[0].Action)
+	suite.Equal(model.RevisionRevert, revisions[1].Action)
+	suite.Equal(1, revisions[1].RevertedTo)
+
+	// Other users cannot read it
+	otherToken := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+todo.ID.Hex()+"/history", nil, otherToken)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestIfMatch_RejectsStaleVersions() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Draft"})
+	suite.Equal(int64(1), todo.Version)
+	path := "/todos/" + todo.ID.Hex()
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Equal(`"1"`, w.Header().Get("ETag"))
+
+	// The first client wins and moves the version on
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "First"}, suite.token, map[string]string{"If-Match": `"1"`})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Equal(`"2"`, w.Header().Get("ETag"))
+
+	// The second client still holds version 1 and gets the current todo back
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "Second"}, suite.token, map[string]string{"If-Match": `"1"`})
+	suite.Require().Equal(http.StatusPreconditionFailed, w.Code, w.Body.String())
+	suite.Equal(`"2"`, w.Header().Get("ETag"))
+	var failed model.TodoPreconditionFailed
+	test.ParseResponse(suite.T(), w, &failed)
+	suite.Equal("First", failed.Todo.Title)
+	suite.Equal(int64(2), failed.Todo.Version)
+
+	// Weak tags never match, any of several strong ones does
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "Weak"}, suite.token, map[string]string{"If-Match": `W/"2"`})
+	suite.Equal(http.StatusPreconditionFailed, w.Code)
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "Third"}, suite.token, map[string]string{"If-Match": `"1", "2"`})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	req := httptest.NewRequest("PATCH", path, strings.NewReader(`{"completed": true}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("Authorization", "Bearer "+suite.token)
+	req.Header.Set("If-Match", `"2"`)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Equal(http.StatusPreconditionFailed, w.Code)
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "DELETE", path, nil, suite.token, map[string]string{"If-Match": `"2"`})
+	suite.Equal(http.StatusPreconditionFailed, w.Code)
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "DELETE", path, nil, suite.token, map[string]string{"If-Match": `"3"`})
+	suite.Equal(http.StatusNoContent, w.Code)
+
+	// A deleted todo is not found rather than out of date
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "DELETE", path, nil, suite.token, map[string]string{"If-Match": `"4"`})
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestIfMatch_CanBeRequired() {
+	router := gin.New()
+	routes.SetupRoutes(router, routes.Deps{
+		AuthController: controller.NewAuthController(suite.authService),
+		TodoController: controller.NewTodoController(suite.todoService, true),
+		AuthService:    suite.authService,
+		Idempotency:    suite.idempotency,
+	})
+
+	todo := suite.createTodo(model.TodoCreate{Title: "Draft"})
+	w := test.CreateTestRequest(suite.T(), router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Final"}, suite.token)
+	suite.Equal(http.StatusPreconditionRequired, w.Code)
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Final"}, suite.token, map[string]string{"If-Match": "*"})
+	suite.Equal(http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *TodoControllerTestSuite) TestHistory_RevertRestoresListParentAndLabels() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: "Home"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var home model.List
+	test.ParseResponse(suite.T(), w, &home)
+	kept, dropped := suite.createLabel("kept"), suite.createLabel("dropped")
+
+	parent := suite.createTodo(model.TodoCreate{Title: "Paint fence"})
+	todo := suite.createTodo(model.TodoCreate{Title: "Buy paint", ParentID: &parent.ID, Labels: []primitive.ObjectID{kept.ID, dropped.ID}})
+	child := suite.createTodo(model.TodoCreate{Title: "Pick colour", ParentID: &todo.ID})
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex()+"/list", model.TodoListMove{ListID: home.ID}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+todo.ID.Hex()+"/labels/"+kept.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/labels/"+dropped.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	suite.Contains(suite.listTitles("/lists/"+home.ID.Hex()+"/todos"), "Buy paint")
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+todo.ID.Hex()+"/revert/1", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var reverted model.Todo
+	test.ParseResponse(suite.T(), w, &reverted)
+	suite.Equal(&parent.ID, reverted.ParentID)
+	suite.Equal(parent.ListID, reverted.ListID)
+	// The deleted label stays gone
+	suite.Equal([]primitive.ObjectID{kept.ID}, reverted.Labels)
+	suite.NotContains(suite.listTitles("/lists/"+home.ID.Hex()+"/todos"), "Buy paint")
+
+	// The revert records every field it restored, and the subtask it
+	// carried back gets an update of its own
+	revisions := suite.history(todo.ID)
+	suite.Equal(model.RevisionRevert, revisions[0].Action)
+	var fields []string
+	for _, change := range revisions[0].Changes {
+		fields = append(fields, change.Field)
+	}
+	suite.Equal([]string{"parentId", "listId", "labels"}, fields)
+	suite.Equal(model.RevisionUpdate, suite.history(child.ID)[0].Action)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+child.ID.Hex(), nil, suite.token)
+	test.ParseResponse(suite.T(), w, &child)
+	suite.Equal(parent.ListID, child.ListID)
+
+	// A parent that is gone cannot be restored
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex()+"/parent", model.TodoMove{}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+parent.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+todo.ID.Hex()+"/revert/1", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func (suite *TodoControllerTestSuite) TestConditionalGet_AnswersNotModified() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Cached"})
+	path := "/todos/" + todo.ID.Hex()
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Header().Get("Cache-Control"), "private")
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	suite.NotEmpty(lastModified)
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", path, nil, suite.token, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusNotModified, w.Code)
+	suite.Empty(w.Body.String())
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", path, nil, suite.token, map[string]string{"If-Modified-Since": lastModified})
+	suite.Equal(http.StatusNotModified, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	listETag := w.Header().Get("ETag")
+	suite.True(strings.HasPrefix(listETag, `W/"`))
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", "/todos", nil, suite.token, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusNotModified, w.Code)
+
+	// Another query is another representation
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", "/todos?completed=true", nil, suite.token, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusOK, w.Code)
+
+	// Any change to a todo invalidates both the todo and the list
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "Changed"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", path, nil, suite.token, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusOK, w.Code)
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", "/todos", nil, suite.token, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestConditionalGet_TodoETagCoversProgressAndRendering() {
+	parent := suite.createTodo(model.TodoCreate{Title: "Move house", Description: "**Soon**"})
+	child := suite.createTodo(model.TodoCreate{Title: "Pack books", ParentID: &parent.ID})
+	suite.createTodo(model.TodoCreate{Title: "Book van", ParentID: &parent.ID})
+	path := "/todos/" + parent.ID.Hex()
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	suite.Empty(w.Header().Get("Last-Modified"))
+
+	// The HTML rendering is another representation
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", path+"?render=html", nil, suite.token, map[string]string{"If-None-Match": etag})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.NotEqual(etag, w.Header().Get("ETag"))
+
+	// Completing a subtask changes the parent's progress but not its version
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+child.ID.Hex(), model.TodoUpdate{Title: "Pack books", Completed: true}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", path, nil, suite.token, map[string]string{"If-None-Match": etag})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var todo model.Todo
+	test.ParseResponse(suite.T(), w, &todo)
+	suite.Equal(50, *todo.Progress)
+	etag = w.Header().Get("ETag")
+	suite.Equal(fmt.Sprintf(`"%d-p50"`, todo.Version), etag)
+
+	// If-Match only compares the version
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "PUT", path, model.TodoUpdate{Title: "Move flat"}, suite.token, map[string]string{"If-Match": etag})
+	suite.Equal(http.StatusOK, w.Code, w.Body.String())
+}
+
+func (suite *TodoControllerTestSuite) TestConditionalGet_ListETagNeverComesBack() {
+	suite.createTodo(model.TodoCreate{Title: "A"})
+	b := suite.createTodo(model.TodoCreate{Title: "B"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	listETag := w.Header().Get("ETag")
+
+	// Trashing, purging and creating brings back the count of todos and the
+	// sum of their versions, but not the list they make up
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+b.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/trash/"+b.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	suite.createTodo(model.TodoCreate{Title: "C"})
+
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", "/todos", nil, suite.token, map[string]string{"If-None-Match": listETag})
+	suite.Equal(http.StatusOK, w.Code)
+	suite.NotEqual(listETag, w.Header().Get("ETag"))
+}
+
+func (suite *TodoControllerTestSuite) TestIdempotencyKey_ReplaysRetries() {
+	headers := map[string]string{middleware.IdempotencyKeyHeader: "create-milk"}
+	create := model.TodoCreate{Title: "Buy milk"}
+
+	w := test.CreateTestRequestWithHeaders(suite.T(), suite.router, "POST", "/todos", create, suite.token, headers)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var created model.Todo
+	test.ParseResponse(suite.T(), w, &created)
+
+	// The retry gets the same response and creates nothing
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "POST", "/todos", create, suite.token, headers)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	suite.Equal("true", w.Header().Get("Idempotent-Replayed"))
+	var replayed model.Todo
+	test.ParseResponse(suite.T(), w, &replayed)
+	suite.Equal(created.ID, replayed.ID)
+	suite.Equal([]string{"Buy milk"}, suite.listTitles("/todos"))
+
+	// The key cannot be reused for another request
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Buy eggs"}, suite.token, headers)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+
+	// Keys belong to a user
+	otherToken := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "POST", "/todos", create, otherToken, headers)
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Empty(w.Header().Get("Idempotent-Replayed"))
+}
+
+func (suite *TodoControllerTestSuite) TestImportExport_RoundTripsAndSkipsDuplicates() {
+	label := suite.createLabel("Errands")
+	suite.createTodo(model.TodoCreate{Title: "Buy milk", Labels: []primitive.ObjectID{label.ID}})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/export?format=csv", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Contains(w.Header().Get("Content-Type"), "text/csv")
+	suite.Contains(w.Header().Get("Content-Disposition"), "todos.csv")
+	suite.Contains(w.Body.String(), "Buy milk")
+	suite.Contains(w.Body.String(), "Errands")
+	exported := w.Body.String()
+
+	csv := "title,list,labels\nPay rent,Bills,\"Errands,Monthly\"\n,,\nbuy MILK,,\n"
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "POST", "/todos/import?format=csv&dryRun=true", "text/csv", csv, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var result model.TodoImportResult
+	test.ParseResponse(suite.T(), w, &result)
+	suite.True(result.DryRun)
+	suite.Equal([]int{1, 1, 1}, []int{result.Created, result.Skipped, result.Rejected})
+	suite.Equal(3, result.Items[1].Line)
+	suite.Equal(model.ImportRejected, result.Items[1].Status)
+	suite.Equal([]string{"Buy milk"}, suite.listTitles("/todos"))
+
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "POST", "/todos/import?format=csv", "text/csv", csv, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &result)
+	suite.Equal(1, result.Created)
+	suite.ElementsMatch([]string{"Buy milk", "Pay rent"}, suite.listTitles("/todos"))
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+result.Items[0].ID, nil, suite.token)
+	var rent model.Todo
+	test.ParseResponse(suite.T(), w, &rent)
+	suite.Len(rent.Labels, 2)
+
+	// Importing the export again finds every todo already there
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "POST", "/todos/import?format=csv", "text/csv", exported, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &result)
+	suite.Equal(0, result.Created)
+	suite.Equal(1, result.Skipped)
+
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "POST", "/todos/import?format=json", "application/json", `{"title":"x"}`, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/export?format=xml", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestCalendar_FeedNeedsOnlyItsToken() {
+	due := time.Date(2030, 1, 5, 9, 0, 0, 0, time.UTC)
+	suite.createTodo(model.TodoCreate{Title: "Pay rent", DueAt: &due})
+	suite.createTodo(model.TodoCreate{Title: "Someday"})
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos.ics", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Contains(w.Header().Get("Content-Type"), "text/calendar")
+	suite.Equal(2, strings.Count(w.Body.String(), "BEGIN:VTODO"))
+	suite.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	suite.Contains(w.Body.String(), "DUE:20300105T090000Z")
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos.ics", nil, "")
+	suite.Equal(http.StatusUnauthorized, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/calendar/feed", nil, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var feed model.CalendarFeed
+	test.ParseResponse(suite.T(), w, &feed)
+	suite.Require().NotEmpty(feed.Token)
+	feedPath := "/calendar/feed/" + feed.Token + ".ics"
+	suite.True(strings.HasSuffix(feed.URL, feedPath))
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", feedPath+"?component=vevent", nil, "")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), "SUMMARY:Pay rent")
+	suite.NotContains(w.Body.String(), "BEGIN:VTODO")
+
+	// Calendar apps polling the feed get 304 while nothing changed
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", feedPath, nil, "", map[string]string{"If-None-Match": w.Header().Get("ETag")})
+	suite.Equal(http.StatusOK, w.Code, "another query is another representation")
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "GET", feedPath, nil, "", map[string]string{"If-None-Match": w.Header().Get("ETag")})
+	suite.Equal(http.StatusNotModified, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/calendar/feed", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.NotContains(w.Body.String(), feed.Token)
+
+	// Creating a feed again replaces the token, and revoking ends it. The
+	// token is never kept for replay, even with an Idempotency-Key.
+	w = test.CreateTestRequestWithHeaders(suite.T(), suite.router, "POST", "/calendar/feed", nil, suite.token, map[string]string{middleware.IdempotencyKeyHeader: "new-feed"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	suite.Contains(w.Header().Get("Cache-Control"), "no-store")
+	var replaced model.CalendarFeed
+	test.ParseResponse(suite.T(), w, &replaced)
+	count, err := suite.mongoDB.Database.Collection("idempotency_keys").CountDocuments(context.Background(), bson.M{})
+	suite.Require().NoError(err)
+	suite.Zero(count)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", feedPath, nil, "")
+	suite.Equal(http.StatusNotFound, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/calendar/feed", nil, suite.token)
+	suite.Equal(http.StatusNoContent, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/calendar/feed/"+replaced.Token, nil, "")
+	suite.Equal(http.StatusNotFound, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/calendar/feed", nil, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestCalDAV_SyncsTodosOfAList() {
+	dav := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		return test.CreateDAVTestRequest(suite.T(), suite.router, method, path, body, "todo@example.com", "password123", headers)
+	}
+
+	w := dav("PROPFIND", "/dav/calendars/", "", map[string]string{"Depth": "0"})
+	suite.Equal(http.StatusMultiStatus, w.Code)
+	w = test.CreateDAVTestRequest(suite.T(), suite.router, "PROPFIND", "/dav/calendars/", "", "todo@example.com", "wrong", nil)
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	suite.Contains(w.Header().Get("WWW-Authenticate"), "Basic")
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/lists", nil, suite.token)
+	var lists []model.List
+	test.ParseResponse(suite.T(), w, &lists)
+	suite.Require().Len(lists, 1)
+	calendar := "/dav/calendars/" + lists[0].ID.Hex() + "/"
+
+	w = dav("PROPFIND", "/dav/calendars/", `<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/"><d:prop><d:displayname/><cs:getctag/></d:prop></d:propfind>`, map[string]string{"Depth": "1"})
+	suite.Require().Equal(http.StatusMultiStatus, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), "<d:href>"+calendar+"</d:href>")
+	suite.Contains(w.Body.String(), "<d:displayname>"+model.InboxListName+"</d:displayname>")
+
+	// A todo created by a client keeps the name and UID it was sent with
+	vtodo := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:abc-123\r\nSUMMARY:Water plants\r\nDUE;VALUE=DATE:20300105\r\nCATEGORIES:home\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	w = dav("PUT", calendar+"abc-123.ics", vtodo, map[string]string{"If-None-Match": "*"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	w = dav("PUT", calendar+"abc-123.ics", vtodo, map[string]string{"If-None-Match": "*"})
+	suite.Equal(http.StatusPreconditionFailed, w.Code)
+
+	w = dav("GET", calendar+"abc-123.ics", "", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), "UID:abc-123")
+	suite.Contains(w.Body.String(), "DUE;VALUE=DATE:20300105")
+	suite.Contains(w.Body.String(), "CATEGORIES:home")
+	etag := w.Header().Get("ETag")
+	suite.NotEmpty(etag)
+
+	suite.Equal([]string{"Water plants"}, suite.listTitles("/todos"))
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/labels", nil, suite.token)
+	suite.Contains(w.Body.String(), `"home"`)
+
+	// Todos made in the app are resources too
+	made := suite.createTodo(model.TodoCreate{Title: "Call mom"})
+	query := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop>` +
+		`<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"/></c:comp-filter></c:filter></c:calendar-query>`
+	w = dav("REPORT", calendar, query, map[string]string{"Depth": "1"})
+	suite.Require().Equal(http.StatusMultiStatus, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), calendar+"abc-123.ics")
+	suite.Contains(w.Body.String(), calendar+made.ID.Hex()+".ics")
+	suite.Contains(w.Body.String(), "SUMMARY:Call mom")
+
+	// Replacing checks If-Match
+	done := strings.Replace(vtodo, "END:VTODO", "STATUS:COMPLETED\r\nEND:VTODO", 1)
+	w = dav("PUT", calendar+"abc-123.ics", done, map[string]string{"If-Match": `"999"`})
+	suite.Equal(http.StatusPreconditionFailed, w.Code)
+	w = dav("PUT", calendar+"abc-123.ics", done, map[string]string{"If-Match": etag})
+	suite.Require().Equal(http.StatusNoContent, w.Code, w.Body.String())
+	suite.Equal([]string{"Water plants"}, suite.listTitles("/todos?completed=true"))
+
+	multiget := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/></d:prop>` +
+		`<d:href>` + calendar + `abc-123.ics</d:href><d:href>` + calendar + `missing.ics</d:href></c:calendar-multiget>`
+	w = dav("REPORT", calendar, multiget, nil)
+	suite.Require().Equal(http.StatusMultiStatus, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), "HTTP/1.1 404 Not Found")
+
+	w = dav("DELETE", calendar+made.ID.Hex()+".ics", "", nil)
+	suite.Equal(http.StatusNoContent, w.Code)
+	w = dav("GET", calendar+made.ID.Hex()+".ics", "", nil)
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal([]string{"Water plants"}, suite.listTitles("/todos?completed=true"))
+}
+
+func (suite *TodoControllerTestSuite) TestWebhooks_DeliverSignedEventsAndRetry() {
+	type received struct {
+		event     string
+		signature string
+		payload   model.WebhookPayload
+		body      []byte
+	}
+	var requests []received
+	failing := true
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload model.WebhookPayload
+		json.Unmarshal(body, &payload)
+		requests = append(requests, received{r.Header.Get(worker.WebhookEventHeader), r.Header.Get(worker.WebhookSignatureHeader), payload, body})
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	const secret = "a-very-secret-signing-key"
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/webhooks", model.WebhookCreate{
+		URL:    receiver.URL,
+		Secret: secret,
+		Events: []string{model.EventTodoCreated, model.EventTodoCompleted},
+	}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	suite.NotContains(w.Body.String(), secret)
+	var webhook model.Webhook
+	test.ParseResponse(suite.T(), w, &webhook)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/webhooks", model.WebhookCreate{URL: "not a url", Secret: secret}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	for _, url := range []string{"http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hook", "http://[::ffff:192.168.1.1]/hook", "http://0.0.0.0:8080/"} {
+		w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/webhooks", model.WebhookCreate{URL: url, Secret: secret}, suite.token)
+		suite.Equal(http.StatusBadRequest, w.Code, url)
+		suite.Contains(w.Body.String(), "private")
+	}
+
+	todo := suite.createTodo(model.TodoCreate{Title: "Ship it"})
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Ship it now"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+todo.ID.Hex(), model.TodoUpdate{Title: "Ship it now", Completed: true}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	dispatcher := worker.NewWebhookDispatcher(
+		repository.NewWebhookDeliveryRepository(suite.mongoDB.Database, "webhook_deliveries"),
+		repository.NewWebhookRepository(suite.mongoDB.Database, "webhooks"),
+		suite.webhookGuard, 2, time.Minute, time.Second)
+	now := time.Now()
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), now))
+
+	// Updates are not subscribed to, so only the creation and completion
+	// are sent, and both fail
+	suite.Require().Len(requests, 2)
+	suite.Equal(model.EventTodoCreated, requests[0].event)
+	suite.Equal(model.EventTodoCompleted, requests[1].event)
+	suite.Equal("Ship it", requests[0].payload.Todo.Title)
+	suite.True(requests[1].payload.Todo.Completed)
+	suite.Equal(worker.WebhookSignature(secret, requests[0].body), requests[0].signature)
+
+	deliveries := func(query string) []model.WebhookDelivery {
+		w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/webhooks/"+webhook.ID.Hex()+"/deliveries"+query, nil, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var deliveries []model.WebhookDelivery
+		test.ParseResponse(suite.T(), w, &deliveries)
+		return deliveries
+	}
+	sent := deliveries("")
+	suite.Require().Len(sent, 2)
+	suite.Equal(model.DeliveryPending, sent[0].Status)
+	suite.Require().Len(sent[0].Attempts, 1)
+	suite.Equal(http.StatusServiceUnavailable, sent[0].Attempts[0].StatusCode)
+
+	// Nothing is retried before the backoff is up, and the last attempt
+	// marks the deliveries dead
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), now.Add(30*time.Second)))
+	suite.Len(requests, 2)
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), now.Add(2*time.Minute)))
+	suite.Len(requests, 4)
+	suite.Len(deliveries("?status=dead"), 2)
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), now.Add(time.Hour)))
+	suite.Len(requests, 4)
+
+	// A dead delivery can be retried by hand
+	failing = false
+	dead := deliveries("?status=dead")[0]
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/webhooks/"+webhook.ID.Hex()+"/deliveries/"+dead.ID.Hex()+"/retry", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/webhooks/"+webhook.ID.Hex()+"/deliveries/"+dead.ID.Hex()+"/retry", nil, suite.token)
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), time.Now()))
+	suite.Len(requests, 5)
+	delivered := deliveries("?status=delivered")
+	suite.Require().Len(delivered, 1)
+	suite.Len(delivered[0].Attempts, 3)
+	suite.NotNil(delivered[0].DeliveredAt)
+
+	// Other users cannot see the webhook
+	otherToken := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/webhooks/"+webhook.ID.Hex()+"/deliveries", nil, otherToken)
+	suite.Equal(http.StatusNotFound, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/webhooks/"+webhook.ID.Hex(), nil, suite.token)
+	suite.Equal(http.StatusNoContent, w.Code)
+	suite.createTodo(model.TodoCreate{Title: "Unheard"})
+	suite.Require().NoError(dispatcher.RunOnce(context.Background(), time.Now()))
+	suite.Len(requests, 5)
+}
+
+func (suite *TodoControllerTestSuite) TestStream_PushesChangesAndResumes() {
+	server := httptest.NewServer(suite.router)
+	defer server.Close()
+
+	// EventSource cannot send headers, so the token goes in the URL. The
+	// timeout keeps a missing event from hanging the test.
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(server.URL + "/todos/stream?access_token=" + suite.token)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	next := func() (id, event string, data model.StreamEvent) {
+		for {
+			line, err := reader.ReadString('\n')
+			suite.Require().NoError(err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && event != "":
+				return id, event, data
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				suite.Require().NoError(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data))
+			}
+		}
+	}
+
+	_, event, _ := next()
+	suite.Equal(model.StreamReset, event)
+
+	// Other users' changes are not sent
+	other := suite.registerAndLogin("other@example.com")
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Not mine"}, other)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	todo := suite.createTodo(model.TodoCreate{Title: "Buy milk"})
+	id, event, data := next()
+	suite.Equal(model.EventTodoCreated, event)
+	suite.Equal(id, data.ID)
+	suite.Equal("Buy milk", data.Todo.Title)
+
+	// Completing is sent as an update only
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"completed":true}`, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	_, event, data = next()
+	suite.Equal(model.EventTodoUpdated, event)
+	suite.True(data.Todo.Completed)
+
+	// A WebSocket resuming from the first event is sent what followed it
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+todo.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	_, event, _ = next()
+	suite.Equal(model.EventTodoDeleted, event)
+
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/todos/ws?lastEventId="+id, server.URL)
+	suite.Require().NoError(err)
+	config.Header.Set("Authorization", "Bearer "+suite.token)
+	ws, err := websocket.DialConfig(config)
+	suite.Require().NoError(err)
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var events []string
+	for range 2 {
+		var message model.StreamEvent
+		suite.Require().NoError(websocket.JSON.Receive(ws, &message))
+		events = append(events, message.Event)
+	}
+	suite.Equal([]string{model.EventTodoUpdated, model.EventTodoDeleted}, events)
+
+	// Streams are refused past the limit, and without a token
+	resp, err = http.Get(server.URL + "/todos/stream?access_token=" + suite.token)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/todos/stream")
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (suite *TodoControllerTestSuite) sync(since string) model.SyncChanges {
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/sync?since="+since, nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var changes model.SyncChanges
+	test.ParseResponse(suite.T(), w, &changes)
+	return changes
+}
+
+func (suite *TodoControllerTestSuite) TestSync_SendsOnlyChangesSinceToken() {
+	suite.createTodo(model.TodoCreate{Title: "Kept"})
+	edited := suite.createTodo(model.TodoCreate{Title: "Edited"})
+	purged := suite.createTodo(model.TodoCreate{Title: "Purged"})
+
+	// A first sync pages through every todo
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/sync?limit=2", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var first model.SyncChanges
+	test.ParseResponse(suite.T(), w, &first)
+	suite.Len(first.Changed, 2)
+	suite.True(first.More)
+	rest := suite.sync(first.Token)
+	suite.Len(rest.Changed, 1)
+	suite.False(rest.More)
+	suite.Empty(suite.sync(rest.Token).Changed)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+edited.ID.Hex(), map[string]string{"title": "Edited again"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/todos/"+purged.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	added := suite.createTodo(model.TodoCreate{Title: "Added"})
+
+	delta := suite.sync(rest.Token)
+	suite.Require().Len(delta.Changed, 2)
+	suite.Equal("Edited again", delta.Changed[0].Title)
+	suite.Equal(added.ID, delta.Changed[1].ID)
+	suite.Equal([]string{purged.ID.Hex()}, delta.Deleted)
+
+	// Purging a trashed todo leaves a tombstone
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/trash/"+purged.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	after := suite.sync(delta.Token)
+	suite.Empty(after.Changed)
+	suite.Equal([]string{purged.ID.Hex()}, after.Deleted)
+
+	// Another user's changes are not sent
+	other := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos", model.TodoCreate{Title: "Theirs"}, other)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	suite.Empty(suite.sync(after.Token).Changed)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/sync?since=garbage", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestSync_AppliesMutationsAndReportsConflicts() {
+	todo := suite.createTodo(model.TodoCreate{Title: "Shared"})
+	doomed := suite.createTodo(model.TodoCreate{Title: "Doomed"})
+	newID := primitive.NewObjectID().Hex()
+	stale, created := todo.Version, int64(1)
+
+	// Another device edits the todo first
+	w := test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), map[string]string{"title": "Edited elsewhere"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	request := model.SyncRequest{Mutations: []model.SyncMutation{
+		{Op: "create", ID: newID, Todo: &model.TodoCreate{Title: "Made offline"}},
+		{Op: "update", ID: newID, BaseVersion: &created, Patch: []byte(`{"completed":true}`)},
+		{Op: "update", ID: todo.ID.Hex(), BaseVersion: &stale, Patch: []byte(`{"title":"Edited offline"}`)},
+		{Op: "delete", ID: doomed.ID.Hex()},
+		{Op: "update", ID: newID, Patch: []byte(`{"title":"No base"}`)},
+	}}
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/sync", request, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response model.SyncResponse
+	test.ParseResponse(suite.T(), w, &response)
+	suite.Require().Len(response.Results, 5)
+	suite.Equal(3, response.Applied)
+	suite.Equal(1, response.Conflicts)
+	suite.Equal(1, response.Failed)
+
+	suite.Equal(http.StatusCreated, response.Results[0].Status)
+	suite.Equal(newID, response.Results[0].Todo.ID.Hex())
+	suite.Equal(http.StatusOK, response.Results[1].Status, response.Results[1].Error)
+	suite.True(response.Results[1].Todo.Completed)
+	suite.Equal(http.StatusConflict, response.Results[2].Status)
+	suite.Equal("Edited elsewhere", response.Results[2].Todo.Title)
+	suite.Equal(http.StatusNoContent, response.Results[3].Status)
+	suite.Equal(http.StatusBadRequest, response.Results[4].Status)
+
+	// Retrying the push creates nothing twice and deleting again succeeds
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/sync", model.SyncRequest{Mutations: request.Mutations[:1]}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &response)
+	suite.Equal(http.StatusOK, response.Results[0].Status)
+	suite.True(response.Results[0].Todo.Completed)
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/sync", model.SyncRequest{Mutations: request.Mutations[3:4]}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &response)
+	suite.Equal(http.StatusNoContent, response.Results[0].Status)
+	suite.ElementsMatch([]string{"Edited elsewhere", "Made offline"}, suite.listTitles("/todos"))
+
+	// Another user cannot take the ID
+	other := suite.registerAndLogin("other@example.com")
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/sync", model.SyncRequest{Mutations: request.Mutations[:1]}, other)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &response)
+	suite.Equal(http.StatusConflict, response.Results[0].Status)
+}
+
+func (suite *TodoControllerTestSuite) TestStats_AggregatesInUserTimeZone() {
+	suite.token = suite.registerAndLoginIn("tokyo@example.com", "Asia/Tokyo")
+	user, err := suite.userRepo.FindByEmail(context.Background(), "tokyo@example.com")
+	suite.Require().NoError(err)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	suite.Require().NoError(err)
+	today := time.Now().In(tokyo)
+	day := func(daysAgo, hour int) time.Time {
+		return time.Date(today.Year(), today.Month(), today.Day()-daysAgo, hour, 0, 0, 0, tokyo).UTC().Truncate(time.Millisecond)
+	}
+	at := func(t time.Time) *time.Time { return &t }
+
+	todos := []interface{}{
+		// Completed on each of the last three days, one of them late
+		model.Todo{Title: "A", UserID: user.ID, Completed: true, CreatedAt: day(5, 9), UpdatedAt: day(3, 9), CompletedAt: at(day(3, 9))},
+		model.Todo{Title: "B", UserID: user.ID, Completed: true, CreatedAt: day(3, 9), UpdatedAt: day(2, 1), CompletedAt: at(day(2, 1)), DueAt: at(day(3, 12))},
+		model.Todo{Title: "C", UserID: user.ID, Completed: true, CreatedAt: day(2, 9), UpdatedAt: day(1, 23)},
+		// Open, one overdue; and one trashed
+		model.Todo{Title: "D", UserID: user.ID, CreatedAt: day(2, 9), UpdatedAt: day(2, 9), DueAt: at(day(1, 9))},
+		model.Todo{Title: "E", UserID: user.ID, Completed: true, CreatedAt: day(2, 9), UpdatedAt: day(1, 9), CompletedAt: at(day(1, 9)), DeletedAt: at(day(1, 10))},
+	}
+	_, err = suite.mongoDB.Database.Collection("todos").InsertMany(context.Background(), todos)
+	suite.Require().NoError(err)
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/stats", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var summary model.TodoStats
+	test.ParseResponse(suite.T(), w, &summary)
+	suite.Equal("Asia/Tokyo", summary.TimeZone)
+	suite.Equal(today.Format("2006-01-02"), summary.To)
+	suite.Require().Len(summary.Days, 7)
+	suite.Equal(3, summary.Completed)
+	suite.Equal(3, summary.CurrentStreak)
+	suite.Equal(3, summary.LongestStreak)
+	suite.Equal(1, summary.CompletedLate)
+	suite.Equal(1, summary.Overdue)
+	suite.Equal(4, summary.Created)
+	suite.Equal(0.75, summary.CompletionRate)
+
+	// The pipeline agrees with the in-process equivalent
+	repo := repository.NewTodoRepository(suite.mongoDB.Database, "todos")
+	var all []*model.Todo
+	cursor, err := suite.mongoDB.Database.Collection("todos").Find(context.Background(), bson.M{"userId": user.ID})
+	suite.Require().NoError(err)
+	suite.Require().NoError(cursor.All(context.Background(), &all))
+	for _, daysAgo := range []int{0, 2, 4} {
+		window := stats.NewRange(day(daysAgo+3, 0), day(daysAgo, 0), tokyo, time.Now())
+		totals, err := repo.Stats(context.Background(), user.ID.Hex(), window)
+		suite.Require().NoError(err)
+		suite.Equal(stats.Aggregate(all, window), totals, daysAgo)
+	}
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/stats?from=2024-03-10&to=2024-03-01", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/stats?from=2023-01-01&to=2024-03-01", nil, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestQuickAdd_CreatesParsedTodo() {
+	finance := suite.createLabel("Finance")
+
+	// A preview only parses
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/quick?preview=true", model.QuickAdd{Text: "Pay rent tomorrow 9am #finance #home !high every month"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var preview model.QuickAddResult
+	test.ParseResponse(suite.T(), w, &preview)
+	suite.Nil(preview.Todo)
+	suite.Equal("Pay rent", preview.Parsed.Title)
+	suite.Equal([]string{"finance", "home"}, preview.Parsed.Labels)
+	suite.Empty(suite.listTitles("/todos"))
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/quick", model.QuickAdd{Text: "Pay rent tomorrow 9am #finance #home !high every month"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var result model.QuickAddResult
+	test.ParseResponse(suite.T(), w, &result)
+	suite.Require().NotNil(result.Todo)
+	suite.Equal("Pay rent", result.Todo.Title)
+	suite.Equal(model.PriorityHigh, result.Todo.Priority)
+	suite.Equal("FREQ=MONTHLY", result.Todo.Recurrence)
+	suite.Require().NotNil(result.Todo.DueAt)
+	suite.True(result.Todo.DueAt.Equal(*result.Parsed.DueAt))
+	suite.Equal(9, result.Todo.DueAt.In(time.UTC).Hour())
+
+	// The existing label is reused and the missing one created
+	suite.Require().Len(result.Todo.Labels, 2)
+	suite.Equal(finance.ID, result.Todo.Labels[0])
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/labels", nil, suite.token)
+	var labels []model.Label
+	test.ParseResponse(suite.T(), w, &labels)
+	suite.Len(labels, 2)
+
+	// Priority can be changed like any other field
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+result.Todo.ID.Hex(), map[string]string{"priority": "low"}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+result.Todo.ID.Hex(), map[string]string{"priority": "urgent"}, suite.token)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/quick", model.QuickAdd{Text: "tomorrow #finance"}, suite.token)
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) move(id primitive.ObjectID, reorder model.TodoReorder) model.Todo {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+id.Hex()+"/move", reorder, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var todo model.Todo
+	test.ParseResponse(suite.T(), w, &todo)
+	return todo
+}
+
+func (suite *TodoControllerTestSuite) TestReorder_MovesOnlyTheMovedTodo() {
+	a := suite.createTodo(model.TodoCreate{Title: "A"})
+	b := suite.createTodo(model.TodoCreate{Title: "B"})
+	c := suite.createTodo(model.TodoCreate{Title: "C"})
+	suite.Equal([]string{"A", "B", "C"}, suite.listTitles("/todos?sort=position"))
+
+	moved := suite.move(c.ID, model.TodoReorder{Before: &a.ID})
+	suite.Equal(c.Version+1, moved.Version)
+	suite.Equal([]string{"C", "A", "B"}, suite.listTitles("/todos?sort=position"))
+	suite.move(c.ID, model.TodoReorder{After: &a.ID, Before: &b.ID})
+	suite.Equal([]string{"A", "C", "B"}, suite.listTitles("/todos?sort=position"))
+	suite.move(a.ID, model.TodoReorder{After: &b.ID})
+	suite.Equal([]string{"C", "B", "A"}, suite.listTitles("/todos?sort=position"))
+	suite.Equal([]string{"A", "B", "C"}, suite.listTitles("/todos?sort=position&order=desc"))
+
+	// The todos around it are untouched
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+b.ID.Hex(), nil, suite.token)
+	var unchanged model.Todo
+	test.ParseResponse(suite.T(), w, &unchanged)
+	suite.Equal(b.Version, unchanged.Version)
+	suite.Equal(b.Position, unchanged.Position)
+
+	for _, reorder := range []model.TodoReorder{{}, {After: &a.ID}, {After: &c.ID, Before: &b.ID}} {
+		w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+a.ID.Hex()+"/move", reorder, suite.token)
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	missing := primitive.NewObjectID()
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+a.ID.Hex()+"/move", model.TodoReorder{After: &missing}, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TodoControllerTestSuite) TestReorder_RebalancesLongPositions() {
+	first := suite.createTodo(model.TodoCreate{Title: "First"})
+	last := suite.createTodo(model.TodoCreate{Title: "Last"})
+
+	// Moving into the same gap again and again makes positions grow
+	next := last
+	for i := 0; i < 30; i++ {
+		todo := suite.createTodo(model.TodoCreate{Title: fmt.Sprintf("Todo %d", i)})
+		next = suite.move(todo.ID, model.TodoReorder{After: &first.ID, Before: &next.ID})
+	}
+	suite.Greater(len(next.Position), 4)
+
+	rebalancer := worker.NewRankRebalancer(repository.NewTodoRepository(suite.mongoDB.Database, "todos"), 4, time.Hour)
+	suite.Require().NoError(rebalancer.RunOnce(context.Background(), time.Now()))
+
+	titles := suite.listTitles("/todos?sort=position&limit=100")
+	suite.Require().Len(titles, 32)
+	suite.Equal("First", titles[0])
+	suite.Equal("Todo 29", titles[1])
+	suite.Equal("Last", titles[31])
+
+	w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos?sort=position&limit=100", nil, suite.token)
+	var page model.TodoPage
+	test.ParseResponse(suite.T(), w, &page)
+	for _, todo := range page.Items {
+		suite.LessOrEqual(len(todo.Position), 2, todo.Title)
+	}
+}
+
+func (suite *TodoControllerTestSuite) TestGetAllTodos_SortsByPriorityAcrossPages() {
+	for _, priority := range []string{"", model.PriorityLow, model.PriorityHigh, "", model.PriorityMedium} {
+		suite.createTodo(model.TodoCreate{Title: "Todo " + priority, Priority: priority})
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		path := "/todos?limit=2&sort=priority"
+		if cursor != "" {
+			path += "&cursor=" + cursor
+		}
+		w := test.CreateTestRequest(suite.T(), suite.router, "GET", path, nil, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var page model.TodoPage
+		test.ParseResponse(suite.T(), w, &page)
+		for _, todo := range page.Items {
+			seen = append(seen, todo.Priority)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	suite.Equal([]string{"high", "medium", "low", "", ""}, seen)
+}
+
+func (suite *TodoControllerTestSuite) transition(id primitive.ObjectID, status string) *httptest.ResponseRecorder {
+	return test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+id.Hex()+"/transition", model.TodoTransition{Status: status}, suite.token)
+}
+
+func (suite *TodoControllerTestSuite) TestWorkflow_TransitionsDeriveCompleted() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: "Team"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var team model.List
+	test.ParseResponse(suite.T(), w, &team)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/workflows", model.WorkflowCreate{
+		Name:    "Team board",
+		ListIDs: []primitive.ObjectID{team.ID},
+		States: []model.WorkflowState{
+			{Key: "todo", Name: "To do"},
+			{Key: "in_progress", Name: "In progress"},
+			{Key: "review", Name: "In review"},
+			{Key: "done", Name: "Done", Done: true},
+		},
+		Transitions: []model.WorkflowTransition{
+			{From: "todo", To: "in_progress"},
+			{From: "in_progress", To: "review"},
+			{From: "review", To: "done"},
+			{From: "done", To: "todo"},
+		},
+	}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	todo := suite.createTodo(model.TodoCreate{Title: "Ship release", ListID: &team.ID})
+	suite.Equal("todo", todo.Status)
+
+	suite.Equal(http.StatusConflict, suite.transition(todo.ID, "done").Code)
+	suite.Equal(http.StatusUnprocessableEntity, suite.transition(todo.ID, "shipped").Code)
+
+	for _, status := range []string{"in_progress", "review", "done"} {
+		w = suite.transition(todo.ID, status)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		test.ParseResponse(suite.T(), w, &todo)
+		suite.Equal(status, todo.Status)
+		suite.Equal(status == "done", todo.Completed)
+	}
+
+	// Clients that only know completed reopen todos into the first open state
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+todo.ID.Hex(), "application/merge-patch+json", `{"completed":false}`, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &todo)
+	suite.Equal("todo", todo.Status)
+
+	// Completing or reverting a todo is a move like any other, which the
+	// workflow must allow
+	docs := suite.createTodo(model.TodoCreate{Title: "Write docs", ListID: &team.ID})
+	w = test.CreateRawTestRequest(suite.T(), suite.router, "PATCH", "/todos/"+docs.ID.Hex(), "application/merge-patch+json", `{"completed":true}`, suite.token)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+docs.ID.Hex(), model.TodoUpdate{Title: "Write docs", Completed: true}, suite.token)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	for _, status := range []string{"in_progress", "review"} {
+		suite.Require().Equal(http.StatusOK, suite.transition(docs.ID, status).Code)
+	}
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/todos/"+docs.ID.Hex()+"/revert/1", nil, suite.token)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	w = test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+docs.ID.Hex(), nil, suite.token)
+	test.ParseResponse(suite.T(), w, &docs)
+	suite.Equal("review", docs.Status)
+	suite.False(docs.Completed)
+
+	// Todos outside the list follow the built-in workflow
+	other := suite.createTodo(model.TodoCreate{Title: "Water plants"})
+	suite.Equal(model.WorkflowStateOpen, other.Status)
+	w = suite.transition(other.ID, model.WorkflowStateDone)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &other)
+	suite.True(other.Completed)
+}
+
+func (suite *TodoControllerTestSuite) TestWorkflow_ChangesSettleTodos() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: "Team"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var team model.List
+	test.ParseResponse(suite.T(), w, &team)
+
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/workflows", model.WorkflowCreate{
+		Name:    "Team board",
+		ListIDs: []primitive.ObjectID{team.ID},
+		States: []model.WorkflowState{
+			{Key: "todo", Name: "To do"},
+			{Key: "review", Name: "In review"},
+			{Key: "done", Name: "Done", Done: true},
+		},
+	}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var workflow model.Workflow
+	test.ParseResponse(suite.T(), w, &workflow)
+
+	reviewed := suite.createTodo(model.TodoCreate{Title: "Review PR", ListID: &team.ID})
+	suite.Require().Equal(http.StatusOK, suite.transition(reviewed.ID, "review").Code)
+	finished := suite.createTodo(model.TodoCreate{Title: "Tag release", ListID: &team.ID})
+	suite.Require().Equal(http.StatusOK, suite.transition(finished.ID, "done").Code)
+
+	// Dropping review and making done an open state settles both todos
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/workflows/"+workflow.ID.Hex(), model.WorkflowUpdate{
+		Name:    "Team board",
+		ListIDs: []primitive.ObjectID{team.ID},
+		States: []model.WorkflowState{
+			{Key: "todo", Name: "To do"},
+			{Key: "done", Name: "Done, to be shipped"},
+			{Key: "shipped", Name: "Shipped", Done: true},
+		},
+	}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	get := func(id primitive.ObjectID) model.Todo {
+		w := test.CreateTestRequest(suite.T(), suite.router, "GET", "/todos/"+id.Hex(), nil, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var todo model.Todo
+		test.ParseResponse(suite.T(), w, &todo)
+		return todo
+	}
+	todo := get(reviewed.ID)
+	suite.Equal("todo", todo.Status)
+	suite.False(todo.Completed)
+	todo = get(finished.ID)
+	suite.Equal("done", todo.Status)
+	suite.False(todo.Completed)
+	suite.Nil(todo.CompletedAt)
+	suite.ElementsMatch([]string{"Review PR", "Tag release"}, suite.listTitles("/lists/"+team.ID.Hex()+"/todos?completed=false"))
+
+	// A todo moved to a list with another workflow is settled too
+	suite.Require().Equal(http.StatusOK, suite.transition(finished.ID, "shipped").Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/lists", model.ListCreate{Name: "Home"}, suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var home model.List
+	test.ParseResponse(suite.T(), w, &home)
+	w = test.CreateTestRequest(suite.T(), suite.router, "PUT", "/todos/"+finished.ID.Hex()+"/list", model.TodoListMove{ListID: home.ID}, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	test.ParseResponse(suite.T(), w, &todo)
+	suite.Equal(model.WorkflowStateDone, todo.Status)
+	suite.True(todo.Completed)
+
+	// Deleting the workflow hands its todos to the built-in one
+	suite.Require().Equal(http.StatusOK, suite.transition(reviewed.ID, "done").Code)
+	w = test.CreateTestRequest(suite.T(), suite.router, "DELETE", "/workflows/"+workflow.ID.Hex(), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	todo = get(reviewed.ID)
+	suite.Equal(model.WorkflowStateDone, todo.Status)
+	suite.True(todo.Completed)
+	suite.NotNil(todo.CompletedAt)
+}
+
+func (suite *TodoControllerTestSuite) TestWorkflow_RejectsInvalidDefinitions() {
+	w := test.CreateTestRequest(suite.T(), suite.router, "POST", "/workflows", model.WorkflowCreate{
+		Name: "No end",
+		States: []model.WorkflowState{
+			{Key: "todo", Name: "To do"},
+			{Key: "doing", Name: "Doing"},
+		},
+	}, suite.token)
+	suite.Equal(http.StatusBadRequest, w.Code)
+
+	missing := primitive.NewObjectID()
+	w = test.CreateTestRequest(suite.T(), suite.router, "POST", "/workflows", model.WorkflowCreate{
+		Name:    "Elsewhere",
+		ListIDs: []primitive.ObjectID{missing},
+		States:  model.DefaultWorkflow().States,
+	}, suite.token)
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestTodoControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(TodoControllerTestSuite))
+}
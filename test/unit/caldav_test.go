@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"todo-app/internal/caldav"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalDAV_ParsesReports(t *testing.T) {
+	query := `<?xml version="1.0"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VTODO">
+        <c:prop-filter name="COMPLETED"><c:is-not-defined/></c:prop-filter>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+	report, err := caldav.ParseReport(strings.NewReader(query))
+	require.NoError(t, err)
+	assert.Equal(t, caldav.CalendarQuery, report.Name)
+	assert.Equal(t, "VTODO", report.Component)
+	assert.True(t, report.Open)
+	assert.True(t, report.Props.Has(caldav.CalDAV("calendar-data")))
+	assert.False(t, report.Props.AllProp)
+
+	multiget := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:href>/dav/calendars/1/a.ics</d:href><d:href>/dav/calendars/1/b.ics</d:href>
+</c:calendar-multiget>`
+	report, err = caldav.ParseReport(strings.NewReader(multiget))
+	require.NoError(t, err)
+	assert.Equal(t, caldav.CalendarMultiget, report.Name)
+	assert.Equal(t, []string{"/dav/calendars/1/a.ics", "/dav/calendars/1/b.ics"}, report.Hrefs)
+	assert.True(t, report.Props.AllProp, "a report without prop asks for everything")
+
+	_, err = caldav.ParseReport(strings.NewReader("<not xml"))
+	assert.Error(t, err)
+}
+
+func TestCalDAV_ParsesPropfind(t *testing.T) {
+	request, err := caldav.ParsePropfind(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.True(t, request.AllProp)
+
+	request, err = caldav.ParsePropfind(strings.NewReader(`<propfind xmlns="DAV:"><prop><displayname/><x:color xmlns:x="urn:example"/></prop></propfind>`))
+	require.NoError(t, err)
+	found, missing := request.Select([]caldav.Property{
+		{Name: caldav.DAV("displayname"), Value: "Inbox"},
+		{Name: caldav.DAV("resourcetype"), Value: "<d:collection/>"},
+	})
+	assert.Equal(t, []caldav.Property{{Name: caldav.DAV("displayname"), Value: "Inbox"}}, found)
+	require.Len(t, missing, 1)
+	assert.Equal(t, "color", missing[0].Local)
+
+	_, err = caldav.ParsePropfind(strings.NewReader(`<propfind xmlns="DAV:"/>`))
+	assert.Error(t, err)
+}
+
+func TestCalDAV_WritesMultistatus(t *testing.T) {
+	var b strings.Builder
+	err := caldav.WriteMultistatus(&b, []*caldav.Response{
+		{
+			Href:    "/dav/calendars/1/",
+			Found:   []caldav.Property{{Name: caldav.DAV("displayname"), Value: caldav.Escape("Home & garden")}},
+			Missing: []xml.Name{{Space: "urn:example", Local: "color"}},
+		},
+		{Href: "/dav/calendars/1/gone.ics", Status: http.StatusNotFound},
+	})
+	require.NoError(t, err)
+
+	out := b.String()
+	assert.Contains(t, out, "<d:href>/dav/calendars/1/</d:href>")
+	assert.Contains(t, out, "<d:displayname>Home &amp; garden</d:displayname>")
+	assert.Contains(t, out, `<x:color xmlns:x="urn:example"/>`)
+	assert.Contains(t, out, "<d:status>HTTP/1.1 200 OK</d:status>")
+	assert.Contains(t, out, "<d:href>/dav/calendars/1/gone.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status>")
+}
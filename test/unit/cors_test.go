@@ -0,0 +1,32 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-app/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS_AllowsConditionalHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CORS())
+	router.GET("/todos/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	preflight := httptest.NewRequest("OPTIONS", "/todos/1", nil)
+	preflight.Header.Set("Access-Control-Request-Method", "PUT")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, preflight)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "If-Match")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "If-None-Match")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Idempotency-Key")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/todos/1", nil))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "ETag")
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Last-Modified")
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"todo-app/internal/errors"
+	"todo-app/internal/events"
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func todoEvent(userID primitive.ObjectID, title string) *model.TodoEvent {
+	return &model.TodoEvent{
+		Type:       model.EventTodoUpdated,
+		UserID:     userID,
+		Todo:       &model.Todo{UserID: userID, Title: title},
+		OccurredAt: time.Now(),
+	}
+}
+
+func titles(messages []*events.Message) []string {
+	result := make([]string, len(messages))
+	for i, message := range messages {
+		result[i] = message.Event.Todo.Title
+	}
+	return result
+}
+
+func TestBus_DeliversOnlyTheUsersEvents(t *testing.T) {
+	bus := events.NewBus(10, 10, 5)
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+
+	sub, replay, resumed, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+	assert.Empty(t, replay)
+	assert.False(t, resumed)
+
+	bus.Broadcast(todoEvent(bob, "Bob's"), todoEvent(alice, "Alice's"))
+	message := <-sub.C
+	assert.Equal(t, "Alice's", message.Event.Todo.Title)
+	assert.Empty(t, sub.C)
+}
+
+func TestBus_ResumesAfterTheLastEvent(t *testing.T) {
+	bus := events.NewBus(10, 10, 5)
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+
+	sub, _, _, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+	bus.Broadcast(todoEvent(alice, "First"))
+	last := (<-sub.C).ID
+	bus.Unsubscribe(sub)
+
+	bus.Broadcast(todoEvent(alice, "Second"), todoEvent(bob, "Bob's"), todoEvent(alice, "Third"))
+
+	sub, replay, resumed, err := bus.Subscribe(alice.Hex(), last)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, []string{"Second", "Third"}, titles(replay))
+
+	// A client that saw nothing resumes from the cursor it was given
+	cursor := sub.Cursor
+	bus.Broadcast(todoEvent(alice, "Fourth"))
+	_, replay, resumed, err = bus.Subscribe(alice.Hex(), cursor)
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	assert.Equal(t, []string{"Fourth"}, titles(replay))
+}
+
+func TestBus_DoesNotResumeFromForgottenEvents(t *testing.T) {
+	bus := events.NewBus(2, 10, 5)
+	alice := primitive.NewObjectID()
+
+	sub, _, _, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+	bus.Broadcast(todoEvent(alice, "First"))
+	last := (<-sub.C).ID
+	bus.Unsubscribe(sub)
+
+	bus.Broadcast(todoEvent(alice, "Second"), todoEvent(alice, "Third"), todoEvent(alice, "Fourth"))
+
+	for _, id := range []string{last, "unknown", "0-1", sub.Cursor + "9"} {
+		_, replay, resumed, err := bus.Subscribe(alice.Hex(), id)
+		require.NoError(t, err)
+		assert.False(t, resumed, id)
+		assert.Empty(t, replay, id)
+	}
+}
+
+func TestBus_DropsSubscribersThatFallBehind(t *testing.T) {
+	bus := events.NewBus(10, 2, 5)
+	alice := primitive.NewObjectID()
+
+	slow, _, _, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+	fast, _, _, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+
+	bus.Broadcast(todoEvent(alice, "First"), todoEvent(alice, "Second"))
+	assert.Equal(t, "First", (<-fast.C).Event.Todo.Title)
+	bus.Broadcast(todoEvent(alice, "Third"))
+
+	var received []string
+	for message := range slow.C {
+		received = append(received, message.Event.Todo.Title)
+	}
+	assert.Equal(t, []string{"First", "Second"}, received)
+	assert.Equal(t, "Second", (<-fast.C).Event.Todo.Title)
+	assert.Equal(t, "Third", (<-fast.C).Event.Todo.Title)
+
+	// Unsubscribing a dropped stream is harmless
+	bus.Unsubscribe(slow)
+	bus.Unsubscribe(fast)
+}
+
+func TestBus_LimitsStreamsPerUser(t *testing.T) {
+	bus := events.NewBus(10, 10, 1)
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+
+	sub, _, _, err := bus.Subscribe(alice.Hex(), "")
+	require.NoError(t, err)
+	_, _, _, err = bus.Subscribe(alice.Hex(), "")
+	assert.ErrorIs(t, err, errors.ErrTooManyStreams)
+	_, _, _, err = bus.Subscribe(bob.Hex(), "")
+	assert.NoError(t, err)
+
+	bus.Unsubscribe(sub)
+	_, _, _, err = bus.Subscribe(alice.Hex(), "")
+	assert.NoError(t, err)
+}
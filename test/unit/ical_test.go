@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/internal/ical"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICal_FoldsAndEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	w.Text("SUMMARY", "Milk, eggs; and\nbread \\ "+strings.Repeat("é", 60))
+	w.Text("CATEGORIES", "Errands", "Home, garden")
+	require.NoError(t, w.Err())
+
+	out := buf.String()
+	assert.True(t, strings.HasSuffix(out, "\r\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "line splits a character: %q", line)
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, `SUMMARY:Milk\, eggs\; and\nbread \\ éé`)
+	assert.Contains(t, unfolded, `CATEGORIES:Errands,Home\, garden`+"\r\n")
+}
+
+func TestICal_WritesTodosAndEvents(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	created := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	midnight := time.Date(2024, 7, 1, 0, 0, 0, 0, london)
+	alarm := midnight.Add(-time.Hour)
+
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	w.BeginCalendar("Todos")
+	item := &ical.Item{
+		ID: "abc", Title: "Pay rent", Created: created, Modified: created,
+		Due: &midnight, AllDay: true, Location: london, Alarms: []time.Time{alarm}, ParentID: "def",
+	}
+	w.Todo(item)
+	w.Event(item)
+	w.Event(&ical.Item{ID: "nodue", Title: "Someday", Created: created, Modified: created})
+	w.EndCalendar()
+	require.NoError(t, w.Err())
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "UID:abc@todo-app\r\n")
+	assert.Contains(t, out, "DUE;VALUE=DATE:20240701\r\n")
+	assert.Contains(t, out, "STATUS:NEEDS-ACTION\r\n")
+	assert.Contains(t, out, "RELATED-TO:def@todo-app\r\n")
+	assert.Contains(t, out, "UID:abc-due@todo-app\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20240701\r\nDTEND;VALUE=DATE:20240702\r\n")
+	assert.Contains(t, out, "TRIGGER;VALUE=DATE-TIME:20240630T220000Z\r\n")
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:VEVENT"), "todos without a due date have no event")
+
+	// Completed todos have no alarms
+	buf.Reset()
+	w = ical.NewWriter(&buf)
+	due := time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC)
+	w.Todo(&ical.Item{ID: "done", Title: "Done", Completed: true, CompletedAt: &due, Created: created, Modified: created, Due: &due, Alarms: []time.Time{alarm}})
+	out = buf.String()
+	assert.Contains(t, out, "DUE:20240701T093000Z\r\n")
+	assert.Contains(t, out, "STATUS:COMPLETED\r\n")
+	assert.Contains(t, out, "COMPLETED:20240701T093000Z\r\n")
+	assert.NotContains(t, out, "VALARM")
+}
+
+func TestICal_ReadsTodos(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	object := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:abc\r\nSUMMARY:Buy milk\\, eggs\r\n" +
+		"DESCRIPTION:a long\r\n  note\r\nDUE;TZID=\"America/New_York\":20300105T090000\r\n" +
+		"CATEGORIES:Errands,Home\\, garden\r\nCOMPLETED:20300101T120000Z\r\nSTATUS:NEEDS-ACTION\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nEND:VALARM\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	item, err := ical.ReadTodo(strings.NewReader(object), paris)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", item.UID)
+	assert.Equal(t, "Buy milk, eggs", item.Title)
+	assert.Equal(t, "a long note", item.Description)
+	assert.Equal(t, []string{"Errands", "Home, garden"}, item.Categories)
+	assert.False(t, item.Completed, "STATUS wins over COMPLETED")
+	require.NotNil(t, item.Due)
+	assert.Equal(t, time.Date(2030, 1, 5, 14, 0, 0, 0, time.UTC), item.Due.UTC())
+	assert.False(t, item.AllDay)
+
+	item, err = ical.ReadTodo(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nDUE;VALUE=DATE:20300105\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"), paris)
+	require.NoError(t, err)
+	assert.True(t, item.AllDay)
+	assert.Equal(t, time.Date(2030, 1, 5, 0, 0, 0, 0, paris), item.Due.In(paris))
+
+	for _, invalid := range []string{
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nDUE:tomorrow\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+	} {
+		_, err := ical.ReadTodo(strings.NewReader(invalid), paris)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore keeps idempotency records in a map
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*model.IdempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := record.UserID + "/" + record.Key
+	if existing, ok := s.records[id]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		copied := *existing
+		return &copied, nil
+	}
+	s.records[id] = record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, record *model.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, record.UserID+"/"+record.Key)
+	return nil
+}
+
+func idempotentRouter(store middleware.IdempotencyStore, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("userId", ctx.GetHeader("X-User"))
+	}, middleware.Idempotency(store, time.Hour))
+	router.POST("/things", func(ctx *gin.Context) {
+		*calls++
+		if ctx.Query("fail") != "" {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		if ctx.Query("secret") != "" {
+			ctx.Header("Cache-Control", "private, no-store")
+		}
+		ctx.Header("Location", "/things/1")
+		ctx.JSON(http.StatusCreated, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func postThing(router http.Handler, path, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	router := idempotentRouter(&memoryIdempotencyStore{records: map[string]*model.IdempotencyRecord{}}, &calls)
+
+	first := postThing(router, "/things", "alice", "k1", `{"a":1}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	retry := postThing(router, "/things", "alice", "k1", `{"a":1}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/things/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	// A different body under the same key is refused
+	reused := postThing(router, "/things", "alice", "k1", `{"a":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+
+	// Keys are per user, and requests without one always run
+	assert.Equal(t, http.StatusCreated, postThing(router, "/things", "bob", "k1", `{"a":1}`).Code)
+	assert.Equal(t, http.StatusCreated, postThing(router, "/things", "alice", "", `{"a":1}`).Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotency_ServerErrorsFreeTheKey(t *testing.T) {
+	calls := 0
+	router := idempotentRouter(&memoryIdempotencyStore{records: map[string]*model.IdempotencyRecord{}}, &calls)
+
+	assert.Equal(t, http.StatusInternalServerError, postThing(router, "/things?fail=1", "alice", "k2", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, postThing(router, "/things?fail=1", "alice", "k2", "").Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RefusesOversizedBodies(t *testing.T) {
+	calls := 0
+	store := &memoryIdempotencyStore{records: map[string]*model.IdempotencyRecord{}}
+	router := idempotentRouter(store, &calls)
+
+	w := postThing(router, "/things", "alice", "k5", strings.Repeat("x", model.MaxImportBytes+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, 0, calls)
+	assert.Empty(t, store.records)
+
+	// Without a key the body is left to the handler
+	assert.Equal(t, http.StatusCreated, postThing(router, "/things", "alice", "", strings.Repeat("x", model.MaxImportBytes+1)).Code)
+}
+
+func TestIdempotency_NoStoreResponsesAreNotKept(t *testing.T) {
+	calls := 0
+	store := &memoryIdempotencyStore{records: map[string]*model.IdempotencyRecord{}}
+	router := idempotentRouter(store, &calls)
+
+	first := postThing(router, "/things?secret=1", "alice", "k3", "")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, store.records)
+
+	retry := postThing(router, "/things?secret=1", "alice", "k3", "")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_InFlightRequestConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("userId", "alice")
+	}, middleware.Idempotency(&memoryIdempotencyStore{records: map[string]*model.IdempotencyRecord{}}, time.Hour))
+	router.POST("/things", func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusNoContent)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postThing(router, "/things", "alice", "k3", "") }()
+	<-started
+
+	w := postThing(router, "/things", "alice", "k3", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(release)
+	assert.Equal(t, http.StatusNoContent, (<-done).Code)
+	assert.Equal(t, http.StatusNoContent, postThing(router, "/things", "alice", "k3", "").Code)
+}
@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"todo-app/pkg/jsonpatch"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch_RFC7386Examples(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	}
+	for _, tc := range cases {
+		got, err := jsonpatch.MergePatch([]byte(tc.doc), []byte(tc.patch))
+		require.NoError(t, err, tc.patch)
+		assert.JSONEq(t, tc.want, string(got), tc.patch)
+	}
+}
+
+func TestApply_Operations(t *testing.T) {
+	doc := `{"title":"a","done":false,"tags":["x","y"],"meta":{"n":1}}`
+	patch := `[
+		{"op":"test","path":"/meta/n","value":1.0},
+		{"op":"replace","path":"/done","value":true},
+		{"op":"add","path":"/tags/1","value":"z"},
+		{"op":"add","path":"/tags/-","value":"end"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/title","path":"/copy"},
+		{"op":"move","from":"/meta/n","path":"/n"},
+		{"op":"add","path":"/due","value":null}
+	]`
+
+	got, err := jsonpatch.Apply([]byte(doc), []byte(patch))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"a","done":true,"tags":["z","y","end"],"meta":{},"copy":"a","n":1,"due":null}`, string(got))
+}
+
+func TestApply_EscapedPointer(t *testing.T) {
+	got, err := jsonpatch.Apply([]byte(`{"a/b":1,"m~n":2}`), []byte(`[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"m~n":3}`, string(got))
+}
+
+func TestApply_Failures(t *testing.T) {
+	doc := []byte(`{"title":"a","tags":[]}`)
+
+	_, err := jsonpatch.Apply(doc, []byte(`[{"op":"test","path":"/title","value":"b"}]`))
+	assert.True(t, errors.Is(err, jsonpatch.ErrTestFailed))
+
+	for _, patch := range []string{
+		`{"op":"add"}`,
+		`[{"op":"replace","path":"/missing","value":1}]`,
+		`[{"op":"remove","path":"/tags/0"}]`,
+		`[{"op":"add","path":"/tags/01","value":1}]`,
+		`[{"op":"add","path":"title","value":1}]`,
+		`[{"op":"add","path":"/title"}]`,
+		`[{"op":"move","from":"/tags","path":"/tags/0"}]`,
+		`[{"op":"frobnicate","path":"/title"}]`,
+	} {
+		_, err := jsonpatch.Apply(doc, []byte(patch))
+		assert.True(t, errors.Is(err, jsonpatch.ErrInvalidPatch), patch)
+	}
+}
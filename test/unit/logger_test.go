@@ -0,0 +1,33 @@
+package unit
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"todo-app/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_LogsRouteNotPath(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Logger())
+	router.GET("/calendar/feed/:token", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/calendar/feed/s3cr3t-token.ics", nil))
+	assert.Contains(t, logged.String(), "Path: /calendar/feed/:token")
+	assert.NotContains(t, logged.String(), "s3cr3t-token")
+
+	logged.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+	assert.Contains(t, logged.String(), "Status: 404 | Path: /nowhere")
+}
@@ -0,0 +1,51 @@
+package unit
+
+import (
+	"testing"
+	"todo-app/internal/markdown"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHTML_BlocksAndInlines(t *testing.T) {
+	src := "# Plan *this*\n\nBuy **milk** and `eggs`\nthen [shop](https://example.com/a?b=1&c=2).\n\n- [x] done\n- [ ] open\n  - nested\n\n> quoted\n\n```go\nfmt.Println(\"<hi>\")\n```\n"
+
+	want := "<h1>Plan <em>this</em></h1>\n" +
+		"<p>Buy <strong>milk</strong> and <code>eggs</code>\n" +
+		"then <a href=\"https://example.com/a?b=1&amp;c=2\" rel=\"nofollow noopener noreferrer\">shop</a>.</p>\n" +
+		"<ul>\n<li><input type=\"checkbox\" checked disabled> done\n</li>\n" +
+		"<li><input type=\"checkbox\" disabled> open\n<ul>\n<li>nested\n</li>\n</ul>\n</li>\n</ul>\n" +
+		"<blockquote>\n<p>quoted</p>\n</blockquote>\n" +
+		"<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n"
+	assert.Equal(t, want, markdown.ToHTML(src))
+}
+
+func TestToHTML_OrderedAndLooseLists(t *testing.T) {
+	assert.Equal(t, "<ol start=\"3\">\n<li>three\n</li>\n<li>four\n</li>\n</ol>\n", markdown.ToHTML("3. three\n4. four"))
+	assert.Equal(t, "<ul>\n<li><p>one</p>\n</li>\n<li><p>two</p>\n</li>\n</ul>\n", markdown.ToHTML("- one\n\n- two"))
+}
+
+func TestToHTML_EscapesRawHTML(t *testing.T) {
+	got := markdown.ToHTML("<script>alert(1)</script> <img src=x onerror=alert(1)>")
+	assert.Equal(t, "<p>&lt;script&gt;alert(1)&lt;/script&gt; &lt;img src=x onerror=alert(1)&gt;</p>\n", got)
+}
+
+func TestToHTML_DropsUnsafeLinks(t *testing.T) {
+	for _, src := range []string{
+		"[click](javascript:alert(1))",
+		"[click](JaVaScRiPt:alert(1))",
+		"[click](data:text/html;base64,PHNjcmlwdD4=)",
+		"[click](java&#115;cript:alert(1))",
+		"[click](/relative)",
+		"![click](vbscript:msgbox)",
+	} {
+		assert.Equal(t, "<p>click</p>\n", markdown.ToHTML(src), src)
+	}
+
+	assert.Equal(t, "<p><a href=\"mailto:me@example.com\" rel=\"nofollow noopener noreferrer\">mailto:me@example.com</a></p>\n",
+		markdown.ToHTML("<mailto:me@example.com>"))
+}
+
+func TestToHTML_LeavesIntrawordUnderscores(t *testing.T) {
+	assert.Equal(t, "<p>snake_case_name and <em>em</em></p>\n", markdown.ToHTML("snake_case_name and _em_"))
+}
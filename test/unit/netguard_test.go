@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+	"todo-app/internal/netguard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetguard_RefusesInternalAddresses(t *testing.T) {
+	guard, err := netguard.New(nil)
+	require.NoError(t, err)
+
+	for _, addr := range []string{"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1", "fd00::1", "0.0.0.0", "::", "::ffff:10.0.0.1"} {
+		assert.False(t, guard.Allows(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, guard.Allows(netip.MustParseAddr(addr)), addr)
+	}
+
+	assert.False(t, guard.AllowsURL(context.Background(), "http://169.254.169.254/latest/meta-data"))
+	assert.False(t, guard.AllowsURL(context.Background(), "https://[::1]:8443/hook"))
+	assert.True(t, guard.AllowsURL(context.Background(), "https://93.184.216.34/hook"))
+}
+
+func TestNetguard_AllowsConfiguredNetworks(t *testing.T) {
+	guard, err := netguard.New([]string{"10.1.0.0/16", " 127.0.0.1 ", ""})
+	require.NoError(t, err)
+	assert.True(t, guard.Allows(netip.MustParseAddr("10.1.200.3")))
+	assert.True(t, guard.Allows(netip.MustParseAddr("127.0.0.1")))
+	assert.False(t, guard.Allows(netip.MustParseAddr("10.2.0.1")))
+	assert.False(t, guard.Allows(netip.MustParseAddr("127.0.0.2")))
+
+	_, err = netguard.New([]string{"not a network"})
+	assert.Error(t, err)
+}
+
+func TestNetguard_ClientChecksEveryConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	guard, err := netguard.New(nil)
+	require.NoError(t, err)
+	_, err = guard.Client(time.Second).Get(server.URL)
+	assert.ErrorContains(t, err, "not allowed")
+
+	guard, err = netguard.New([]string{"127.0.0.1"})
+	require.NoError(t, err)
+	response, err := guard.Client(time.Second).Get(server.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
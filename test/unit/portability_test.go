@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/portability"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func portableRecords() []*model.TodoRecord {
+	due := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+	return []*model.TodoRecord{
+		{
+			ID:         "5f8d0614db5c5c7b3a18f201",
+			Title:      "Buy groceries",
+			Completed:  true,
+			DueAt:      &due,
+			Recurrence: "FREQ=WEEKLY;BYDAY=MO",
+			List:       "Home Stuff",
+			Labels:     []string{"Errands", "Quick win"},
+			CreatedAt:  &created,
+			UpdatedAt:  &updated,
+		},
+		{Title: "Call mum"},
+	}
+}
+
+func TestPortability_RoundTrips(t *testing.T) {
+	for _, format := range []string{portability.CSV, portability.JSON, portability.TodoTxt} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := portability.NewWriter(format, &buf)
+			require.NoError(t, err)
+			for _, record := range portableRecords() {
+				require.NoError(t, writer.Write(record))
+			}
+			require.NoError(t, writer.Close())
+
+			entries, err := portability.Read(format, &buf)
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			for i, want := range portableRecords() {
+				require.NoError(t, entries[i].Err)
+				got := entries[i].Record
+				assert.Equal(t, want.ID, got.ID)
+				assert.Equal(t, want.Title, got.Title)
+				assert.Equal(t, want.Completed, got.Completed)
+				assert.Equal(t, want.DueAt, got.DueAt)
+				assert.Equal(t, want.Recurrence, got.Recurrence)
+				assert.Equal(t, want.List, got.List)
+				assert.Equal(t, want.Labels, got.Labels)
+			}
+		})
+	}
+}
+
+func TestPortability_ReportsLines(t *testing.T) {
+	csv := "\ufeffTitle,Completed,dueAt\n" +
+		"Buy milk,false,\n" +
+		"\"Two\nlines\",maybe,\n" +
+		",,\n" +
+		"Pay rent,true,2024-13-01\n"
+	entries, err := portability.Read(portability.CSV, strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, 2, entries[0].Line)
+	assert.NoError(t, entries[0].Err)
+	assert.Equal(t, 3, entries[1].Line)
+	assert.ErrorContains(t, entries[1].Err, "completed")
+	assert.Equal(t, 5, entries[2].Line)
+	assert.ErrorContains(t, entries[2].Err, "title")
+	assert.Equal(t, 6, entries[3].Line)
+	assert.ErrorContains(t, entries[3].Err, "invalid time")
+
+	json := "[\n  {\"title\": \"Buy milk\"},\n  {\"title\": 3},\n\n  {\"title\": \"\"}\n]"
+	entries, err = portability.Read(portability.JSON, strings.NewReader(json))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []int{2, 3, 5}, []int{entries[0].Line, entries[1].Line, entries[2].Line})
+	assert.NoError(t, entries[0].Err)
+	assert.Error(t, entries[1].Err)
+	assert.Error(t, entries[2].Err)
+
+	todoTxt := "(A) 2024-01-02 Call mum @phone\n\nx 2024-01-05 2024-01-01 File taxes due:2024-04-15\nx\n"
+	entries, err = portability.Read(portability.TodoTxt, strings.NewReader(todoTxt))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, 1, entries[0].Line)
+	assert.Equal(t, "Call mum", entries[0].Record.Title)
+	assert.Equal(t, []string{"phone"}, entries[0].Record.Labels)
+	assert.Equal(t, 3, entries[1].Line)
+	assert.True(t, entries[1].Record.Completed)
+	assert.Equal(t, "2024-01-01", entries[1].Record.CreatedAt.Format(time.DateOnly))
+	assert.Equal(t, 4, entries[2].Line)
+	assert.Error(t, entries[2].Err)
+
+	_, err = portability.Read(portability.CSV, strings.NewReader("name,done\nx,y\n"))
+	assert.Error(t, err)
+	_, err = portability.Read(portability.JSON, strings.NewReader(`{"title":"x"}`))
+	assert.Error(t, err)
+}
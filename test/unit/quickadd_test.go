@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/quickadd"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quickAddClock is Wednesday 2024-03-13, 10:00 in London
+func quickAddClock(t *testing.T) (time.Time, *time.Location) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	return time.Date(2024, 3, 13, 10, 0, 0, 0, london), london
+}
+
+func TestQuickAdd_ReadsEveryField(t *testing.T) {
+	now, london := quickAddClock(t)
+
+	parsed := quickadd.Parse("Pay rent tomorrow 9am #finance !high every month", now, london)
+	assert.Equal(t, "Pay rent", parsed.Title)
+	require.NotNil(t, parsed.DueAt)
+	assert.Equal(t, time.Date(2024, 3, 14, 9, 0, 0, 0, london), *parsed.DueAt)
+	assert.Equal(t, []string{"finance"}, parsed.Labels)
+	assert.Equal(t, model.PriorityHigh, parsed.Priority)
+	assert.Equal(t, "FREQ=MONTHLY", parsed.Recurrence)
+	assert.Equal(t, "Europe/London", parsed.TimeZone)
+	assert.Equal(t, []model.QuickAddMatch{
+		{Kind: model.QuickAddDue, Text: "tomorrow"},
+		{Kind: model.QuickAddDue, Text: "9am"},
+		{Kind: model.QuickAddLabel, Text: "#finance"},
+		{Kind: model.QuickAddPriority, Text: "!high"},
+		{Kind: model.QuickAddRecurrence, Text: "every month"},
+	}, parsed.Matches)
+}
+
+func TestQuickAdd_DueDates(t *testing.T) {
+	now, london := quickAddClock(t)
+	day := func(month time.Month, d, hour, minute int) time.Time {
+		return time.Date(2024, month, d, hour, minute, 0, 0, london)
+	}
+
+	for text, due := range map[string]time.Time{
+		"Call mum today":                         day(3, 13, 0, 0),
+		"Call mum friday":                        day(3, 15, 0, 0),
+		"Call mum on Wednesday":                  day(3, 20, 0, 0),
+		"Call mum next week":                     day(3, 18, 0, 0),
+		"Call mum next month":                    day(4, 1, 0, 0),
+		"Call mum in 3 days":                     day(3, 16, 0, 0),
+		"Call mum in a week":                     day(3, 20, 0, 0),
+		"Call mum 2024-04-02":                    day(4, 2, 0, 0),
+		"Call mum march 20th at 5:30pm":          day(3, 20, 17, 30),
+		"Call mum 1 apr 2024 at noon":            day(4, 1, 12, 0),
+		"Call mum at 11am":                       day(3, 13, 11, 0),
+		"Call mum 9 am":                          day(3, 14, 9, 0),
+		"Call mum 21:15":                         day(3, 13, 21, 15),
+		"Call mum every weekday 8am":             day(3, 14, 8, 0),
+		"Call mum every sunday":                  day(3, 17, 0, 0),
+		"Call mum by tomorrow, 6pm":              day(3, 14, 18, 0),
+		"Call mum every other week":              day(3, 13, 0, 0),
+		"Call mum march 1":                       time.Date(2025, 3, 1, 0, 0, 0, 0, london),
+		"Call mum daily at midnight":             day(3, 14, 0, 0),
+		"Call mum every monday and thursday 3pm": day(3, 14, 15, 0),
+	} {
+		parsed := quickadd.Parse(text, now, london)
+		if assert.NotNil(t, parsed.DueAt, text) {
+			assert.Equal(t, due, *parsed.DueAt, text)
+		}
+		assert.Equal(t, "Call mum", parsed.Title, text)
+	}
+}
+
+func TestQuickAdd_Recurrences(t *testing.T) {
+	now, london := quickAddClock(t)
+
+	for text, rule := range map[string]string{
+		"Water plants daily":                    "FREQ=DAILY",
+		"Water plants every 3 days":             "FREQ=DAILY;INTERVAL=3",
+		"Water plants every other week":         "FREQ=WEEKLY;INTERVAL=2",
+		"Water plants every weekday":            "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		"Water plants every monday, thursday":   "FREQ=WEEKLY;BYDAY=MO,TH",
+		"Water plants every tuesday and friday": "FREQ=WEEKLY;BYDAY=TU,FR",
+		"Water plants annually":                 "FREQ=YEARLY",
+	} {
+		parsed := quickadd.Parse(text, now, london)
+		assert.Equal(t, rule, parsed.Recurrence, text)
+		assert.Equal(t, "Water plants", parsed.Title, text)
+		assert.NotNil(t, parsed.DueAt, "a recurring todo needs a due date")
+	}
+}
+
+func TestQuickAdd_LeavesOrdinaryWordsInTitle(t *testing.T) {
+	now, london := quickAddClock(t)
+
+	for _, text := range []string{
+		"Read 1984",
+		"Fix issue #12",
+		"Ask what every student needs",
+		"Plan the march",
+		"Wait at the station",
+		"Book in advance",
+	} {
+		parsed := quickadd.Parse(text, now, london)
+		assert.Equal(t, text, parsed.Title)
+		assert.Nil(t, parsed.DueAt, text)
+		assert.Empty(t, parsed.Matches, text)
+	}
+}
+
+func TestQuickAdd_QuotesKeepWordsInTitle(t *testing.T) {
+	now, london := quickAddClock(t)
+
+	parsed := quickadd.Parse(`Watch "Friday Night Lights" friday #tv_shows #TV_Shows !low !high`, now, london)
+	assert.Equal(t, "Watch Friday Night Lights !high", parsed.Title)
+	require.NotNil(t, parsed.DueAt)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, london), *parsed.DueAt)
+	assert.Equal(t, []string{"tv shows"}, parsed.Labels)
+	assert.Equal(t, model.PriorityLow, parsed.Priority)
+}
+
+func TestQuickAdd_DependsOnlyOnClockAndTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	// Late on the 13th in London is already the 14th in Tokyo
+	now := time.Date(2024, 3, 13, 23, 0, 0, 0, time.UTC)
+
+	first := quickadd.Parse("Standup tomorrow 9am", now, tokyo)
+	second := quickadd.Parse("Standup tomorrow 9am", now, tokyo)
+	assert.Equal(t, first, second)
+	require.NotNil(t, first.DueAt)
+	assert.Equal(t, time.Date(2024, 3, 15, 9, 0, 0, 0, tokyo), *first.DueAt)
+	assert.Equal(t, "Asia/Tokyo", first.TimeZone)
+}
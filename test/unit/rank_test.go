@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"todo-app/internal/rank"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank_Between(t *testing.T) {
+	for _, c := range []struct{ before, after, want string }{
+		{"", "", "V"},
+		{"V", "", "l"},
+		{"", "V", "G"},
+		{"A", "B", "AV"},
+		{"A1", "B", "AW"},
+		{"A5", "B3", "B"},
+		{"", "1", "0V"},
+		{"", "01", "00V"},
+		{"A", "A01", "A00V"},
+		{"z", "", "zV"},
+	} {
+		got, err := rank.Between(c.before, c.after)
+		require.NoError(t, err)
+		assert.Equal(t, c.want, got, "between %q and %q", c.before, c.after)
+		assert.True(t, rank.Valid(got))
+	}
+}
+
+func TestRank_BetweenRejectsBadBounds(t *testing.T) {
+	for _, c := range []struct{ before, after string }{
+		{"B", "A"},
+		{"A", "A"},
+		{"A0", ""},
+		{"", "a-b"},
+	} {
+		_, err := rank.Between(c.before, c.after)
+		assert.Error(t, err, "between %q and %q", c.before, c.after)
+	}
+}
+
+func TestRank_RepeatedMovesKeepOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	ranks := []string{"V"}
+	for i := 0; i < 2000; i++ {
+		at := random.Intn(len(ranks) + 1)
+		before, after := "", ""
+		if at > 0 {
+			before = ranks[at-1]
+		}
+		if at < len(ranks) {
+			after = ranks[at]
+		}
+		next, err := rank.Between(before, after)
+		require.NoError(t, err)
+		ranks = append(ranks[:at], append([]string{next}, ranks[at:]...)...)
+	}
+	assert.True(t, sort.StringsAreSorted(ranks))
+
+	// Always squeezing into the same gap is what makes ranks long
+	before, after := "A", "B"
+	for i := 0; i < 50; i++ {
+		next, err := rank.Between(before, after)
+		require.NoError(t, err)
+		after = next
+	}
+	assert.Greater(t, len(after), 8)
+}
+
+func TestRank_AfterStaysShort(t *testing.T) {
+	assert.Equal(t, "V", rank.After(""))
+	assert.Equal(t, "zz", rank.After("zyA"))
+	assert.Equal(t, "z1", rank.After("z"))
+
+	last := ""
+	for i := 0; i < 1000; i++ {
+		next := rank.After(last)
+		require.True(t, rank.Valid(next), next)
+		require.Greater(t, next, last)
+		last = next
+	}
+	assert.LessOrEqual(t, len(last), 18)
+}
+
+func TestRank_Spread(t *testing.T) {
+	assert.Empty(t, rank.Spread(0))
+
+	ranks := rank.Spread(1000)
+	require.Len(t, ranks, 1000)
+	assert.True(t, sort.StringsAreSorted(ranks))
+	for i, r := range ranks {
+		assert.True(t, rank.Valid(r), r)
+		assert.LessOrEqual(t, len(r), 3, r)
+		if i > 0 {
+			assert.NotEqual(t, ranks[i-1], r)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"todo-app/internal/rrule"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, value string) *rrule.Rule {
+	rule, err := rrule.Parse(value)
+	require.NoError(t, err)
+	return rule
+}
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 15:04 MST")
+	}
+	return out
+}
+
+func TestParse_RoundTrips(t *testing.T) {
+	rule := mustParse(t, "RRULE:freq=weekly;INTERVAL=2;BYDAY=MO,th;COUNT=4")
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4", rule.String())
+}
+
+func TestParse_RejectsUnsupportedRules(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=XX",
+	} {
+		_, err := rrule.Parse(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestBetween_WeeklyByDayWithCount(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC) // a Monday
+	rule := mustParse(t, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5")
+
+	got := rule.Between(start, start, start.AddDate(1, 0, 0), 100)
+	assert.Equal(t, []string{
+		"2025-01-06 09:00 UTC",
+		"2025-01-08 09:00 UTC",
+		"2025-01-13 09:00 UTC",
+		"2025-01-15 09:00 UTC",
+		"2025-01-20 09:00 UTC",
+	}, dates(got))
+}
+
+func TestBetween_MonthlyLastFridayUntil(t *testing.T) {
+	start := time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20250430")
+
+	got := rule.Between(start, start, start.AddDate(1, 0, 0), 100)
+	assert.Equal(t, []string{
+		"2025-01-31 17:00 UTC",
+		"2025-02-28 17:00 UTC",
+		"2025-03-28 17:00 UTC",
+		"2025-04-25 17:00 UTC",
+	}, dates(got))
+}
+
+func TestBetween_MonthlySkipsShortMonths(t *testing.T) {
+	start := time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=MONTHLY;COUNT=3")
+
+	got := rule.Between(start, start, start.AddDate(1, 0, 0), 100)
+	assert.Equal(t, []string{"2025-01-31 08:00 UTC", "2025-03-31 08:00 UTC", "2025-05-31 08:00 UTC"}, dates(got))
+}
+
+func TestNext_KeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	start := time.Date(2025, 3, 29, 9, 0, 0, 0, loc) // clocks go forward on the 30th
+	rule := mustParse(t, "FREQ=DAILY;INTERVAL=1")
+
+	next, ok := rule.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, "2025-03-30 09:00 BST", next.Format("2006-01-02 15:04 MST"))
+}
+
+func TestNext_YearlyAndEnd(t *testing.T) {
+	start := time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)
+	rule := mustParse(t, "FREQ=YEARLY;COUNT=2")
+
+	next, ok := rule.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, "2028-02-29 12:00 UTC", next.Format("2006-01-02 15:04 MST"))
+
+	_, ok = rule.Next(start, next)
+	assert.False(t, ok)
+}
@@ -0,0 +1,50 @@
+package unit
+
+import (
+	"testing"
+	"todo-app/internal/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"buy", "milk", "2", "café"}, search.Tokenize("Buy MILK, 2 Café!"))
+}
+
+func TestRank_OrdersByRelevance(t *testing.T) {
+	docs := []search.Document{
+		{Title: "Call the bank", Description: "ask about groceries budget"},
+		{Title: "Buy groceries", Description: "milk and eggs"},
+		{Title: "Walk the dog"},
+	}
+
+	matches := search.Rank("GROCERIES", docs)
+	require.Len(t, matches, 2)
+	assert.Equal(t, 1, matches[0].Index, "title matches outrank description matches")
+	assert.Equal(t, 0, matches[1].Index)
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+}
+
+func TestRank_MatchesPrefixes(t *testing.T) {
+	matches := search.Rank("groc", []search.Document{{Title: "Buy groceries"}, {Title: "Gym"}})
+	require.Len(t, matches, 1)
+	assert.Equal(t, 0, matches[0].Index)
+}
+
+func TestRank_EmptyQuery(t *testing.T) {
+	assert.Empty(t, search.Rank("  ,, ", []search.Document{{Title: "Anything"}}))
+}
+
+func TestHighlight_WrapsMatchesAndEscapes(t *testing.T) {
+	got := search.Highlight("Buy <milk> & Milkshake", "milk", 0)
+	assert.Equal(t, "Buy &lt;<mark>milk</mark>&gt; &amp; <mark>Milkshake</mark>", got)
+}
+
+func TestHighlight_TruncatesAroundFirstMatch(t *testing.T) {
+	text := "aaaa bbbb cccc dddd eeee ffff gggg target hhhh iiii jjjj"
+	got := search.Highlight(text, "target", 20)
+	assert.Contains(t, got, "<mark>target</mark>")
+	assert.True(t, len([]rune(got)) < len([]rune(text))+len("<mark></mark>"))
+	assert.Equal(t, "…", string([]rune(got)[0]))
+}
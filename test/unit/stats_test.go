@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"testing"
+	"time"
+	"todo-app/internal/model"
+	"todo-app/internal/stats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func completedTodo(created, completed time.Time) *model.Todo {
+	return &model.Todo{Completed: true, CreatedAt: created, UpdatedAt: completed, CompletedAt: &completed}
+}
+
+func TestStats_GroupsCompletionsByLocalDay(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, tokyo)
+	window := stats.NewRange(time.Date(2024, 3, 4, 0, 0, 0, 0, tokyo), now, tokyo, now)
+
+	// 23:30 UTC on the 4th is already the 5th in Tokyo
+	late := time.Date(2024, 3, 4, 23, 30, 0, 0, time.UTC)
+	created := late.Add(-2 * time.Hour)
+	due := late.Add(-time.Hour)
+	todos := []*model.Todo{
+		completedTodo(created, late),
+		{Completed: true, CreatedAt: created, DueAt: &due, UpdatedAt: late.Add(4 * time.Hour)},
+		// Before the range, and trashed
+		completedTodo(created, time.Date(2024, 3, 3, 14, 0, 0, 0, time.UTC)),
+		{Completed: true, CreatedAt: created, CompletedAt: &late, DeletedAt: &late},
+	}
+
+	summary := stats.Summarize(stats.Aggregate(todos, window), window)
+	assert.Equal(t, "2024-03-04", summary.From)
+	assert.Equal(t, "2024-03-10", summary.To)
+	assert.Equal(t, "Asia/Tokyo", summary.TimeZone)
+	require.Len(t, summary.Days, 7)
+	assert.Equal(t, 0, summary.Days[0].Completed)
+	assert.Equal(t, 2, summary.Days[1].Completed)
+	assert.Equal(t, 2, summary.Completed)
+	assert.Equal(t, 1, summary.CompletedLate)
+	assert.Equal(t, int64(4*3600), summary.AverageCompletionSeconds)
+}
+
+func TestStats_Streaks(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	window := stats.NewRange(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), now, time.UTC, now)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 8, 0, 0, 0, time.UTC) }
+
+	var todos []*model.Todo
+	for _, d := range []int{1, 2, 3, 4, 6, 7, 8, 9} {
+		todos = append(todos, completedTodo(day(d), day(d)))
+	}
+	summary := stats.Summarize(stats.Aggregate(todos, window), window)
+	assert.Equal(t, 4, summary.LongestStreak)
+	assert.Equal(t, 4, summary.CurrentStreak, "today is not over, so it does not break the streak")
+
+	// A range ending before today needs a completion on its last day
+	past := stats.NewRange(day(1), day(5), time.UTC, now)
+	summary = stats.Summarize(stats.Aggregate(todos, past), past)
+	assert.Equal(t, 4, summary.LongestStreak)
+	assert.Equal(t, 0, summary.CurrentStreak)
+}
+
+func TestStats_CompletionRateAndOverdue(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	window := stats.NewRange(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), now, time.UTC, now)
+	created := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	passed, upcoming, before := now.Add(-time.Hour), now.Add(time.Hour), created.AddDate(0, 0, -10)
+
+	todos := []*model.Todo{
+		completedTodo(created, created.Add(time.Hour)),
+		{CreatedAt: created, DueAt: &passed},
+		{CreatedAt: created, DueAt: &upcoming},
+		{CreatedAt: before, DueAt: &before},
+	}
+	summary := stats.Summarize(stats.Aggregate(todos, window), window)
+	assert.Equal(t, 3, summary.Created)
+	assert.Equal(t, 0.333, summary.CompletionRate)
+	assert.Equal(t, 1, summary.Overdue, "only todos due in the range and already past are overdue")
+}
+
+func TestStats_RangeCoversWholeDaysAcrossDST(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	first := time.Date(2024, 3, 30, 15, 0, 0, 0, london)
+	window := stats.NewRange(first, first.AddDate(0, 0, 1), london, first)
+
+	assert.Equal(t, []string{"2024-03-30", "2024-03-31"}, window.Days())
+	assert.Equal(t, 47*time.Hour, window.End.Sub(window.Start))
+}
@@ -0,0 +1,14 @@
+package unit
+
+import (
+	"testing"
+	"todo-app/internal/worker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSignature_IsHexHMACSHA256(t *testing.T) {
+	signature := worker.WebhookSignature("key", []byte("The quick brown fox jumps over the lazy dog"))
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", signature)
+	assert.NotEqual(t, signature, worker.WebhookSignature("other key", []byte("The quick brown fox jumps over the lazy dog")))
+}
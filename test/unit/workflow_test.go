@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"testing"
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func teamWorkflow() *model.Workflow {
+	return &model.Workflow{
+		States: []model.WorkflowState{
+			{Key: "todo", Name: "To do"},
+			{Key: "in_progress", Name: "In progress"},
+			{Key: "review", Name: "In review"},
+			{Key: "done", Name: "Done", Done: true},
+		},
+		Transitions: []model.WorkflowTransition{
+			{From: "todo", To: "in_progress"},
+			{From: "in_progress", To: "review"},
+			{From: "review", To: "in_progress"},
+			{From: "review", To: "done"},
+		},
+	}
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	workflow := teamWorkflow()
+	assert.NoError(t, model.ValidateWorkflow(workflow.States, workflow.Transitions))
+	assert.NoError(t, model.ValidateWorkflow(workflow.States, nil))
+
+	open := model.WorkflowState{Key: "todo", Name: "To do"}
+	done := model.WorkflowState{Key: "done", Name: "Done", Done: true}
+	for name, c := range map[string]struct {
+		states      []model.WorkflowState
+		transitions []model.WorkflowTransition
+	}{
+		"duplicate key":        {[]model.WorkflowState{open, done, open}, nil},
+		"no done state":        {[]model.WorkflowState{open, {Key: "doing", Name: "Doing"}}, nil},
+		"no open state":        {[]model.WorkflowState{done, {Key: "shipped", Name: "Shipped", Done: true}}, nil},
+		"unknown state":        {[]model.WorkflowState{open, done}, []model.WorkflowTransition{{From: "todo", To: "review"}}},
+		"self transition":      {[]model.WorkflowState{open, done}, []model.WorkflowTransition{{From: "done", To: "done"}}},
+		"duplicate transition": {[]model.WorkflowState{open, done}, []model.WorkflowTransition{{From: "todo", To: "done"}, {From: "todo", To: "done"}}},
+	} {
+		assert.Error(t, model.ValidateWorkflow(c.states, c.transitions), name)
+	}
+}
+
+func TestWorkflow_Allows(t *testing.T) {
+	workflow := teamWorkflow()
+	assert.True(t, workflow.Allows("todo", "in_progress"))
+	assert.True(t, workflow.Allows("review", "in_progress"))
+	assert.False(t, workflow.Allows("todo", "done"))
+	assert.False(t, workflow.Allows("done", "todo"))
+
+	// Without transitions any state can follow any other
+	assert.True(t, model.DefaultWorkflow().Allows("done", "todo"))
+}
+
+func TestWorkflow_StateOf(t *testing.T) {
+	workflow := teamWorkflow()
+	assert.Equal(t, "todo", workflow.FirstState(false))
+	assert.Equal(t, "done", workflow.FirstState(true))
+
+	assert.Equal(t, "review", workflow.StateOf(&model.Todo{Status: "review"}))
+	// Todos from before workflows, or in a state the workflow dropped,
+	// follow their completed flag
+	assert.Equal(t, "todo", workflow.StateOf(&model.Todo{}))
+	assert.Equal(t, "done", workflow.StateOf(&model.Todo{Completed: true}))
+	assert.Equal(t, "done", workflow.StateOf(&model.Todo{Status: "shipped", Completed: true}))
+}